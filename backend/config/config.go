@@ -1,8 +1,11 @@
 package config
 
 import (
+	"errors"
+	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +16,17 @@ type Config struct {
 	FrontendURL string
 	Mail        MailConfig
 	ImageKit    ImageKitConfig // ✅ ADDED
+	TwoFA       TwoFAConfig
+	WebAuthn    WebAuthnConfig
+	Lockout     LockoutConfig
+	OIDC        OIDCConfig
+	Invite      InviteConfig
+	Password    PasswordConfig
+	EmailVerify EmailVerifyConfig
+	LoginAlerts LoginAlertConfig
+	SCIM        SCIMConfig
+	Policy      PolicyConfig
+	Elevation   ElevationConfig
 }
 
 type ServerConfig struct {
@@ -25,10 +39,20 @@ type DatabaseConfig struct {
 }
 
 type JWTConfig struct {
-	AccessSecret  string
-	RefreshSecret string
 	AccessExpiry  time.Duration
 	RefreshExpiry time.Duration
+
+	// Access and 2FA tokens are signed with asymmetric keys from KeyDir
+	// (<kid>.pem each); ActiveKeyID selects the signing key. Refresh
+	// tokens are opaque and need no key.
+	KeyDir      string
+	ActiveKeyID string
+
+	// How long a "not revoked" answer is cached per instance
+	RevocationCacheTTL time.Duration
+
+	// Step-up: sensitive routes need auth_time within this window
+	ReauthMaxAge time.Duration
 }
 
 /* =====================
@@ -53,6 +77,134 @@ type ImageKitConfig struct {
 	Endpoint   string
 }
 
+/* =====================
+   2FA (TOTP)
+===================== */
+
+type TwoFAConfig struct {
+	Issuer        string // shown in authenticator apps
+	EncryptionKey string // encrypts TOTP secrets at rest
+}
+
+/* =====================
+   WebAuthn (Passkeys)
+===================== */
+
+type WebAuthnConfig struct {
+	RPID          string // effective domain, e.g. app.example.com
+	RPDisplayName string
+	RPOrigins     []string // allowed browser origins
+}
+
+/* =====================
+   Brute-force Protection
+===================== */
+
+type LockoutConfig struct {
+	AccountThreshold int           // failures per email before backoff kicks in
+	IPThreshold      int           // failures per client IP before backoff
+	OTPMaxAttempts   int           // wrong guesses before an emailed OTP is burned
+	BaseDelay        time.Duration // first lockout, doubled on every further failure
+	MaxDelay         time.Duration
+	Window           time.Duration // failures older than this are forgotten
+}
+
+/* =====================
+   Staff SSO (OpenID Connect)
+===================== */
+
+type OIDCConfig struct {
+	IssuerURL    string // empty = SSO disabled
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string // must point at /api/v1/auth/oidc/callback
+	Scopes       []string
+
+	GroupsClaim   string   // ID-token claim holding group names
+	AdminGroups   []string // → models.RoleAdmin
+	SupportGroups []string // → models.RoleSupport
+
+	AllowedDomains  []string // optional email domain allowlist
+	JITProvisioning bool     // create unknown staff on first login
+}
+
+func (c OIDCConfig) Enabled() bool {
+	return c.IssuerURL != "" && c.ClientID != ""
+}
+
+/* =====================
+   User Invitations
+===================== */
+
+type InviteConfig struct {
+	TTL time.Duration // how long the set-password link stays valid
+}
+
+/* =====================
+   Email Verification
+===================== */
+
+type EmailVerifyConfig struct {
+	TTL time.Duration // confirmation link lifetime (verify / change address)
+}
+
+/* =====================
+   Login History Alerts
+===================== */
+
+type LoginAlertConfig struct {
+	Enabled bool // mail the user on sign-in from an unseen device / IP range
+}
+
+/* =====================
+   SCIM Provisioning
+===================== */
+
+// Groups are fixed and map onto roles; the IdP pushes members into them
+type SCIMConfig struct {
+	BaseURL      string // prefix for meta.location (public URL of /scim/v2)
+	AdminGroup   string // displayName of the group granting RoleAdmin
+	SupportGroup string // displayName of the group granting RoleSupport
+}
+
+/* =====================
+   Resource Policies
+===================== */
+
+type PolicyConfig struct {
+	// 404 hides that the resource exists; 403 is friendlier to debug
+	DenyStatus int
+}
+
+/* =====================
+   Break-glass Elevation
+===================== */
+
+type ElevationConfig struct {
+	MaxDuration time.Duration // longest elevation a request may ask for
+	RequestTTL  time.Duration // unapproved requests expire after this
+}
+
+/* =====================
+   Password Policy & Hashing
+===================== */
+
+type PasswordConfig struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	BlocklistFile string // one common password per line; empty = no blocklist
+	HistorySize   int    // last N passwords that can't be reused (0 = off)
+
+	Algorithm     string // "argon2id" or "bcrypt"; older hashes upgrade on login
+	BcryptCost    int
+	Argon2Time    uint32
+	Argon2Memory  uint32 // KiB
+	Argon2Threads uint8
+}
+
 func LoadConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
@@ -63,10 +215,13 @@ func LoadConfig() *Config {
 			URL: getEnv("DATABASE_URL", ""),
 		},
 		JWT: JWTConfig{
-			AccessSecret:  getEnv("JWT_ACCESS_SECRET", "access-secret"),
-			RefreshSecret: getEnv("JWT_REFRESH_SECRET", "refresh-secret"),
 			AccessExpiry:  15 * time.Minute,
 			RefreshExpiry: 7 * 24 * time.Hour,
+			KeyDir:        getEnv("JWT_KEY_DIR", ""),
+			ActiveKeyID:   getEnv("JWT_ACTIVE_KID", ""),
+
+			RevocationCacheTTL: time.Duration(getEnvAsInt("JWT_REVOCATION_CACHE_SECONDS", 5)) * time.Second,
+			ReauthMaxAge:       time.Duration(getEnvAsInt("REAUTH_MAX_AGE_SECONDS", 300)) * time.Second,
 		},
 		FrontendURL: getEnv("FRONTEND_URL", "http://localhost:5173"),
 
@@ -83,9 +238,113 @@ func LoadConfig() *Config {
 			PrivateKey: getEnv("IMAGEKIT_PRIVATE_KEY", ""),
 			Endpoint:   getEnv("IMAGEKIT_ENDPOINT", ""),
 		},
+
+		TwoFA: TwoFAConfig{
+			Issuer:        getEnv("TWO_FA_ISSUER", "RBAC App"),
+			EncryptionKey: getEnv("TWO_FA_ENCRYPTION_KEY", ""),
+		},
+
+		WebAuthn: WebAuthnConfig{
+			RPID:          getEnv("WEBAUTHN_RP_ID", "localhost"),
+			RPDisplayName: getEnv("WEBAUTHN_RP_NAME", "RBAC App"),
+			RPOrigins: getEnvAsList(
+				"WEBAUTHN_RP_ORIGINS",
+				[]string{getEnv("FRONTEND_URL", "http://localhost:5173")},
+			),
+		},
+
+		Lockout: LockoutConfig{
+			AccountThreshold: getEnvAsInt("LOCKOUT_ACCOUNT_THRESHOLD", 5),
+			IPThreshold:      getEnvAsInt("LOCKOUT_IP_THRESHOLD", 20),
+			OTPMaxAttempts:   getEnvAsInt("LOCKOUT_OTP_MAX_ATTEMPTS", 5),
+			BaseDelay:        time.Duration(getEnvAsInt("LOCKOUT_BASE_DELAY_SECONDS", 30)) * time.Second,
+			MaxDelay:         time.Duration(getEnvAsInt("LOCKOUT_MAX_DELAY_SECONDS", 3600)) * time.Second,
+			Window:           time.Duration(getEnvAsInt("LOCKOUT_WINDOW_SECONDS", 86400)) * time.Second,
+		},
+
+		OIDC: OIDCConfig{
+			IssuerURL:    getEnv("OIDC_ISSUER_URL", ""),
+			ClientID:     getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:  getEnv("OIDC_REDIRECT_URL", "http://localhost:8080/api/v1/auth/oidc/callback"),
+			Scopes:       getEnvAsList("OIDC_SCOPES", []string{"openid", "email", "profile"}),
+
+			GroupsClaim:   getEnv("OIDC_GROUPS_CLAIM", "groups"),
+			AdminGroups:   getEnvAsList("OIDC_ADMIN_GROUPS", nil),
+			SupportGroups: getEnvAsList("OIDC_SUPPORT_GROUPS", nil),
+
+			AllowedDomains:  getEnvAsList("OIDC_ALLOWED_DOMAINS", nil),
+			JITProvisioning: getEnvAsBool("OIDC_JIT_PROVISIONING", false),
+		},
+
+		Invite: InviteConfig{
+			TTL: time.Duration(getEnvAsInt("INVITE_TTL_HOURS", 72)) * time.Hour,
+		},
+
+		EmailVerify: EmailVerifyConfig{
+			TTL: time.Duration(getEnvAsInt("EMAIL_VERIFY_TTL_HOURS", 24)) * time.Hour,
+		},
+
+		LoginAlerts: LoginAlertConfig{
+			Enabled: getEnvAsBool("LOGIN_ALERTS_ENABLED", true),
+		},
+
+		SCIM: SCIMConfig{
+			BaseURL:      getEnv("SCIM_BASE_URL", "/scim/v2"),
+			AdminGroup:   getEnv("SCIM_ADMIN_GROUP", "Admins"),
+			SupportGroup: getEnv("SCIM_SUPPORT_GROUP", "Support Engineers"),
+		},
+
+		Policy: PolicyConfig{
+			DenyStatus: getEnvAsInt("POLICY_DENY_STATUS", 404),
+		},
+
+		Elevation: ElevationConfig{
+			MaxDuration: time.Duration(getEnvAsInt("ELEVATION_MAX_MINUTES", 60)) * time.Minute,
+			RequestTTL:  time.Duration(getEnvAsInt("ELEVATION_REQUEST_TTL_MINUTES", 30)) * time.Minute,
+		},
+
+		Password: PasswordConfig{
+			MinLength:     getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+			RequireUpper:  getEnvAsBool("PASSWORD_REQUIRE_UPPER", true),
+			RequireLower:  getEnvAsBool("PASSWORD_REQUIRE_LOWER", true),
+			RequireDigit:  getEnvAsBool("PASSWORD_REQUIRE_DIGIT", true),
+			RequireSymbol: getEnvAsBool("PASSWORD_REQUIRE_SYMBOL", false),
+			BlocklistFile: getEnv("PASSWORD_BLOCKLIST_FILE", "config/password_blocklist.txt"),
+			HistorySize:   getEnvAsInt("PASSWORD_HISTORY_SIZE", 5),
+
+			Algorithm:     getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+			BcryptCost:    getEnvAsInt("PASSWORD_BCRYPT_COST", 12),
+			Argon2Time:    uint32(getEnvAsInt("PASSWORD_ARGON2_TIME", 3)),
+			Argon2Memory:  uint32(getEnvAsInt("PASSWORD_ARGON2_MEMORY_KB", 64*1024)),
+			Argon2Threads: uint8(getEnvAsInt("PASSWORD_ARGON2_THREADS", 2)),
+		},
 	}
 }
 
+/* =====================
+   Startup Checks
+===================== */
+
+// Secrets with no safe default. Development gets a throwaway value (a
+// fixed key, an ephemeral JWT signing key); anywhere else a missing one
+// stops startup
+func (c *Config) Validate() error {
+	// Ephemeral keys die with the process and differ per replica
+	if c.JWT.KeyDir == "" && c.Server.Env != "development" {
+		return errors.New("JWT_KEY_DIR must be set outside development")
+	}
+
+	if c.TwoFA.EncryptionKey == "" {
+		if c.Server.Env != "development" {
+			return errors.New("TWO_FA_ENCRYPTION_KEY must be set outside development")
+		}
+		log.Println("⚠️  TWO_FA_ENCRYPTION_KEY not set, using the development key")
+		c.TwoFA.EncryptionKey = "two-fa-secret"
+	}
+	return nil
+}
+
 /* =====================
    Helpers
 ===================== */
@@ -105,3 +364,27 @@ func getEnvAsInt(key string, fallback int) int {
 	}
 	return fallback
 }
+
+func getEnvAsBool(key string, fallback bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
+
+func getEnvAsList(key string, fallback []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
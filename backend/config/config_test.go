@@ -0,0 +1,27 @@
+package config
+
+import "testing"
+
+func TestValidateRequiresTwoFAKeyOutsideDevelopment(t *testing.T) {
+	cfg := &Config{Server: ServerConfig{Env: "production"}, JWT: JWTConfig{KeyDir: "/etc/keys"}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("production started without TWO_FA_ENCRYPTION_KEY")
+	}
+
+	cfg.Server.Env = "development"
+	if err := cfg.Validate(); err != nil || cfg.TwoFA.EncryptionKey == "" {
+		t.Fatalf("development: %v", err)
+	}
+}
+
+func TestValidateRequiresJWTKeysOutsideDevelopment(t *testing.T) {
+	cfg := &Config{Server: ServerConfig{Env: "production"}, TwoFA: TwoFAConfig{EncryptionKey: "k"}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("production started with an ephemeral JWT signing key")
+	}
+
+	cfg.Server.Env = "development"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("development: %v", err)
+	}
+}
@@ -10,12 +10,29 @@ import (
 func Migrate(db *gorm.DB) {
 	err := db.AutoMigrate(
 		&models.User{},
+		&models.RoleDefinition{},
 		&models.RefreshToken{},
 		&models.PasswordResetToken{},
 		&models.TwoFAOTP{},
 		&models.RememberedDevice{},
+		&models.RecoveryCode{},
+		&models.PasswordHistory{},
+		&models.EmailVerificationToken{},
+		&models.LoginThrottle{},
+		&models.LoginEvent{},
+		&models.RevokedAccessToken{},
+		&models.UserTokenCutoff{},
+		&models.WebAuthnCredential{},
+		&models.WebAuthnSession{},
+		&models.OIDCLoginState{},
+		&models.APIKey{},
+		&models.Invitation{},
 		&models.Customer{},
+		&models.CustomerMember{},
 		&models.SupportEngineer{},
+		&models.SupportTeam{},
+		&models.SupportTeamMember{},
+		&models.ElevationGrant{},
 		&models.Brand{},
 		&models.Category{},
 		&models.Model{},
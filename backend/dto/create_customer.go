@@ -3,7 +3,7 @@ package dto
 type CreateCustomerRequest struct {
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8"`
+	Password string `json:"password" binding:"required"`
 
 	Company string `json:"company" binding:"required"`
 	Phone   string `json:"phone"`
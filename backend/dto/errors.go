@@ -0,0 +1,69 @@
+package dto
+
+// Machine-readable error codes returned next to "error" on auth endpoints.
+// The frontend switches on these; the message text may change freely.
+type ErrorCode string
+
+const (
+	CodeInvalidRequest ErrorCode = "invalid_request"
+
+	// Login / credentials
+	CodeInvalidCredentials    ErrorCode = "invalid_credentials"
+	CodePasswordResetRequired ErrorCode = "password_reset_required"
+	CodeAccountLocked         ErrorCode = "account_locked"
+	CodeAccountInactive       ErrorCode = "account_inactive"
+
+	// Second factor
+	CodeInvalidOTP          ErrorCode = "invalid_otp"
+	CodeInvalidRecoveryCode ErrorCode = "invalid_recovery_code"
+	CodeTwoFANotEnabled     ErrorCode = "two_fa_not_enabled"
+	CodeTwoFASessionInvalid ErrorCode = "two_fa_session_invalid"
+
+	// Tokens / passwords
+	CodeInvalidRefreshToken ErrorCode = "invalid_refresh_token"
+	CodeInvalidResetToken   ErrorCode = "invalid_reset_token"
+	CodeInvalidOldPassword  ErrorCode = "invalid_old_password"
+	CodeWeakPassword        ErrorCode = "weak_password"
+	CodePasswordReused      ErrorCode = "password_reused"
+
+	// Email verification / change
+	CodeInvalidVerificationToken ErrorCode = "invalid_verification_token"
+	CodeEmailTaken               ErrorCode = "email_taken"
+	CodeEmailAlreadyVerified     ErrorCode = "email_already_verified"
+
+	// Roles / permissions
+	CodeRoleExists   ErrorCode = "role_exists"
+	CodeRoleInUse    ErrorCode = "role_in_use"
+	CodeRoleReadOnly ErrorCode = "role_read_only"
+	CodeInvalidRole  ErrorCode = "invalid_role"
+	CodeLastAdmin    ErrorCode = "last_admin"
+
+	// Support teams
+	CodeTeamExists    ErrorCode = "team_exists"
+	CodeNotAnEngineer ErrorCode = "not_an_engineer"
+	CodeNotTeamMember ErrorCode = "not_team_member"
+
+	// Break-glass elevation
+	CodeElevationOpen     ErrorCode = "elevation_open"
+	CodeElevationInactive ErrorCode = "elevation_inactive"
+	CodeElevationExpired  ErrorCode = "elevation_expired" // elevated token's grant is over
+
+	// Access token / authorization
+	CodeTokenMissing   ErrorCode = "token_missing"
+	CodeTokenInvalid   ErrorCode = "token_invalid"
+	CodeTokenRevoked   ErrorCode = "token_revoked"
+	CodeReauthRequired ErrorCode = "reauth_required"
+	CodeForbidden      ErrorCode = "forbidden"
+	CodeNotFound       ErrorCode = "not_found"
+	CodeRateLimited    ErrorCode = "rate_limited"
+
+	CodeBadRequest         ErrorCode = "bad_request"
+	CodeServiceUnavailable ErrorCode = "service_unavailable"
+	CodeInternal           ErrorCode = "internal_error"
+)
+
+type ErrorResponse struct {
+	Error      string    `json:"error"`
+	Code       ErrorCode `json:"code"`
+	RetryAfter int       `json:"retry_after,omitempty"` // seconds, with 429
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"rbac/service"
+)
+
+type AccessHandler struct {
+	service *service.AccessService
+}
+
+func NewAccessHandler(service *service.AccessService) *AccessHandler {
+	return &AccessHandler{service: service}
+}
+
+type ExplainAccessRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+	Method string    `json:"method" binding:"required"`
+	Path   string    `json:"path" binding:"required"` // e.g. /api/v1/tickets/<id>
+}
+
+// Admin: GET /admin/access/routes → every route and what it requires
+func (h *AccessHandler) Manifest(c *gin.Context) {
+	manifest, err := h.service.Manifest()
+	if err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, manifest)
+}
+
+// Admin: POST /admin/access/explain → would this user get through, and
+// if not, which requirement stops them
+func (h *AccessHandler) Explain(c *gin.Context) {
+	var req ExplainAccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c)
+		return
+	}
+
+	explanation, err := h.service.Explain(req.UserID, req.Method, req.Path)
+	if err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, explanation)
+}
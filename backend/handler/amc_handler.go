@@ -43,6 +43,22 @@ func (h *AMCHandler) GetMyAMCs(c *gin.Context) {
 
 	c.JSON(http.StatusOK, amcs)
 }
+// GET /amc/:id (owner, same company, or amc.read)
+func (h *AMCHandler) GetAMC(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid amc id"})
+		return
+	}
+
+	amc, err := h.service.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "amc not found"})
+		return
+	}
+	c.JSON(http.StatusOK, amc)
+}
+
 func (h *AMCHandler) Create(c *gin.Context) {
 	var req struct {
 		CustomerProductID uuid.UUID `json:"customer_product_id"`
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"rbac/models"
+	"rbac/service"
+)
+
+type APIKeyHandler struct {
+	service *service.APIKeyService
+}
+
+func NewAPIKeyHandler(service *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+type CreateAPIKeyRequest struct {
+	Name               string            `json:"name" binding:"required"`
+	Scopes             []models.APIScope `json:"scopes" binding:"required,min=1"`
+	ExpiresAt          *time.Time        `json:"expires_at"`
+	RateLimitPerMinute int               `json:"rate_limit_per_minute" binding:"omitempty,min=1,max=10000"`
+}
+
+// Admin: POST /admin/api-keys → raw key returned ONCE
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	raw, info, err := h.service.Create(
+		req.Name,
+		req.Scopes,
+		req.ExpiresAt,
+		req.RateLimitPerMinute,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"api_key": raw,
+		"key":     info,
+	})
+}
+
+// Admin: GET /admin/api-keys
+func (h *APIKeyHandler) List(c *gin.Context) {
+	keys, err := h.service.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch api keys"})
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// Admin: DELETE /admin/api-keys/:id
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid api key id"})
+		return
+	}
+
+	if err := h.service.Revoke(
+		id,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "api key revoked"})
+}
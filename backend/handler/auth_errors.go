@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"rbac/dto"
+	"rbac/service"
+)
+
+/*
+=====================
+ Auth Error Responses
+=====================
+ Every auth endpoint answers {"error": "...", "code": "..."}
+*/
+
+func authError(c *gin.Context, status int, code dto.ErrorCode, message string) {
+	c.JSON(status, dto.ErrorResponse{Error: message, Code: code})
+}
+
+func invalidRequest(c *gin.Context) {
+	authError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "invalid request")
+}
+
+// Maps service errors to status + code; unknown errors keep fallbackStatus
+func respondAuthError(c *gin.Context, err error, fallbackStatus int) {
+	var locked *service.LockedError
+	if errors.As(err, &locked) {
+		secs := int(math.Ceil(locked.RetryAfter.Seconds()))
+		if secs < 1 {
+			secs = 1
+		}
+
+		c.Header("Retry-After", strconv.Itoa(secs))
+		c.JSON(http.StatusTooManyRequests, dto.ErrorResponse{
+			Error:      "too many attempts, try again later",
+			Code:       dto.CodeAccountLocked,
+			RetryAfter: secs,
+		})
+		return
+	}
+
+	for _, m := range authErrorMap {
+		if errors.Is(err, m.err) {
+			message := err.Error()
+			if m.message != "" {
+				message = m.message
+			}
+			authError(c, m.status, m.code, message)
+			return
+		}
+	}
+
+	code := dto.CodeBadRequest
+	switch fallbackStatus {
+	case http.StatusUnauthorized:
+		code = dto.CodeInvalidCredentials
+	case http.StatusNotFound:
+		code = dto.CodeNotFound
+	case http.StatusServiceUnavailable:
+		code = dto.CodeServiceUnavailable
+	case http.StatusInternalServerError:
+		code = dto.CodeInternal
+	}
+	authError(c, fallbackStatus, code, err.Error())
+}
+
+// Code for redirect-style flows (SSO) that cannot send a JSON body
+func authErrorCode(err error, fallback dto.ErrorCode) dto.ErrorCode {
+	for _, m := range authErrorMap {
+		if errors.Is(err, m.err) {
+			return m.code
+		}
+	}
+	return fallback
+}
+
+var authErrorMap = []struct {
+	err     error
+	status  int
+	code    dto.ErrorCode
+	message string // overrides err.Error() when set
+}{
+	{service.ErrInvalidCredentials, http.StatusUnauthorized, dto.CodeInvalidCredentials, ""},
+	{service.ErrPasswordResetRequired, http.StatusForbidden, dto.CodePasswordResetRequired, ""},
+	{service.ErrAccountInactive, http.StatusForbidden, dto.CodeAccountInactive, ""},
+	{service.ErrUserNotFound, http.StatusNotFound, dto.CodeNotFound, ""},
+
+	{service.ErrInvalidOTP, http.StatusUnauthorized, dto.CodeInvalidOTP, ""},
+	{service.ErrInvalidRecoveryCode, http.StatusUnauthorized, dto.CodeInvalidRecoveryCode, ""},
+	{service.ErrTwoFANotEnabled, http.StatusBadRequest, dto.CodeTwoFANotEnabled, ""},
+
+	{service.ErrInvalidRefreshToken, http.StatusUnauthorized, dto.CodeInvalidRefreshToken, "invalid session"},
+	{service.ErrInvalidResetToken, http.StatusBadRequest, dto.CodeInvalidResetToken, ""},
+	{service.ErrInvalidOldPassword, http.StatusBadRequest, dto.CodeInvalidOldPassword, ""},
+	{service.ErrWeakPassword, http.StatusBadRequest, dto.CodeWeakPassword, ""},
+	{service.ErrPasswordReused, http.StatusBadRequest, dto.CodePasswordReused, ""},
+
+	{service.ErrInvalidVerificationToken, http.StatusBadRequest, dto.CodeInvalidVerificationToken, ""},
+	{service.ErrEmailTaken, http.StatusConflict, dto.CodeEmailTaken, ""},
+	{service.ErrEmailAlreadyVerified, http.StatusBadRequest, dto.CodeEmailAlreadyVerified, ""},
+
+	{service.ErrRoleNotFound, http.StatusNotFound, dto.CodeNotFound, ""},
+	{service.ErrRoleExists, http.StatusConflict, dto.CodeRoleExists, ""},
+	{service.ErrRoleInUse, http.StatusConflict, dto.CodeRoleInUse, ""},
+	{service.ErrRoleReadOnly, http.StatusForbidden, dto.CodeRoleReadOnly, ""},
+	{service.ErrInvalidRole, http.StatusBadRequest, dto.CodeInvalidRole, ""},
+	{service.ErrLastAdmin, http.StatusConflict, dto.CodeLastAdmin, ""},
+	{service.ErrForbidden, http.StatusForbidden, dto.CodeForbidden, ""},
+	{service.ErrResourceNotFound, http.StatusNotFound, dto.CodeNotFound, ""},
+
+	{service.ErrTeamExists, http.StatusConflict, dto.CodeTeamExists, ""},
+	{service.ErrNotAnEngineer, http.StatusBadRequest, dto.CodeNotAnEngineer, ""},
+	{service.ErrNotTeamMember, http.StatusBadRequest, dto.CodeNotTeamMember, ""},
+
+	{service.ErrElevationOpen, http.StatusConflict, dto.CodeElevationOpen, ""},
+	{service.ErrElevationInactive, http.StatusConflict, dto.CodeElevationInactive, ""},
+
+	{service.ErrMailerUnavailable, http.StatusServiceUnavailable, dto.CodeServiceUnavailable, ""},
+}
@@ -1,15 +1,15 @@
 package handler
 
 import (
-	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
 	"rbac/config"
+	"rbac/dto"
+	"rbac/middleware"
 	"rbac/models"
 	"rbac/service"
 )
@@ -47,7 +47,7 @@ type LoginRequest struct {
 
 type ResetPasswordRequest struct {
 	Token       string `json:"token" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required,min=8"`
+	NewPassword string `json:"new_password" binding:"required"`
 }
 type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token"`
@@ -56,16 +56,31 @@ type RefreshRequest struct {
 type CreateUserRequest struct {
 	Name  string      `json:"name" binding:"required"`
 	Email string      `json:"email" binding:"required,email"`
-	Role  models.Role `json:"role" binding:"required,oneof=admin support customer"`
+	Role  models.Role `json:"role" binding:"required"` // any defined role
 
 	Company string `json:"company"`
 	Phone   string `json:"phone"`
 	Address string `json:"address"`
+
+	// Customers only: join an existing organization instead of founding one
+	CustomerID       *uuid.UUID              `json:"customer_id"`
+	IsCustomerAdmin  bool                    `json:"is_customer_admin"`
+	TicketVisibility models.TicketVisibility `json:"ticket_visibility" binding:"omitempty,oneof=own all"`
+}
+
+type TwoFAMethodRequest struct {
+	Method models.TwoFAMethod `json:"method" binding:"omitempty,oneof=email totp webauthn"`
+}
+
+// Step-up: either or both
+type ReauthRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code" binding:"omitempty,len=6"`
 }
 
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required,min=8"`
+	NewPassword string `json:"new_password" binding:"required"`
 }
 
 /* =====================
@@ -86,6 +101,22 @@ func (h *AuthHandler) setRefreshCookie(c *gin.Context, token string) {
 	)
 }
 
+func (h *AuthHandler) setRememberDeviceCookie(c *gin.Context, token string) {
+	c.SetCookie(
+		"remember_device",
+		token,
+		30*24*3600,
+		"/",
+		"",
+		true, // Secure
+		true, // HttpOnly
+	)
+	c.Writer.Header().Add(
+		"Set-Cookie",
+		"remember_device="+token+"; SameSite=Strict",
+	)
+}
+
 func (h *AuthHandler) clearRefreshCookie(c *gin.Context) {
 	secure := h.cfg.Server.Env == "production"
 
@@ -108,13 +139,11 @@ func (h *AuthHandler) clearRefreshCookie(c *gin.Context) {
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid request",
-		})
+		invalidRequest(c)
 		return
 	}
 
-	resp, err := h.service.Login(
+	result, err := h.service.Login(
 		c,
 		req.Email,
 		req.Password,
@@ -122,35 +151,24 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		c.ClientIP(),
 		c.GetHeader("User-Agent"),
 	)
-	fmt.Println("RememberDevice:", req.RememberDevice)
-	// 🔐 2FA required
-	if err != nil && strings.HasPrefix(err.Error(), "TWO_FA_REQUIRED:") {
-		token := strings.TrimPrefix(err.Error(), "TWO_FA_REQUIRED:")
-
-		c.JSON(http.StatusOK, gin.H{
-			"two_fa_required": true,
-			"two_fa_token":    token,
-		})
-		return
-	}
-
-	// 🔒 Password reset required
-	if err != nil && err.Error() == "PASSWORD_RESET_REQUIRED" {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "password_reset_required",
-		})
+	// ❌ Invalid credentials / locked / inactive / reset required
+	if err != nil {
+		respondAuthError(c, err, http.StatusUnauthorized)
 		return
 	}
 
-	// ❌ Invalid credentials
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "invalid credentials",
+	// 🔐 2FA required
+	if result.TwoFA != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"two_fa_required": true,
+			"two_fa_method":   result.TwoFA.Method,
+			"two_fa_token":    result.TwoFA.Token,
 		})
 		return
 	}
 
 	// ✅ Normal login success
+	resp := result.Tokens
 	h.setRefreshCookie(c, resp.RefreshToken)
 
 	c.JSON(http.StatusOK, gin.H{
@@ -165,7 +183,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	if err != nil {
 		var req RefreshRequest
 		if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "refresh token required"})
+			authError(c, http.StatusBadRequest, dto.CodeInvalidRefreshToken, "refresh token required")
 			return
 		}
 		refreshToken = req.RefreshToken
@@ -177,7 +195,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		c.GetHeader("User-Agent"),
 	)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid session"})
+		respondAuthError(c, err, http.StatusUnauthorized)
 		return
 	}
 
@@ -191,19 +209,19 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 
 // Logout
 func (h *AuthHandler) Logout(c *gin.Context) {
-	refreshToken, err := c.Cookie("refresh_token")
-	if err == nil {
-		userID := c.MustGet("user_id").(uuid.UUID)
-		role := c.MustGet("user_role").(models.Role)
+	refreshToken, _ := c.Cookie("refresh_token")
+	userID := c.MustGet("user_id").(uuid.UUID)
+	role := c.MustGet("user_role").(models.Role)
 
-		_ = h.service.Logout(
-			refreshToken,
-			userID,
-			role,
-			c.ClientIP(),
-			c.GetHeader("User-Agent"),
-		)
-	}
+	_ = h.service.Logout(
+		refreshToken,
+		c.GetString("token_jti"),
+		c.GetTime("token_expires_at"),
+		userID,
+		role,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
 
 	h.clearRefreshCookie(c)
 	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
@@ -213,13 +231,26 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 func (h *AuthHandler) CreateUser(c *gin.Context) {
 	var req CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		invalidRequest(c)
 		return
 	}
 
 	createdBy := c.MustGet("user_id").(uuid.UUID)
 
-	user, err := h.service.CreateUser(
+	var seat *service.OrganizationSeat
+	if req.CustomerID != nil {
+		visibility := req.TicketVisibility
+		if visibility == "" {
+			visibility = models.TicketVisibilityOwn
+		}
+		seat = &service.OrganizationSeat{
+			CustomerID:       *req.CustomerID,
+			IsAdmin:          req.IsCustomerAdmin,
+			TicketVisibility: visibility,
+		}
+	}
+
+	user, invitation, err := h.service.CreateUser(
 		req.Name,
 		req.Email,
 		req.Role,
@@ -230,17 +261,25 @@ func (h *AuthHandler) CreateUser(c *gin.Context) {
 		req.Company,
 		req.Phone,
 		req.Address,
+
+		seat,
 	)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondAuthError(c, err, http.StatusBadRequest)
 		return
 	}
 
+	message := "User created. Password setup email sent."
+	if !invitation.EmailSent {
+		message = "User created, but the invitation email could not be sent. Resend it from the invitations list."
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
-		"id":      user.ID,
-		"email":   user.Email,
-		"role":    user.Role,
-		"message": "User created. Password setup email sent.",
+		"id":         user.ID,
+		"email":      user.Email,
+		"role":       user.Role,
+		"invitation": invitation,
+		"message":    message,
 	})
 }
 
@@ -248,7 +287,7 @@ func (h *AuthHandler) CreateUser(c *gin.Context) {
 func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	var req ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		invalidRequest(c)
 		return
 	}
 
@@ -264,7 +303,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		c.GetHeader("User-Agent"),
 	)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondAuthError(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -278,29 +317,39 @@ func (h *AuthHandler) GetMe(c *gin.Context) {
 
 	user, err := h.service.GetUserByID(userID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "user not found",
-		})
+		authError(c, http.StatusUnauthorized, dto.CodeNotFound, "user not found")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"id":    user.ID,
 		"name":  user.Name, // ✅ FROM DB
 		"email": user.Email,
 		"role":  user.Role,
-	})
+
+		"email_verified": user.EmailVerifiedAt != nil,
+	}
+
+	// 🎭 Lets the UI show a "viewing as …" banner
+	if actorID, ok := c.Get("actor_id"); ok {
+		resp["impersonated_by"] = gin.H{
+			"id":    actorID,
+			"email": c.GetString("actor_email"),
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	var req ResetPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		invalidRequest(c)
 		return
 	}
 
 	if err := h.service.ResetPassword(req.Token, req.NewPassword); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondAuthError(c, err, http.StatusBadRequest)
 		return
 	}
 
@@ -311,7 +360,7 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	var req ForgotPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		invalidRequest(c)
 		return
 	}
 
@@ -329,6 +378,12 @@ type VerifyOTPRequest struct {
 	Code string `json:"code" binding:"required,len=6"`
 }
 
+// Either the OTP or a one-time recovery code
+type Verify2FACodeRequest struct {
+	Code         string `json:"code" binding:"omitempty,len=6"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
 // func (h *AuthHandler) Verify2FA(c *gin.Context) {
 // 	userID := c.MustGet("temp_user_id").(uuid.UUID)
 
@@ -355,7 +410,7 @@ type VerifyOTPRequest struct {
 // func (h *AuthHandler) Verify2FA(c *gin.Context) {
 // 	var req Verify2FARequest
 // 	if err := c.ShouldBindJSON(&req); err != nil {
-// 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+// 		invalidRequest(c)
 // 		return
 // 	}
 
@@ -377,29 +432,162 @@ type VerifyOTPRequest struct {
 func (h *AuthHandler) Enable2FA(c *gin.Context) {
 	userID := c.MustGet("user_id").(uuid.UUID)
 
-	if err := h.service.Enable2FA(userID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+	// Body is optional → defaults to email OTP
+	var req TwoFAMethodRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			invalidRequest(c)
+			return
+		}
+	}
+
+	codes, err := h.service.Enable2FA(
+		userID,
+		req.Method,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"message": "2FA enabled successfully",
-	})
+	}
+	// Only present the first time → shown once, never again
+	if len(codes) > 0 {
+		resp["recovery_codes"] = codes
+	}
+
+	c.JSON(http.StatusOK, resp)
 }
 func (h *AuthHandler) Disable2FA(c *gin.Context) {
 	userID := c.MustGet("user_id").(uuid.UUID)
 
-	if err := h.service.Disable2FA(userID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+	// {"method":"totp"} → remove authenticator only, empty → disable 2FA
+	var req TwoFAMethodRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			invalidRequest(c)
+			return
+		}
+	}
+
+	if err := h.service.Disable2FA(
+		userID,
+		req.Method,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
 		return
 	}
 
+	message := "2FA disabled successfully"
+	if req.Method == models.TwoFAMethodTOTP {
+		message = "authenticator app removed, email OTP is now active"
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "2FA disabled successfully",
+		"message": message,
+	})
+}
+
+// Authenticator app: start enrollment (QR provisioning URI)
+func (h *AuthHandler) SetupTOTP(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	setup, err := h.service.SetupTOTP(
+		userID,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// Authenticator app: confirm enrollment with the first code
+func (h *AuthHandler) ConfirmTOTP(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	var req VerifyOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c)
+		return
+	}
+
+	codes, err := h.service.ConfirmTOTP(
+		userID,
+		req.Code,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	resp := gin.H{
+		"message": "authenticator app enabled",
+	}
+	if len(codes) > 0 {
+		resp["recovery_codes"] = codes
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Recovery codes: how many are left
+func (h *AuthHandler) GetRecoveryCodes(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	remaining, err := h.service.CountRecoveryCodes(userID)
+	if err != nil {
+		authError(c, http.StatusInternalServerError, dto.CodeInternal, "failed to fetch recovery codes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"remaining": remaining,
+	})
+}
+
+// Recovery codes: invalidate the old set and issue a new one
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	codes, err := h.service.RegenerateRecoveryCodes(
+		userID,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recovery_codes": codes,
+		"message":        "store these codes somewhere safe, they will not be shown again",
+	})
+}
+
+// Pending 2FA session: email a code instead of using the authenticator
+func (h *AuthHandler) SendEmailOTP(c *gin.Context) {
+	userID := c.MustGet("2fa_user_id").(uuid.UUID)
+
+	if err := h.service.SendEmailOTP(userID); err != nil {
+		respondAuthError(c, err, http.StatusServiceUnavailable)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "code sent to your email",
 	})
 }
 
@@ -447,43 +635,28 @@ func (h *AuthHandler) Verify2FA(c *gin.Context) {
 	userID := c.MustGet("2fa_user_id").(uuid.UUID)
 	remember := c.MustGet("2fa_remember").(bool)
 
-	var req VerifyOTPRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid request",
-		})
+	var req Verify2FACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Code == "" && req.RecoveryCode == "") {
+		invalidRequest(c)
 		return
 	}
 
 	resp, rememberToken, err := h.service.Verify2FA(
 		userID,
 		req.Code,
+		req.RecoveryCode,
 		remember,
 		c.ClientIP(),
 		c.GetHeader("User-Agent"),
 	)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": err.Error(),
-		})
+		respondAuthError(c, err, http.StatusUnauthorized)
 		return
 	}
 
 	// 🍪 Set remember-device cookie (ONLY if requested)
 	if rememberToken != nil {
-		c.SetCookie(
-			"remember_device",
-			*rememberToken,
-			30*24*3600,
-			"/",
-			"",
-			true, // Secure
-			true, // HttpOnly
-		)
-		c.Writer.Header().Add(
-			"Set-Cookie",
-			"remember_device="+*rememberToken+"; SameSite=Strict",
-		)
+		h.setRememberDeviceCookie(c, *rememberToken)
 	}
 
 	h.setRefreshCookie(c, resp.RefreshToken)
@@ -516,7 +689,7 @@ func (h *AuthHandler) GetAllUsers(c *gin.Context) {
 		}
 	}
 
-	users, total, err := h.service.GetUsersPaginated(page)
+	users, total, err := h.service.GetUsersPaginated(page, c.Query("unverified") == "true")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to fetch users",
@@ -540,3 +713,108 @@ func (h *AuthHandler) GetSupportEngineers(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, users)
 }
+
+// Admin: clear login / 2FA lockout for a user
+func (h *AuthHandler) UnlockUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		authError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "invalid user id")
+		return
+	}
+
+	if err := h.service.UnlockUser(
+		userID,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account unlocked"})
+}
+
+// Step-up: fresh auth_time for routes behind RequireRecentAuth
+func (h *AuthHandler) Reauthenticate(c *gin.Context) {
+	var req ReauthRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Password == "" && req.Code == "") {
+		invalidRequest(c)
+		return
+	}
+
+	session, _ := c.Get(middleware.CtxSessionID)
+	sessionID, _ := session.(uuid.UUID)
+
+	resp, err := h.service.Reauthenticate(
+		c.MustGet("user_id").(uuid.UUID),
+		sessionID,
+		req.Password,
+		req.Code,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusUnauthorized)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Step-up with email OTP: send the code first
+func (h *AuthHandler) SendReauthOTP(c *gin.Context) {
+	if err := h.service.SendReauthOTP(c.MustGet("user_id").(uuid.UUID)); err != nil {
+		respondAuthError(c, err, http.StatusServiceUnavailable)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "code sent to your email",
+	})
+}
+
+// Admin: start "view as user" (short-lived, read-only token)
+func (h *AuthHandler) Impersonate(c *gin.Context) {
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		authError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "invalid user id")
+		return
+	}
+
+	resp, err := h.service.Impersonate(
+		c.MustGet("user_id").(uuid.UUID),
+		targetID,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Stop impersonating (called with the impersonation token)
+func (h *AuthHandler) EndImpersonation(c *gin.Context) {
+	actorID, ok := c.Get("actor_id")
+	if !ok {
+		authError(c, http.StatusBadRequest, dto.CodeBadRequest, "not impersonating")
+		return
+	}
+
+	if err := h.service.EndImpersonation(
+		c.GetString("token_jti"),
+		c.GetTime("token_expires_at"),
+		c.MustGet("user_id").(uuid.UUID),
+		actorID.(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		authError(c, http.StatusInternalServerError, dto.CodeInternal, "failed to end impersonation")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "impersonation ended"})
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -24,7 +25,7 @@ func (h *CustomerHandler) Create(c *gin.Context) {
 	var req struct {
 		Name     string `json:"name" binding:"required"`
 		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required,min=8"`
+		Password string `json:"password" binding:"required"`
 		Company  string `json:"company" binding:"required"`
 		Phone    string `json:"phone"`
 		Address  string `json:"address"`
@@ -45,6 +46,10 @@ func (h *CustomerHandler) Create(c *gin.Context) {
 		req.Phone,
 		req.Address,
 	); err != nil {
+		if errors.Is(err, service.ErrWeakPassword) {
+			respondAuthError(c, err, http.StatusBadRequest)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to create customer",
 		})
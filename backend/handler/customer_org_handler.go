@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"rbac/models"
+	"rbac/service"
+)
+
+type CustomerOrgHandler struct {
+	service *service.CustomerOrgService
+}
+
+func NewCustomerOrgHandler(service *service.CustomerOrgService) *CustomerOrgHandler {
+	return &CustomerOrgHandler{service: service}
+}
+
+type InviteMemberRequest struct {
+	Name             string                  `json:"name" binding:"required"`
+	Email            string                  `json:"email" binding:"required,email"`
+	IsAdmin          bool                    `json:"is_admin"`
+	TicketVisibility models.TicketVisibility `json:"ticket_visibility" binding:"omitempty,oneof=own all"`
+}
+
+type UpdateMemberRequest struct {
+	IsAdmin          *bool                    `json:"is_admin"`
+	TicketVisibility *models.TicketVisibility `json:"ticket_visibility" binding:"omitempty,oneof=own all"`
+}
+
+// GET /customer/organization
+func (h *CustomerOrgHandler) Organization(c *gin.Context) {
+	org, err := h.service.Organization(c.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, org)
+}
+
+// Customer-admin: GET /customer/members
+func (h *CustomerOrgHandler) ListMembers(c *gin.Context) {
+	members, err := h.service.ListMembers(c.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// Customer-admin: POST /customer/members
+func (h *CustomerOrgHandler) InviteMember(c *gin.Context) {
+	var req InviteMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c)
+		return
+	}
+
+	user, invitation, err := h.service.InviteMember(
+		c.MustGet("user_id").(uuid.UUID),
+		req.Name,
+		req.Email,
+		req.TicketVisibility,
+		req.IsAdmin,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	message := "Member invited. Password setup email sent."
+	if !invitation.EmailSent {
+		message = "Member invited, but the invitation email could not be sent."
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"id":         user.ID,
+		"email":      user.Email,
+		"invitation": invitation,
+		"message":    message,
+	})
+}
+
+// Customer-admin: PATCH /customer/members/:userId
+func (h *CustomerOrgHandler) UpdateMember(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		invalidRequest(c)
+		return
+	}
+
+	var req UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c)
+		return
+	}
+
+	if err := h.service.UpdateMember(
+		c.MustGet("user_id").(uuid.UUID),
+		userID,
+		req.TicketVisibility,
+		req.IsAdmin,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "member updated"})
+}
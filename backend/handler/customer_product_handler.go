@@ -97,3 +97,20 @@ func (h *CustomerProductHandler) GetCustomerProducts(c *gin.Context) {
 
 	c.JSON(http.StatusOK, products)
 }
+
+// GET /customer-products/:id (owner, same company, or product.read)
+func (h *CustomerProductHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	product, err := h.service.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "customer product not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"rbac/middleware"
+	"rbac/models"
+	"rbac/service"
+	"rbac/utils"
+)
+
+type ElevationHandler struct {
+	service *service.ElevationService
+}
+
+func NewElevationHandler(service *service.ElevationService) *ElevationHandler {
+	return &ElevationHandler{service: service}
+}
+
+// role defaults to admin; duration_minutes 0 = the configured maximum
+type ElevationRequest struct {
+	Role            models.Role `json:"role"`
+	Reason          string      `json:"reason" binding:"required"`
+	DurationMinutes int         `json:"duration_minutes"`
+}
+
+// Staff: POST /elevation
+func (h *ElevationHandler) Request(c *gin.Context) {
+	var req ElevationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c)
+		return
+	}
+
+	grant, err := h.service.Request(
+		c.MustGet("user_id").(uuid.UUID),
+		req.Role,
+		req.Reason,
+		req.DurationMinutes,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusCreated, grant)
+}
+
+// Staff: GET /elevation
+func (h *ElevationHandler) Mine(c *gin.Context) {
+	grants, err := h.service.Mine(c.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, grants)
+}
+
+// Staff: POST /elevation/:id/token
+func (h *ElevationHandler) Token(c *gin.Context) {
+	grantID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	// Same auth_time / amr as the session asking, so step-up carries over
+	auth := utils.AuthContext{Time: c.GetTime(middleware.CtxAuthTime)}
+	if session, ok := c.Get(middleware.CtxSessionID); ok {
+		auth.Session = session.(uuid.UUID)
+	}
+	if methods, ok := c.Get(middleware.CtxAuthMethods); ok {
+		auth.Methods, _ = methods.([]string)
+	}
+
+	token, err := h.service.Token(grantID, c.MustGet("user_id").(uuid.UUID), auth)
+	if err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+// Staff: POST /elevation/:id/end
+func (h *ElevationHandler) End(c *gin.Context) {
+	grantID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.End(
+		grantID,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "elevation ended"})
+}
+
+// Admin: GET /admin/elevations?status=pending
+func (h *ElevationHandler) List(c *gin.Context) {
+	grants, err := h.service.List(models.ElevationStatus(c.Query("status")))
+	if err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, grants)
+}
+
+// Admin: POST /admin/elevations/:id/approve
+func (h *ElevationHandler) Approve(c *gin.Context) {
+	grantID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	grant, err := h.service.Approve(
+		grantID,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, grant)
+}
+
+// Admin: POST /admin/elevations/:id/deny
+func (h *ElevationHandler) Deny(c *gin.Context) {
+	grantID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Deny(
+		grantID,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "elevation denied"})
+}
+
+// Admin: POST /admin/elevations/:id/revoke
+func (h *ElevationHandler) Revoke(c *gin.Context) {
+	grantID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Revoke(
+		grantID,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "elevation revoked"})
+}
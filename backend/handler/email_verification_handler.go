@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"rbac/service"
+)
+
+type EmailVerificationHandler struct {
+	service *service.EmailVerificationService
+}
+
+func NewEmailVerificationHandler(service *service.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{service: service}
+}
+
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+}
+
+type ConfirmEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// POST /profile/email → confirmation link to the new address
+func (h *EmailVerificationHandler) RequestChange(c *gin.Context) {
+	var req ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c)
+		return
+	}
+
+	if err := h.service.RequestChange(
+		c.MustGet("user_id").(uuid.UUID),
+		req.NewEmail,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "confirmation link sent to the new address",
+	})
+}
+
+// POST /profile/email/verification → (re)send link for the current address
+func (h *EmailVerificationHandler) SendVerification(c *gin.Context) {
+	if err := h.service.SendVerification(c.MustGet("user_id").(uuid.UUID)); err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "verification link sent",
+	})
+}
+
+// POST /auth/verify-email (public, from the mailed link)
+func (h *EmailVerificationHandler) Confirm(c *gin.Context) {
+	var req ConfirmEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c)
+		return
+	}
+
+	result, err := h.service.Confirm(req.Token, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
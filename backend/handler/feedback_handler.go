@@ -17,12 +17,15 @@ func NewFeedbackHandler(s *service.FeedbackService) *FeedbackHandler {
 
 func (h *FeedbackHandler) Submit(c *gin.Context) {
 
-	ticketID := uuid.MustParse(c.Param("id"))
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid ticket id"})
+		return
+	}
 
 	var req struct {
-		EngineerID uuid.UUID `json:"engineer_id"`
-		Rating     int       `json:"rating"`
-		Comment    string    `json:"comment"`
+		Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+		Comment string `json:"comment"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -32,7 +35,6 @@ func (h *FeedbackHandler) Submit(c *gin.Context) {
 
 	if err := h.service.Submit(
 		ticketID,
-		req.EngineerID,
 		req.Rating,
 		req.Comment,
 	); err != nil {
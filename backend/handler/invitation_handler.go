@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"rbac/dto"
+	"rbac/models"
+	"rbac/service"
+)
+
+type InvitationHandler struct {
+	service *service.InvitationService
+}
+
+func NewInvitationHandler(service *service.InvitationService) *InvitationHandler {
+	return &InvitationHandler{service: service}
+}
+
+// Admin: GET /admin/invitations?status=pending|expired|accepted|revoked
+// (no status = everything still outstanding)
+func (h *InvitationHandler) List(c *gin.Context) {
+	status := models.InvitationStatus(c.Query("status"))
+
+	switch status {
+	case "",
+		models.InvitationPending,
+		models.InvitationExpired,
+		models.InvitationAccepted,
+		models.InvitationRevoked:
+	default:
+		authError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "invalid status")
+		return
+	}
+
+	invitations, err := h.service.List(status)
+	if err != nil {
+		authError(c, http.StatusInternalServerError, dto.CodeInternal, "failed to fetch invitations")
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// Admin: POST /admin/invitations/:id/resend
+func (h *InvitationHandler) Resend(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		authError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "invalid invitation id")
+		return
+	}
+
+	invitation, err := h.service.Resend(
+		id,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, invitation)
+}
+
+// Admin: DELETE /admin/invitations/:id
+func (h *InvitationHandler) Revoke(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		authError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "invalid invitation id")
+		return
+	}
+
+	if err := h.service.Revoke(
+		id,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "invitation revoked"})
+}
@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"rbac/utils"
+)
+
+type JWKSHandler struct {
+	keys *utils.KeyRing
+}
+
+func NewJWKSHandler(keys *utils.KeyRing) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GET /.well-known/jwks.json → public keys for verifying access tokens
+func (h *JWKSHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+
+	"rbac/dto"
+	"rbac/service"
+)
+
+const oidcStateCookie = "oidc_state"
+
+type OIDCHandler struct {
+	service *service.OIDCService
+	auth    *AuthHandler // shared cookie helpers
+}
+
+func NewOIDCHandler(
+	service *service.OIDCService,
+	auth *AuthHandler,
+) *OIDCHandler {
+	return &OIDCHandler{
+		service: service,
+		auth:    auth,
+	}
+}
+
+// GET /auth/oidc/login → 302 to the corporate IdP
+func (h *OIDCHandler) Login(c *gin.Context) {
+	if !h.service.Enabled() {
+		authError(c, http.StatusNotFound, dto.CodeNotFound, "sso not configured")
+		return
+	}
+
+	authURL, state, err := h.service.BeginLogin(c.Request.Context())
+	if err != nil {
+		authError(c, http.StatusBadGateway, dto.CodeServiceUnavailable, err.Error())
+		return
+	}
+
+	// Binds the callback to this browser (login CSRF)
+	c.SetCookie(
+		oidcStateCookie,
+		state,
+		600,
+		"/",
+		"",
+		h.auth.cfg.Server.Env == "production",
+		true,
+	)
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// GET /auth/oidc/callback?code=…&state=… → refresh cookie + back to the SPA,
+// which then calls /auth/refresh for its access token
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	pinned, _ := c.Cookie(oidcStateCookie)
+	c.SetCookie(oidcStateCookie, "", -1, "/", "", h.auth.cfg.Server.Env == "production", true)
+
+	if idpErr := c.Query("error"); idpErr != "" {
+		h.fail(c, dto.CodeInvalidCredentials, idpErr)
+		return
+	}
+
+	state := c.Query("state")
+	if state == "" || state != pinned {
+		h.fail(c, dto.CodeInvalidRequest, "sso state mismatch")
+		return
+	}
+
+	resp, err := h.service.FinishLogin(
+		c.Request.Context(),
+		state,
+		c.Query("code"),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		h.fail(c, authErrorCode(err, dto.CodeInvalidCredentials), err.Error())
+		return
+	}
+
+	h.auth.setRefreshCookie(c, resp.RefreshToken)
+	c.Redirect(http.StatusFound, h.auth.cfg.FrontendURL+"/sso/callback")
+}
+
+func (h *OIDCHandler) fail(c *gin.Context, code dto.ErrorCode, reason string) {
+	c.Redirect(
+		http.StatusFound,
+		h.auth.cfg.FrontendURL+"/login?sso_error="+url.QueryEscape(reason)+
+			"&sso_code="+url.QueryEscape(string(code)),
+	)
+}
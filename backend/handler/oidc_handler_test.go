@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"rbac/models"
+	"rbac/repository"
+	"rbac/service"
+	"rbac/testutil"
+	"rbac/utils"
+)
+
+func newTestOIDCRouter(t *testing.T) (*gin.Engine, *testutil.OIDCProvider, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.NewDB(t)
+	cfg := testutil.Config()
+	idp := testutil.NewOIDCProvider(t, "ticketing")
+
+	cfg.OIDC.IssuerURL = idp.URL
+	cfg.OIDC.ClientID = idp.ClientID
+	cfg.OIDC.ClientSecret = "secret"
+	cfg.OIDC.Scopes = []string{"openid", "email"}
+	cfg.OIDC.GroupsClaim = "groups"
+	cfg.OIDC.SupportGroups = []string{"Engineers"}
+
+	keys, err := utils.LoadKeyRing("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	passwords, err := utils.NewPasswordPolicy(cfg.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authRepo := repository.NewAuthRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	auth := service.NewAuthService(
+		db,
+		authRepo,
+		repository.NewRememberedDeviceRepo(db),
+		repository.NewCustomerRepository(db),
+		auditRepo,
+		repository.NewWebAuthnRepository(db),
+		repository.NewLoginThrottleRepository(db),
+		repository.NewLoginEventRepository(db),
+		keys,
+		repository.NewPostgresTokenRevocationStore(db),
+		service.NewInvitationService(db, repository.NewInvitationRepository(db), auditRepo, cfg),
+		passwords,
+		cfg,
+	)
+	oidc := service.NewOIDCService(
+		repository.NewOIDCRepository(db),
+		authRepo,
+		repository.NewSCIMRepository(db),
+		auth,
+		cfg,
+	)
+	h := NewOIDCHandler(oidc, NewAuthHandler(auth, cfg))
+
+	r := gin.New()
+	r.GET("/login", h.Login)
+	r.GET("/callback", h.Callback)
+
+	engineer := testutil.User(t, db, models.RoleSupport)
+	return r, idp, engineer.Email
+}
+
+func cookieNamed(resp *http.Response, name string) *http.Cookie {
+	for _, c := range resp.Cookies() {
+		if c.Name == name && c.MaxAge >= 0 {
+			return c
+		}
+	}
+	return nil
+}
+
+// The callback only completes in the browser that started the login
+func TestOIDCCallbackIsBoundToTheLoginBrowser(t *testing.T) {
+	r, idp, email := newTestOIDCRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("login status = %d", w.Code)
+	}
+	pinned := cookieNamed(w.Result(), oidcStateCookie)
+	if pinned == nil || !pinned.HttpOnly {
+		t.Fatal("login did not pin the state in an HttpOnly cookie")
+	}
+
+	state, code := idp.Authorize(t, w.Header().Get("Location"), jwt.MapClaims{
+		"sub":            "engineer",
+		"email":          email,
+		"email_verified": true,
+		"groups":         []string{"Engineers"},
+	})
+	callback := "/callback?" + url.Values{"state": {state}, "code": {code}}.Encode()
+
+	// Victim's browser, attacker's code: no pinned state
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, callback, nil))
+	if loc, _ := url.Parse(w.Header().Get("Location")); loc.Query().Get("sso_code") != "invalid_request" {
+		t.Fatalf("callback without the state cookie went to %s", loc)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, callback, nil)
+	req.AddCookie(pinned)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if loc, _ := url.Parse(w.Header().Get("Location")); loc.Path != "/sso/callback" {
+		t.Fatalf("callback went to %s", loc)
+	}
+	if cookieNamed(w.Result(), "refresh_token") == nil {
+		t.Fatal("callback did not set the refresh cookie")
+	}
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"rbac/models"
+	"rbac/service"
+)
+
+type RoleHandler struct {
+	service *service.RoleService
+}
+
+func NewRoleHandler(service *service.RoleService) *RoleHandler {
+	return &RoleHandler{service: service}
+}
+
+type RoleRequest struct {
+	Name        models.Role         `json:"name"` // create only
+	DisplayName string              `json:"display_name"`
+	Description string              `json:"description"`
+	Permissions []models.Permission `json:"permissions" binding:"required"`
+}
+
+type AssignRoleRequest struct {
+	Role models.Role `json:"role" binding:"required"`
+}
+
+// GET /profile/permissions → what the UI may show this user
+func (h *RoleHandler) MyPermissions(c *gin.Context) {
+	role := c.MustGet("user_role").(models.Role)
+
+	perms, err := h.service.PermissionsFor(role)
+	if err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"role":        role,
+		"permissions": perms,
+	})
+}
+
+// Admin: GET /admin/permissions
+func (h *RoleHandler) Catalog(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.Catalog())
+}
+
+// Admin: GET /admin/roles
+func (h *RoleHandler) List(c *gin.Context) {
+	roles, err := h.service.List()
+	if err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// Admin: POST /admin/roles
+func (h *RoleHandler) Create(c *gin.Context) {
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c)
+		return
+	}
+
+	role, err := h.service.Create(
+		service.RoleInput(req),
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// Admin: PUT /admin/roles/:name
+func (h *RoleHandler) Update(c *gin.Context) {
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c)
+		return
+	}
+
+	role, err := h.service.Update(
+		models.Role(c.Param("name")),
+		service.RoleInput(req),
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// Admin: DELETE /admin/roles/:name (only when no user holds it)
+func (h *RoleHandler) Delete(c *gin.Context) {
+	if err := h.service.Delete(
+		models.Role(c.Param("name")),
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "role deleted"})
+}
+
+// Admin: PUT /admin/users/:id/role
+func (h *RoleHandler) AssignRole(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		invalidRequest(c)
+		return
+	}
+
+	var req AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c)
+		return
+	}
+
+	if err := h.service.AssignRole(
+		userID,
+		req.Role,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "role updated"})
+}
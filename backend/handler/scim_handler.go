@@ -0,0 +1,319 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"rbac/config"
+	"rbac/middleware"
+	"rbac/service"
+)
+
+/*
+=====================
+ SCIM 2.0 (RFC 7643 / 7644)
+=====================
+ /scim/v2 — Users and the two role Groups, authenticated with a
+ scim:provision API key (middleware.SCIMAuth)
+*/
+
+type SCIMHandler struct {
+	service *service.SCIMService
+	cfg     *config.Config
+}
+
+func NewSCIMHandler(service *service.SCIMService, cfg *config.Config) *SCIMHandler {
+	return &SCIMHandler{service: service, cfg: cfg}
+}
+
+func (h *SCIMHandler) respond(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", "application/scim+json")
+	c.JSON(status, body)
+}
+
+func (h *SCIMHandler) fail(c *gin.Context, err error) {
+	var scimErr *service.SCIMError
+	if !errors.As(err, &scimErr) {
+		middleware.AbortSCIM(c, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	body := gin.H{
+		"schemas": []string{service.SCIMErrorSchema},
+		"status":  strconv.Itoa(scimErr.Status),
+		"detail":  scimErr.Detail,
+	}
+	if scimErr.SCIMType != "" {
+		body["scimType"] = scimErr.SCIMType
+	}
+	c.Header("Content-Type", "application/scim+json")
+	c.AbortWithStatusJSON(scimErr.Status, body)
+}
+
+// application/scim+json is JSON; gin's binder only cares about the body
+func (h *SCIMHandler) bind(c *gin.Context, out interface{}) bool {
+	if err := c.ShouldBindJSON(out); err != nil {
+		middleware.AbortSCIM(c, http.StatusBadRequest, "invalid request body")
+		return false
+	}
+	return true
+}
+
+func scimPaging(c *gin.Context) (int, int) {
+	startIndex, _ := strconv.Atoi(c.Query("startIndex"))
+	count, _ := strconv.Atoi(c.Query("count"))
+	return startIndex, count
+}
+
+func scimWithMembers(c *gin.Context) bool {
+	for _, attr := range strings.Split(c.Query("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attr), "members") {
+			return false
+		}
+	}
+	return true
+}
+
+/* =====================
+   Users
+===================== */
+
+// GET /scim/v2/Users?filter=userName eq "a@b.c"&startIndex=1&count=50
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	startIndex, count := scimPaging(c)
+
+	list, err := h.service.ListUsers(c.Query("filter"), startIndex, count)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, list)
+}
+
+// GET /scim/v2/Users/:id
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	user, err := h.service.GetUser(c.Param("id"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, user)
+}
+
+// POST /scim/v2/Users
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	var req service.SCIMUser
+	if !h.bind(c, &req) {
+		return
+	}
+
+	user, err := h.service.CreateUser(
+		&req,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	c.Header("Location", user.Meta.Location)
+	h.respond(c, http.StatusCreated, user)
+}
+
+// PUT /scim/v2/Users/:id
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	var req service.SCIMUser
+	if !h.bind(c, &req) {
+		return
+	}
+
+	user, err := h.service.ReplaceUser(
+		c.Param("id"),
+		&req,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, user)
+}
+
+// PATCH /scim/v2/Users/:id
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	var req service.SCIMPatchRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	user, err := h.service.PatchUser(
+		c.Param("id"),
+		&req,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, user)
+}
+
+// DELETE /scim/v2/Users/:id → deactivated, not erased
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	if err := h.service.DeleteUser(
+		c.Param("id"),
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+/* =====================
+   Groups
+===================== */
+
+// GET /scim/v2/Groups
+func (h *SCIMHandler) ListGroups(c *gin.Context) {
+	startIndex, count := scimPaging(c)
+
+	list, err := h.service.ListGroups(c.Query("filter"), startIndex, count, scimWithMembers(c))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, list)
+}
+
+// GET /scim/v2/Groups/:id
+func (h *SCIMHandler) GetGroup(c *gin.Context) {
+	group, err := h.service.GetGroup(c.Param("id"), scimWithMembers(c))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, group)
+}
+
+// POST /scim/v2/Groups → always refused, the groups are fixed
+func (h *SCIMHandler) CreateGroup(c *gin.Context) {
+	var req service.SCIMGroup
+	if !h.bind(c, &req) {
+		return
+	}
+	h.fail(c, h.service.CreateGroup(&req))
+}
+
+// PUT /scim/v2/Groups/:id
+func (h *SCIMHandler) ReplaceGroup(c *gin.Context) {
+	var req service.SCIMGroup
+	if !h.bind(c, &req) {
+		return
+	}
+
+	group, err := h.service.ReplaceGroup(
+		c.Param("id"),
+		&req,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, group)
+}
+
+// PATCH /scim/v2/Groups/:id
+func (h *SCIMHandler) PatchGroup(c *gin.Context) {
+	var req service.SCIMPatchRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	if err := h.service.PatchGroup(
+		c.Param("id"),
+		&req,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DELETE /scim/v2/Groups/:id
+func (h *SCIMHandler) DeleteGroup(c *gin.Context) {
+	h.fail(c, h.service.DeleteGroup(c.Param("id")))
+}
+
+/* =====================
+   Discovery
+===================== */
+
+// GET /scim/v2/ServiceProviderConfig
+func (h *SCIMHandler) ServiceProviderConfig(c *gin.Context) {
+	h.respond(c, http.StatusOK, gin.H{
+		"schemas":        []string{"urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"},
+		"patch":          gin.H{"supported": true},
+		"bulk":           gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         gin.H{"supported": true, "maxResults": service.SCIMMaxResults},
+		"changePassword": gin.H{"supported": false},
+		"sort":           gin.H{"supported": false},
+		"etag":           gin.H{"supported": false},
+		"authenticationSchemes": []gin.H{{
+			"type":        "oauthbearertoken",
+			"name":        "API key",
+			"description": "Bearer API key with the scim:provision scope",
+			"primary":     true,
+		}},
+	})
+}
+
+// GET /scim/v2/ResourceTypes
+func (h *SCIMHandler) ResourceTypes(c *gin.Context) {
+	base := strings.TrimRight(h.cfg.SCIM.BaseURL, "/")
+	resources := []gin.H{
+		{
+			"schemas":  []string{"urn:ietf:params:scim:schemas:core:2.0:ResourceType"},
+			"id":       "User",
+			"name":     "User",
+			"endpoint": "/Users",
+			"schema":   service.SCIMUserSchema,
+			"meta":     gin.H{"resourceType": "ResourceType", "location": base + "/ResourceTypes/User"},
+		},
+		{
+			"schemas":  []string{"urn:ietf:params:scim:schemas:core:2.0:ResourceType"},
+			"id":       "Group",
+			"name":     "Group",
+			"endpoint": "/Groups",
+			"schema":   service.SCIMGroupSchema,
+			"meta":     gin.H{"resourceType": "ResourceType", "location": base + "/ResourceTypes/Group"},
+		},
+	}
+
+	h.respond(c, http.StatusOK, service.SCIMListResponse{
+		Schemas:      []string{service.SCIMListSchema},
+		TotalResults: int64(len(resources)),
+		StartIndex:   1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
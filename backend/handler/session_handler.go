@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"rbac/dto"
+	"rbac/service"
+)
+
+type SessionHandler struct {
+	service *service.SessionService
+	auth    *AuthHandler // shared cookie helpers
+}
+
+func NewSessionHandler(
+	service *service.SessionService,
+	auth *AuthHandler,
+) *SessionHandler {
+	return &SessionHandler{
+		service: service,
+		auth:    auth,
+	}
+}
+
+/* =====================
+   Target Resolution
+===================== */
+
+// Admin routes: /admin/users/:id/... → that user
+func (h *SessionHandler) adminTarget(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		authError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "invalid user id")
+		return uuid.Nil, false
+	}
+
+	if err := h.service.EnsureUser(userID); err != nil {
+		respondAuthError(c, err, http.StatusNotFound)
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+/* =====================
+   Self-service (/profile)
+===================== */
+
+func (h *SessionHandler) MySessions(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	current, _ := c.Cookie("refresh_token")
+
+	h.listSessions(c, userID, current)
+}
+
+func (h *SessionHandler) RevokeMySession(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	h.revokeSession(c, userID)
+}
+
+func (h *SessionHandler) RevokeAllMySessions(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	if h.revokeAllSessions(c, userID) {
+		// Our own refresh token is gone too
+		h.auth.clearRefreshCookie(c)
+		c.JSON(http.StatusOK, gin.H{"message": "all sessions revoked, login again"})
+	}
+}
+
+func (h *SessionHandler) MyDevices(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	h.listDevices(c, userID)
+}
+
+func (h *SessionHandler) RevokeMyDevice(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	h.revokeDevice(c, userID)
+}
+
+func (h *SessionHandler) RevokeAllMyDevices(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	h.revokeAllDevices(c, userID)
+}
+
+func (h *SessionHandler) MyLogins(c *gin.Context) {
+	h.listLogins(c, c.MustGet("user_id").(uuid.UUID))
+}
+
+/* =====================
+   Admin (/admin/users/:id)
+===================== */
+
+func (h *SessionHandler) UserLogins(c *gin.Context) {
+	if userID, ok := h.adminTarget(c); ok {
+		h.listLogins(c, userID)
+	}
+}
+
+func (h *SessionHandler) UserSessions(c *gin.Context) {
+	if userID, ok := h.adminTarget(c); ok {
+		h.listSessions(c, userID, "")
+	}
+}
+
+func (h *SessionHandler) RevokeUserSession(c *gin.Context) {
+	if userID, ok := h.adminTarget(c); ok {
+		h.revokeSession(c, userID)
+	}
+}
+
+func (h *SessionHandler) RevokeAllUserSessions(c *gin.Context) {
+	if userID, ok := h.adminTarget(c); ok {
+		if h.revokeAllSessions(c, userID) {
+			c.JSON(http.StatusOK, gin.H{"message": "all sessions revoked"})
+		}
+	}
+}
+
+func (h *SessionHandler) UserDevices(c *gin.Context) {
+	if userID, ok := h.adminTarget(c); ok {
+		h.listDevices(c, userID)
+	}
+}
+
+func (h *SessionHandler) RevokeUserDevice(c *gin.Context) {
+	if userID, ok := h.adminTarget(c); ok {
+		h.revokeDevice(c, userID)
+	}
+}
+
+func (h *SessionHandler) RevokeAllUserDevices(c *gin.Context) {
+	if userID, ok := h.adminTarget(c); ok {
+		h.revokeAllDevices(c, userID)
+	}
+}
+
+/* =====================
+   Shared
+===================== */
+
+func (h *SessionHandler) listSessions(c *gin.Context, userID uuid.UUID, current string) {
+	sessions, err := h.service.ListSessions(userID, current)
+	if err != nil {
+		authError(c, http.StatusInternalServerError, dto.CodeInternal, "failed to fetch sessions")
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *SessionHandler) revokeSession(c *gin.Context, userID uuid.UUID) {
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		authError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "invalid session id")
+		return
+	}
+
+	if err := h.service.RevokeSession(
+		userID,
+		sessionID,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		respondAuthError(c, err, http.StatusNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
+
+func (h *SessionHandler) revokeAllSessions(c *gin.Context, userID uuid.UUID) bool {
+	if err := h.service.RevokeAllSessions(
+		userID,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		authError(c, http.StatusInternalServerError, dto.CodeInternal, "failed to revoke sessions")
+		return false
+	}
+	return true
+}
+
+func (h *SessionHandler) listDevices(c *gin.Context, userID uuid.UUID) {
+	devices, err := h.service.ListDevices(userID)
+	if err != nil {
+		authError(c, http.StatusInternalServerError, dto.CodeInternal, "failed to fetch devices")
+		return
+	}
+	c.JSON(http.StatusOK, devices)
+}
+
+func (h *SessionHandler) listLogins(c *gin.Context, userID uuid.UUID) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+
+	history, err := h.service.ListLogins(userID, page)
+	if err != nil {
+		authError(c, http.StatusInternalServerError, dto.CodeInternal, "failed to fetch login history")
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+func (h *SessionHandler) revokeDevice(c *gin.Context, userID uuid.UUID) {
+	deviceID, err := uuid.Parse(c.Param("deviceId"))
+	if err != nil {
+		authError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "invalid device id")
+		return
+	}
+
+	if err := h.service.RevokeDevice(
+		userID,
+		deviceID,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		respondAuthError(c, err, http.StatusNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "device revoked"})
+}
+
+func (h *SessionHandler) revokeAllDevices(c *gin.Context, userID uuid.UUID) {
+	if err := h.service.RevokeAllDevices(
+		userID,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		authError(c, http.StatusInternalServerError, dto.CodeInternal, "failed to revoke devices")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "all trusted devices revoked"})
+}
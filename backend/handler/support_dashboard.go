@@ -17,14 +17,36 @@ func NewSupportDashboardHandler(s *service.SupportService) *SupportDashboardHand
 	return &SupportDashboardHandler{service: s}
 }
 
+// GET /support/tickets[?team_id=] — team leads also get their teams' tickets
 func (h *SupportDashboardHandler) MyTickets(c *gin.Context) {
 	engineerID := c.MustGet("user_id").(uuid.UUID)
 
-	tickets, err := h.service.GetAssignedTickets(engineerID)
+	var teamID *uuid.UUID
+	if raw := c.Query("team_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid team id"})
+			return
+		}
+		teamID = &id
+	}
+
+	tickets, err := h.service.GetAssignedTickets(engineerID, teamID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load tickets"})
+		respondAuthError(c, err, http.StatusInternalServerError)
 		return
 	}
 
 	c.JSON(http.StatusOK, tickets)
 }
+
+// GET /support/teams
+func (h *SupportDashboardHandler) MyTeams(c *gin.Context) {
+	teams, err := h.service.MyTeams(c.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load teams"})
+		return
+	}
+
+	c.JSON(http.StatusOK, teams)
+}
@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"rbac/models"
+	"rbac/service"
+)
+
+type SupportTeamHandler struct {
+	service *service.SupportTeamService
+}
+
+func NewSupportTeamHandler(service *service.SupportTeamService) *SupportTeamHandler {
+	return &SupportTeamHandler{service: service}
+}
+
+type TeamRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type TeamMemberRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+	IsLead bool      `json:"is_lead"`
+}
+
+// team_id null takes the ticket off any team queue
+type QueueTicketRequest struct {
+	TeamID *uuid.UUID `json:"team_id"`
+}
+
+type ReassignTicketRequest struct {
+	EngineerID uuid.UUID `json:"engineer_id" binding:"required"`
+}
+
+// Admin: GET /admin/teams
+func (h *SupportTeamHandler) List(c *gin.Context) {
+	teams, err := h.service.List()
+	if err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, teams)
+}
+
+// Admin: GET /admin/teams/:id
+func (h *SupportTeamHandler) Get(c *gin.Context) {
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	team, err := h.service.Get(teamID)
+	if err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, team)
+}
+
+// Admin: POST /admin/teams
+func (h *SupportTeamHandler) Create(c *gin.Context) {
+	var req TeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c)
+		return
+	}
+
+	team, err := h.service.Create(
+		req.Name,
+		req.Description,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusCreated, team)
+}
+
+// Admin: PUT /admin/teams/:id
+func (h *SupportTeamHandler) Update(c *gin.Context) {
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req TeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c)
+		return
+	}
+
+	team, err := h.service.Update(
+		teamID,
+		req.Name,
+		req.Description,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+// Admin: DELETE /admin/teams/:id
+func (h *SupportTeamHandler) Delete(c *gin.Context) {
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(
+		teamID,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "team deleted"})
+}
+
+// Admin: PUT /admin/teams/:id/members (add, or change the lead flag)
+func (h *SupportTeamHandler) SetMember(c *gin.Context) {
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req TeamMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c)
+		return
+	}
+
+	team, err := h.service.SetMember(
+		teamID,
+		req.UserID,
+		req.IsLead,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+// Admin: DELETE /admin/teams/:id/members/:userId
+func (h *SupportTeamHandler) RemoveMember(c *gin.Context) {
+	teamID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	userID, ok := parseIDParam(c, "userId")
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveMember(
+		teamID,
+		userID,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "member removed"})
+}
+
+// Admin: PUT /admin/tickets/:id/team
+func (h *SupportTeamHandler) QueueTicket(c *gin.Context) {
+	ticketID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req QueueTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c)
+		return
+	}
+
+	if err := h.service.QueueTicket(
+		ticketID,
+		req.TeamID,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "ticket queue updated"})
+}
+
+// Team lead: POST /support/tickets/:id/reassign
+func (h *SupportTeamHandler) Reassign(c *gin.Context) {
+	ticketID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req ReassignTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c)
+		return
+	}
+
+	if err := h.service.Reassign(
+		ticketID,
+		req.EngineerID,
+		c.MustGet("user_id").(uuid.UUID),
+		c.MustGet("user_role").(models.Role),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "ticket reassigned"})
+}
+
+func parseIDParam(c *gin.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		invalidRequest(c)
+		return uuid.Nil, false
+	}
+	return id, true
+}
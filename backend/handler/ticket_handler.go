@@ -183,7 +183,40 @@ func (h *TicketHandler) CloseTicket(c *gin.Context) {
 	})
 }
 
+// GET /tickets/:id (any role the ticket policy lets through)
+func (h *TicketHandler) GetTicket(c *gin.Context) {
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket id"})
+		return
+	}
+
+	ticket, err := h.service.GetByID(ticketID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ticket not found"})
+		return
+	}
+	c.JSON(http.StatusOK, ticket)
+}
+
+// GET /admin/tickets[?team_id=] — optionally one team's queue
 func (h *TicketHandler) GetAdminTickets(c *gin.Context) {
+	if raw := c.Query("team_id"); raw != "" {
+		teamID, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid team id"})
+			return
+		}
+
+		tickets, err := h.service.GetByTeam(teamID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tickets"})
+			return
+		}
+		c.JSON(http.StatusOK, tickets)
+		return
+	}
+
 	tickets, err := h.service.GetAll()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tickets"})
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"rbac/dto"
+	"rbac/service"
+)
+
+type UserLifecycleHandler struct {
+	service *service.UserLifecycleService
+}
+
+func NewUserLifecycleHandler(service *service.UserLifecycleService) *UserLifecycleHandler {
+	return &UserLifecycleHandler{service: service}
+}
+
+// Optional: who takes over a departing support engineer's open tickets
+type DeactivateUserRequest struct {
+	ReassignTo *uuid.UUID `json:"reassign_to"`
+}
+
+func (h *UserLifecycleHandler) bind(c *gin.Context) (uuid.UUID, *DeactivateUserRequest, bool) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		authError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "invalid user id")
+		return uuid.Nil, nil, false
+	}
+
+	var req DeactivateUserRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			invalidRequest(c)
+			return uuid.Nil, nil, false
+		}
+	}
+
+	return userID, &req, true
+}
+
+// Admin: POST /admin/users/:id/deactivate
+func (h *UserLifecycleHandler) Deactivate(c *gin.Context) {
+	userID, req, ok := h.bind(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.Deactivate(
+		userID,
+		c.MustGet("user_id").(uuid.UUID),
+		req.ReassignTo,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Admin: POST /admin/users/:id/reactivate
+func (h *UserLifecycleHandler) Reactivate(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		authError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "invalid user id")
+		return
+	}
+
+	result, err := h.service.Reactivate(
+		userID,
+		c.MustGet("user_id").(uuid.UUID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Admin: DELETE /admin/users/:id → permanent offboarding
+func (h *UserLifecycleHandler) Offboard(c *gin.Context) {
+	userID, req, ok := h.bind(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.Offboard(
+		userID,
+		c.MustGet("user_id").(uuid.UUID),
+		req.ReassignTo,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
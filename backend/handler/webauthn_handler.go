@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"rbac/dto"
+	"rbac/service"
+)
+
+const webauthnSessionCookie = "webauthn_session"
+
+type WebAuthnHandler struct {
+	service *service.WebAuthnService
+	auth    *AuthHandler // shared cookie helpers
+}
+
+func NewWebAuthnHandler(
+	service *service.WebAuthnService,
+	auth *AuthHandler,
+) *WebAuthnHandler {
+	return &WebAuthnHandler{
+		service: service,
+		auth:    auth,
+	}
+}
+
+/* =====================
+   Cookie Helpers
+===================== */
+
+// Ceremony session id travels in a short-lived HttpOnly cookie
+func (h *WebAuthnHandler) setSessionCookie(c *gin.Context, id uuid.UUID) {
+	c.SetCookie(
+		webauthnSessionCookie,
+		id.String(),
+		300,
+		"/",
+		"",
+		h.auth.cfg.Server.Env == "production",
+		true,
+	)
+}
+
+func (h *WebAuthnHandler) takeSessionCookie(c *gin.Context) (uuid.UUID, bool) {
+	raw, err := c.Cookie(webauthnSessionCookie)
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	c.SetCookie(webauthnSessionCookie, "", -1, "/", "", h.auth.cfg.Server.Env == "production", true)
+
+	id, err := uuid.Parse(raw)
+	return id, err == nil
+}
+
+/* =====================
+   Registration (JWT)
+===================== */
+
+func (h *WebAuthnHandler) BeginRegistration(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	options, sessionID, err := h.service.BeginRegistration(userID)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	h.setSessionCookie(c, sessionID)
+	c.JSON(http.StatusOK, options)
+}
+
+// Body = attestation response from navigator.credentials.create()
+func (h *WebAuthnHandler) FinishRegistration(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	sessionID, ok := h.takeSessionCookie(c)
+	if !ok {
+		authError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "passkey session missing")
+		return
+	}
+
+	cred, err := h.service.FinishRegistration(
+		userID,
+		sessionID,
+		c.Query("name"),
+		c.Request,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusCreated, cred)
+}
+
+func (h *WebAuthnHandler) ListCredentials(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	creds, err := h.service.ListCredentials(userID)
+	if err != nil {
+		authError(c, http.StatusInternalServerError, dto.CodeInternal, "failed to fetch passkeys")
+		return
+	}
+
+	c.JSON(http.StatusOK, creds)
+}
+
+func (h *WebAuthnHandler) DeleteCredential(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	credID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		authError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "invalid passkey id")
+		return
+	}
+
+	if err := h.service.DeleteCredential(
+		userID,
+		credID,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	); err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "passkey removed"})
+}
+
+/* =====================
+   Passwordless Login (PUBLIC)
+===================== */
+
+func (h *WebAuthnHandler) BeginLogin(c *gin.Context) {
+	options, sessionID, err := h.service.BeginLogin()
+	if err != nil {
+		respondAuthError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	h.setSessionCookie(c, sessionID)
+	c.JSON(http.StatusOK, options)
+}
+
+// Body = assertion response from navigator.credentials.get()
+func (h *WebAuthnHandler) FinishLogin(c *gin.Context) {
+	sessionID, ok := h.takeSessionCookie(c)
+	if !ok {
+		authError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "passkey session missing")
+		return
+	}
+
+	resp, err := h.service.FinishLogin(
+		sessionID,
+		c.Request,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusUnauthorized)
+		return
+	}
+
+	h.auth.setRefreshCookie(c, resp.RefreshToken)
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token": resp.AccessToken,
+		"user":         resp.User,
+	})
+}
+
+/* =====================
+   Second Factor (2FA TOKEN)
+===================== */
+
+func (h *WebAuthnHandler) BeginTwoFA(c *gin.Context) {
+	userID := c.MustGet("2fa_user_id").(uuid.UUID)
+
+	options, sessionID, err := h.service.BeginTwoFA(userID)
+	if err != nil {
+		respondAuthError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	h.setSessionCookie(c, sessionID)
+	c.JSON(http.StatusOK, options)
+}
+
+func (h *WebAuthnHandler) FinishTwoFA(c *gin.Context) {
+	userID := c.MustGet("2fa_user_id").(uuid.UUID)
+	remember := c.MustGet("2fa_remember").(bool)
+
+	sessionID, ok := h.takeSessionCookie(c)
+	if !ok {
+		authError(c, http.StatusBadRequest, dto.CodeInvalidRequest, "passkey session missing")
+		return
+	}
+
+	resp, rememberToken, err := h.service.FinishTwoFA(
+		userID,
+		sessionID,
+		remember,
+		c.Request,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		respondAuthError(c, err, http.StatusUnauthorized)
+		return
+	}
+
+	if rememberToken != nil {
+		h.auth.setRememberDeviceCookie(c, *rememberToken)
+	}
+
+	h.auth.setRefreshCookie(c, resp.RefreshToken)
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token": resp.AccessToken,
+		"user":         resp.User,
+	})
+}
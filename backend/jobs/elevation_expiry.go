@@ -0,0 +1,24 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"rbac/service"
+)
+
+// Closes break-glass grants whose time is up (AuthMiddleware already
+// refuses them) so the end is recorded and admins are told
+func StartElevationExpiry(
+	elevations *service.ElevationService,
+) {
+	go func() {
+		ticker := time.NewTicker(1 * time.Minute)
+		for {
+			<-ticker.C
+			if err := elevations.ExpireDue(); err != nil {
+				log.Println("elevation expiry failed:", err)
+			}
+		}
+	}()
+}
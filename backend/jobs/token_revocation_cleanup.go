@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"rbac/repository"
+)
+
+// Drops denylisted jtis whose tokens have expired on their own
+func StartTokenRevocationCleanup(
+	store *repository.PostgresTokenRevocationStore,
+) {
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
+		for {
+			<-ticker.C
+			if err := store.PurgeExpired(); err != nil {
+				log.Println("token revocation cleanup failed:", err)
+			}
+		}
+	}()
+}
@@ -9,6 +9,7 @@ import (
 	"rbac/config"
 	"rbac/database"
 	"rbac/handler"
+	"rbac/jobs"
 	"rbac/middleware"
 	"rbac/repository"
 	"rbac/routes"
@@ -25,11 +26,30 @@ func main() {
 	}
 
 	cfg := config.LoadConfig()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("❌ config: %v", err)
+	}
 
 	if cfg.Server.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	/* =========================
+	   JWT SIGNING KEYS
+	========================= */
+	keyRing, err := utils.LoadKeyRing(cfg.JWT.KeyDir, cfg.JWT.ActiveKeyID)
+	if err != nil {
+		log.Fatalf("❌ jwt key ring init failed: %v", err)
+	}
+
+	/* =========================
+	   PASSWORD POLICY
+	========================= */
+	passwordPolicy, err := utils.NewPasswordPolicy(cfg.Password)
+	if err != nil {
+		log.Fatalf("❌ password policy init failed: %v", err)
+	}
+
 	/* =========================
 	   DATABASE
 	========================= */
@@ -61,6 +81,26 @@ func main() {
 	========================= */
 	authRepo := repository.NewAuthRepository(database.DB)
 	rememberedDeviceRepo := repository.NewRememberedDeviceRepo(database.DB)
+	auditRepo := repository.NewAuditRepository(database.DB)
+	webauthnRepo := repository.NewWebAuthnRepository(database.DB)
+	loginThrottleRepo := repository.NewLoginThrottleRepository(database.DB)
+	loginEventRepo := repository.NewLoginEventRepository(database.DB)
+	oidcRepo := repository.NewOIDCRepository(database.DB)
+	apiKeyRepo := repository.NewAPIKeyRepository(database.DB)
+	userLifecycleRepo := repository.NewUserLifecycleRepository(database.DB)
+	invitationRepo := repository.NewInvitationRepository(database.DB)
+	emailVerificationRepo := repository.NewEmailVerificationRepository(database.DB)
+	scimRepo := repository.NewSCIMRepository(database.DB)
+	roleRepo := repository.NewRoleRepository(database.DB)
+	policyRepo := repository.NewPolicyRepository(database.DB)
+	supportTeamRepo := repository.NewSupportTeamRepository(database.DB)
+	elevationRepo := repository.NewElevationRepository(database.DB)
+
+	revocationDB := repository.NewPostgresTokenRevocationStore(database.DB)
+	tokenRevocations := repository.NewCachedTokenRevocationStore(
+		revocationDB,
+		cfg.JWT.RevocationCacheTTL,
+	)
 
 	ticketRepo := repository.NewTicketRepository(database.DB)
 
@@ -78,23 +118,104 @@ func main() {
 	/* =========================
 	   SERVICES
 	========================= */
+	invitationService := service.NewInvitationService(
+		db,
+		invitationRepo,
+		auditRepo,
+		cfg,
+	)
+
 	authService := service.NewAuthService(
 		db,
 		authRepo,
 		rememberedDeviceRepo,
 		customerRepo,
+		auditRepo,
+		webauthnRepo,
+		loginThrottleRepo,
+		loginEventRepo,
+		keyRing,
+		tokenRevocations,
+		invitationService,
+		passwordPolicy,
+		cfg,
+	)
+
+	webauthnService, err := service.NewWebAuthnService(
+		webauthnRepo,
+		authRepo,
+		authService,
+		cfg,
+	)
+	if err != nil {
+		log.Fatalf("❌ webauthn init failed: %v", err)
+	}
+
+	oidcService := service.NewOIDCService(
+		oidcRepo,
+		authRepo,
+		scimRepo,
+		authService,
 		cfg,
 	)
 
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, auditRepo)
+
+	roleService := service.NewRoleService(roleRepo, auditRepo, tokenRevocations)
+	if err := roleService.EnsureSystemRoles(); err != nil {
+		log.Fatalf("❌ seeding roles failed: %v", err)
+	}
+
+	policyService := service.NewPolicyService(policyRepo, roleService, cfg)
+	accessService := service.NewAccessService(roleService, policyService, roleRepo)
+	elevationService := service.NewElevationService(elevationRepo, roleService, auditRepo, keyRing, cfg)
+
+	userLifecycleService := service.NewUserLifecycleService(
+		userLifecycleRepo,
+		auditRepo,
+		tokenRevocations,
+	)
+
+	emailVerificationService := service.NewEmailVerificationService(
+		emailVerificationRepo,
+		authRepo,
+		auditRepo,
+		cfg,
+	)
+
+	scimService := service.NewSCIMService(
+		scimRepo,
+		userLifecycleService,
+		passwordPolicy,
+		auditRepo,
+		tokenRevocations,
+		cfg,
+	)
+
+	sessionService := service.NewSessionService(
+		authRepo,
+		rememberedDeviceRepo,
+		loginEventRepo,
+		auditRepo,
+		tokenRevocations,
+	)
+
+	customerOrgService := service.NewCustomerOrgService(customerRepo, authService, auditRepo)
+	if err := customerOrgService.EnsureFounderMemberships(); err != nil {
+		log.Fatalf("❌ customer memberships backfill failed: %v", err)
+	}
+
 	ticketService := service.NewTicketService(ticketRepo)
 
 	adminService := service.NewAdminService(dashboardRepo)
-	supportService := service.NewSupportService(ticketRepo)
+	supportService := service.NewSupportService(ticketRepo, supportTeamRepo)
+	supportTeamService := service.NewSupportTeamService(supportTeamRepo, ticketRepo, roleService, auditRepo)
 	customerService := service.NewCustomerService(
 		db,
 		authRepo,
 		customerRepo,
 		ticketRepo,
+		passwordPolicy,
 	)
 
 	amcService := service.NewAMCService(amcRepo)
@@ -115,10 +236,24 @@ func main() {
 	   HANDLERS
 	========================= */
 	authHandler := handler.NewAuthHandler(authService, cfg)
+	jwksHandler := handler.NewJWKSHandler(keyRing)
+	oidcHandler := handler.NewOIDCHandler(oidcService, authHandler)
+	webauthnHandler := handler.NewWebAuthnHandler(webauthnService, authHandler)
+	sessionHandler := handler.NewSessionHandler(sessionService, authHandler)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	userLifecycleHandler := handler.NewUserLifecycleHandler(userLifecycleService)
+	invitationHandler := handler.NewInvitationHandler(invitationService)
+	emailVerificationHandler := handler.NewEmailVerificationHandler(emailVerificationService)
+	scimHandler := handler.NewSCIMHandler(scimService, cfg)
+	roleHandler := handler.NewRoleHandler(roleService)
+	supportTeamHandler := handler.NewSupportTeamHandler(supportTeamService)
+	accessHandler := handler.NewAccessHandler(accessService)
+	elevationHandler := handler.NewElevationHandler(elevationService)
 
 	adminDashboard := handler.NewAdminDashboardHandler(adminService)
 	supportDashboard := handler.NewSupportDashboardHandler(supportService)
 	customerDashboard := handler.NewCustomerDashboardHandler(customerService)
+	customerOrgHandler := handler.NewCustomerOrgHandler(customerOrgService)
 
 	ticketHandler := handler.NewTicketHandler(ticketService, imageUploader)
 	amcHandler := handler.NewAMCHandler(amcService)
@@ -136,14 +271,36 @@ func main() {
 	routes.SetupRoutes(
 		r,
 		cfg,
+		keyRing,
+		tokenRevocations,
+		apiKeyService,
+		roleService,
+		policyService,
+		accessService,
+		elevationService,
+		auditRepo,
 
 		// Auth
 		authHandler,
+		jwksHandler,
+		oidcHandler,
+		webauthnHandler,
+		sessionHandler,
+		apiKeyHandler,
+		userLifecycleHandler,
+		invitationHandler,
+		emailVerificationHandler,
+		scimHandler,
+		roleHandler,
+		supportTeamHandler,
+		accessHandler,
+		elevationHandler,
 
 		// Dashboards
 		adminDashboard,
 		supportDashboard,
 		customerDashboard,
+		customerOrgHandler,
 
 		// Core
 		ticketHandler,
@@ -158,6 +315,12 @@ func main() {
 		modelHandler,
 	)
 
+	/* =========================
+	   BACKGROUND JOBS
+	========================= */
+	jobs.StartTokenRevocationCleanup(revocationDB)
+	jobs.StartElevationExpiry(elevationService)
+
 	/* =========================
 	   START SERVER
 	========================= */
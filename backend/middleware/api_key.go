@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"rbac/dto"
+	"rbac/models"
+)
+
+/*
+=====================
+ API Keys
+=====================
+ Machine clients send `X-API-Key: rbk_…` (or `Authorization: ApiKey rbk_…`)
+ instead of a Bearer JWT. They can only reach routes that declare a scope.
+*/
+
+const (
+	CtxAPIKeyID     = "api_key_id"
+	CtxAPIKeyScopes = "api_key_scopes"
+)
+
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "ApiKey ") {
+		return strings.TrimPrefix(auth, "ApiKey ")
+	}
+	return ""
+}
+
+func IsAPIKeyRequest(c *gin.Context) bool {
+	_, ok := c.Get(CtxAPIKeyID)
+	return ok
+}
+
+/* =====================
+   Route → Scope Registry
+===================== */
+
+// "METHOD /full/path" → scope; filled once by routes.SetupRoutes
+var routeScopes = map[string]models.APIScope{}
+
+func RegisterRouteScopes(scopes map[string]models.APIScope) {
+	for route, scope := range scopes {
+		routeScopes[route] = scope
+	}
+}
+
+func RouteScope(method string, fullPath string) (models.APIScope, bool) {
+	scope, ok := routeScopes[method+" "+fullPath]
+	return scope, ok
+}
+
+// Route must declare a scope and the key must hold it
+func apiKeyAllowed(c *gin.Context) bool {
+	scope, ok := RouteScope(c.Request.Method, c.FullPath())
+	if !ok {
+		return false
+	}
+
+	granted, _ := c.Get(CtxAPIKeyScopes)
+	scopes, _ := granted.([]models.APIScope)
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+/* =====================
+   Per-key Rate Limit
+===================== */
+
+// Token bucket per key, refilled continuously at limit/minute
+type keyRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[uuid.UUID]*rateBucket
+}
+
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+var apiKeyLimiter = &keyRateLimiter{buckets: map[uuid.UUID]*rateBucket{}}
+
+// Returns 0 when allowed, otherwise how long to wait
+func (l *keyRateLimiter) take(id uuid.UUID, perMinute int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	capacity := float64(perMinute)
+	rate := capacity / 60 // tokens per second
+
+	b, ok := l.buckets[id]
+	if !ok {
+		b = &rateBucket{tokens: capacity, last: now}
+		l.buckets[id] = b
+	}
+
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+func rejectRateLimited(c *gin.Context, wait time.Duration) {
+	secs := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(secs))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, dto.ErrorResponse{
+		Error:      "rate limit exceeded",
+		Code:       dto.CodeRateLimited,
+		RetryAfter: secs,
+	})
+}
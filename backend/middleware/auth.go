@@ -7,8 +7,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
-	"rbac/config"
+	"rbac/dto"
 	"rbac/models"
+	"rbac/repository"
+	"rbac/service"
 	"rbac/utils"
 )
 
@@ -21,6 +23,14 @@ const (
 	CtxUserID    = "user_id"
 	CtxUserEmail = "user_email"
 	CtxUserRole  = "user_role"
+
+	CtxTokenID        = "token_jti"
+	CtxTokenExpiresAt = "token_expires_at"
+
+	CtxAuthTime    = "auth_time"    // time.Time, absent = unknown
+	CtxAuthMethods = "auth_methods" // []string (amr)
+
+	CtxSessionID = "session_id" // uuid.UUID refresh-token family, absent = none
 )
 
 /*
@@ -29,29 +39,43 @@ const (
 =====================
  Validates JWT access token
 */
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+func AuthMiddleware(
+	keys *utils.KeyRing,
+	revocations repository.TokenRevocationStore,
+	apiKeys *service.APIKeyService,
+	elevations *service.ElevationService,
+) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// 🔑 Machine client (API key) instead of a user JWT
+		if raw := apiKeyFromRequest(c); raw != "" {
+			authenticateAPIKey(c, apiKeys, raw)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "authorization header missing",
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Error: "authorization header missing",
+				Code:  dto.CodeTokenMissing,
 			})
 			return
 		}
 
 		if !strings.HasPrefix(authHeader, "Bearer ") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "invalid authorization format",
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Error: "invalid authorization format",
+				Code:  dto.CodeTokenInvalid,
 			})
 			return
 		}
 
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 
-		claims, err := utils.ValidateToken(token, cfg.JWT.AccessSecret)
+		claims, err := utils.ValidateToken(token, keys)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "invalid or expired token",
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Error: "invalid or expired token",
+				Code:  dto.CodeTokenInvalid,
 			})
 			return
 		}
@@ -59,21 +83,161 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		// 🔒 Parse UUID from JWT (CRITICAL)
 		userID, err := uuid.Parse(claims.UserID)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "invalid user identity",
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Error: "invalid user identity",
+				Code:  dto.CodeTokenInvalid,
+			})
+			return
+		}
+
+		// 🚫 Logged out / password changed / deactivated since issue?
+		if claims.ID == "" || claims.IssuedAt == nil || claims.ExpiresAt == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Error: "invalid or expired token",
+				Code:  dto.CodeTokenInvalid,
+			})
+			return
+		}
+
+		revoked, err := revocations.IsRevoked(claims.ID, userID, claims.IssuedAt.Time)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, dto.ErrorResponse{
+				Error: "unable to verify token",
+				Code:  dto.CodeServiceUnavailable,
 			})
 			return
 		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Error: "token revoked",
+				Code:  dto.CodeTokenRevoked,
+			})
+			return
+		}
+
+		// 🚫 Session revoked from the sessions page: its sid is denied
+		var sessionID uuid.UUID
+		if claims.SessionID != "" {
+			sessionID, err = uuid.Parse(claims.SessionID)
+			if err == nil {
+				revoked, err = revocations.IsRevoked(claims.SessionID, userID, claims.IssuedAt.Time)
+			}
+			if err != nil || revoked {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+					Error: "token revoked",
+					Code:  dto.CodeTokenRevoked,
+				})
+				return
+			}
+		}
+
+		// 🎭 Impersonation token: the admin's own cutoff applies too
+		if claims.Actor != nil {
+			actorID, _ := uuid.Parse(claims.Actor.Subject) // checked in ValidateToken
+
+			revoked, err := revocations.IsRevoked(claims.ID, actorID, claims.IssuedAt.Time)
+			if err != nil || revoked {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+					Error: "token revoked",
+					Code:  dto.CodeTokenRevoked,
+				})
+				return
+			}
+
+			c.Set(CtxImpersonating, true)
+			c.Set(CtxActorID, actorID)
+			c.Set(CtxActorEmail, claims.Actor.Email)
+		}
+
+		// 🚨 Break-glass token: only while the grant is still active
+		if claims.Elevation != nil {
+			grantID, err := uuid.Parse(claims.Elevation.GrantID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+					Error: "invalid or expired token",
+					Code:  dto.CodeTokenInvalid,
+				})
+				return
+			}
+
+			active, err := elevations.IsActive(grantID, userID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, dto.ErrorResponse{
+					Error: "unable to verify elevation",
+					Code:  dto.CodeServiceUnavailable,
+				})
+				return
+			}
+			if !active {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+					Error: "elevation has ended",
+					Code:  dto.CodeElevationExpired,
+				})
+				return
+			}
+
+			c.Set(CtxElevationID, grantID)
+			c.Set(CtxBaseRole, claims.Elevation.BaseRole)
+		}
 
 		// ✅ Set typed values into context
-		c.Set(CtxUserID, userID)            // uuid.UUID
-		c.Set(CtxUserEmail, claims.Email)   // string
-		c.Set(CtxUserRole, claims.Role)     // models.Role
+		c.Set(CtxUserID, userID)          // uuid.UUID
+		c.Set(CtxUserEmail, claims.Email) // string
+		c.Set(CtxUserRole, claims.Role)   // models.Role
+
+		c.Set(CtxTokenID, claims.ID)                    // jti
+		c.Set(CtxTokenExpiresAt, claims.ExpiresAt.Time) // time.Time
+
+		if claims.AuthTime != nil {
+			c.Set(CtxAuthTime, claims.AuthTime.Time)
+			c.Set(CtxAuthMethods, claims.AMR)
+		}
+		if sessionID != uuid.Nil {
+			c.Set(CtxSessionID, sessionID)
+		}
 
 		c.Next()
 	}
 }
 
+// Runs as the issuing admin, limited to the key's scopes
+func authenticateAPIKey(
+	c *gin.Context,
+	apiKeys *service.APIKeyService,
+	raw string,
+) {
+	key, err := apiKeys.Authenticate(raw, c.ClientIP())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+			Error: "invalid api key",
+			Code:  dto.CodeTokenInvalid,
+		})
+		return
+	}
+
+	if wait := apiKeyLimiter.take(key.ID, key.RateLimitPerMinute); wait > 0 {
+		rejectRateLimited(c, wait)
+		return
+	}
+
+	c.Set(CtxUserID, key.CreatedBy)
+	c.Set(CtxUserEmail, key.Owner.Email)
+	c.Set(CtxUserRole, key.Owner.Role)
+	c.Set(CtxAPIKeyID, key.ID)
+	c.Set(CtxAPIKeyScopes, key.Scopes)
+
+	// Profile, 2FA, logout… are never reachable with a key
+	if !apiKeyAllowed(c) {
+		c.AbortWithStatusJSON(http.StatusForbidden, dto.ErrorResponse{
+			Error: "api key not allowed for this route",
+			Code:  dto.CodeForbidden,
+		})
+		return
+	}
+
+	c.Next()
+}
+
 /*
 =====================
  Role-Based Access
@@ -81,31 +245,50 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 */
 func RequireRole(roles ...models.Role) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// API keys are authorized by scope, not by role
+		if IsAPIKeyRequest(c) {
+			if !apiKeyAllowed(c) {
+				c.AbortWithStatusJSON(http.StatusForbidden, dto.ErrorResponse{
+					Error: "insufficient api key scope",
+					Code:  dto.CodeForbidden,
+				})
+				return
+			}
+			c.Next()
+			return
+		}
+
 		roleValue, exists := c.Get(CtxUserRole)
 		if !exists {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "user role missing",
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Error: "user role missing",
+				Code:  dto.CodeTokenInvalid,
 			})
 			return
 		}
 
 		userRole, ok := roleValue.(models.Role)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "invalid user role",
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Error: "invalid user role",
+				Code:  dto.CodeTokenInvalid,
 			})
 			return
 		}
 
+		names := make([]string, 0, len(roles))
 		for _, role := range roles {
 			if userRole == role {
 				c.Next()
 				return
 			}
+			names = append(names, string(role))
 		}
 
-		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-			"error": "insufficient permissions",
+		// Name the rule so a denial can be traced (see /admin/access/explain)
+		c.AbortWithStatusJSON(http.StatusForbidden, dto.ErrorResponse{
+			Error: "insufficient permissions: requires role " + strings.Join(names, " or "),
+			Code:  dto.CodeForbidden,
 		})
 	}
 }
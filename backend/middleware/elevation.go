@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"rbac/repository"
+)
+
+/*
+=====================
+ Elevation Guard
+=====================
+ Runs after AuthMiddleware. Every request made with a break-glass token
+ is written to the audit log against the elevation grant, so the whole
+ incident can be reviewed afterwards.
+*/
+
+const (
+	CtxElevationID = "elevation_id" // uuid.UUID — the active grant
+	CtxBaseRole    = "base_role"    // models.Role — the user's own role
+)
+
+func IsElevated(c *gin.Context) bool {
+	_, ok := c.Get(CtxElevationID)
+	return ok
+}
+
+func ElevationGuard(auditRepo *repository.AuditRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsElevated(c) {
+			c.Next()
+			return
+		}
+
+		_ = auditRepo.LogElevated(
+			c.MustGet(CtxElevationID).(uuid.UUID),
+			"elevated_request:"+c.Request.Method+" "+c.FullPath(),
+			c.MustGet(CtxUserID).(uuid.UUID),
+			c.ClientIP(),
+			c.GetHeader("User-Agent"),
+		)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"rbac/dto"
+	"rbac/repository"
+)
+
+/*
+=====================
+ Impersonation Guard
+=====================
+ Runs after AuthMiddleware. For impersonation tokens it records every
+ request in the audit log (performed by the real admin) and refuses
+ anything that would change state as the impersonated user.
+*/
+
+const (
+	CtxActorID       = "actor_id"    // uuid.UUID — the admin really acting
+	CtxActorEmail    = "actor_email" // string
+	CtxImpersonating = "impersonating"
+)
+
+// Writes still allowed while impersonating ("METHOD /full/path")
+var impersonationAllowed = map[string]bool{
+	"DELETE /api/v1/impersonation": true, // stop impersonating
+}
+
+func IsImpersonating(c *gin.Context) bool {
+	return c.GetBool(CtxImpersonating)
+}
+
+func ImpersonationGuard(auditRepo *repository.AuditRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsImpersonating(c) {
+			c.Next()
+			return
+		}
+
+		targetID := c.MustGet(CtxUserID).(uuid.UUID)
+		actorID := c.MustGet(CtxActorID).(uuid.UUID)
+		route := c.Request.Method + " " + c.FullPath()
+
+		readOnly := c.Request.Method == http.MethodGet ||
+			c.Request.Method == http.MethodHead ||
+			c.Request.Method == http.MethodOptions
+
+		if !readOnly && !impersonationAllowed[route] {
+			_ = auditRepo.Log(
+				"user",
+				targetID,
+				"impersonated_request_blocked:"+route,
+				actorID,
+				c.ClientIP(),
+				c.GetHeader("User-Agent"),
+			)
+
+			c.AbortWithStatusJSON(http.StatusForbidden, dto.ErrorResponse{
+				Error: "read-only while impersonating",
+				Code:  dto.CodeForbidden,
+			})
+			return
+		}
+
+		_ = auditRepo.Log(
+			"user",
+			targetID,
+			"impersonated_request:"+route,
+			actorID,
+			c.ClientIP(),
+			c.GetHeader("User-Agent"),
+		)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"rbac/dto"
+	"rbac/models"
+	"rbac/service"
+)
+
+/*
+=====================
+ Permission-Based Access
+=====================
+ Goes after AuthMiddleware. The caller's role must grant perm (roles
+ are permission sets, see service.RoleService). API keys are still
+ authorized by route scope. Break-glass tokens never reach AdminOnly
+ permissions, so an elevation can't be turned into a lasting one.
+*/
+func RequirePermission(roles *service.RoleService, perm models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsAPIKeyRequest(c) {
+			if !apiKeyAllowed(c) {
+				c.AbortWithStatusJSON(http.StatusForbidden, dto.ErrorResponse{
+					Error: "insufficient api key scope",
+					Code:  dto.CodeForbidden,
+				})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		roleValue, _ := c.Get(CtxUserRole)
+		role, ok := roleValue.(models.Role)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Error: "user role missing",
+				Code:  dto.CodeTokenInvalid,
+			})
+			return
+		}
+
+		if info, _ := perm.Info(); info.AdminOnly && IsElevated(c) {
+			c.AbortWithStatusJSON(http.StatusForbidden, dto.ErrorResponse{
+				Error: "permission " + string(perm) + " is not available under elevation",
+				Code:  dto.CodeForbidden,
+			})
+			return
+		}
+
+		allowed, err := roles.HasPermission(role, perm)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Error: "permission check failed",
+				Code:  dto.CodeInternal,
+			})
+			return
+		}
+
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusForbidden, dto.ErrorResponse{
+				Error: "missing permission " + string(perm),
+				Code:  dto.CodeForbidden,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
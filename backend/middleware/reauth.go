@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"rbac/dto"
+)
+
+/*
+=====================
+ Step-up (Recent Auth)
+=====================
+ Goes after AuthMiddleware. The token's auth_time must be younger than
+ maxAge, otherwise the client calls POST /auth/reauth and retries.
+ API keys and impersonation tokens never qualify.
+*/
+func RequireRecentAuth(maxAge time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authTime := c.GetTime(CtxAuthTime)
+
+		if IsAPIKeyRequest(c) || authTime.IsZero() || time.Since(authTime) > maxAge {
+			// RFC 9470 step-up challenge
+			c.Header(
+				"WWW-Authenticate",
+				`Bearer error="insufficient_user_authentication", max_age=`+
+					strconv.Itoa(int(maxAge.Seconds())),
+			)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Error: "recent authentication required",
+				Code:  dto.CodeReauthRequired,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"rbac/models"
+	"rbac/service"
+)
+
+/*
+=====================
+ SCIM Auth
+=====================
+ IdPs send the API key as a plain Bearer token. Only keys holding
+ scim:provision get in; errors use the SCIM error schema.
+*/
+
+func SCIMAuth(apiKeys *service.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := apiKeyFromRequest(c)
+		if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			raw = strings.TrimPrefix(auth, "Bearer ")
+		}
+		if raw == "" {
+			AbortSCIM(c, http.StatusUnauthorized, "authorization header missing")
+			return
+		}
+
+		key, err := apiKeys.Authenticate(raw, c.ClientIP())
+		if err != nil {
+			AbortSCIM(c, http.StatusUnauthorized, "invalid api key")
+			return
+		}
+
+		if !key.HasScope(models.ScopeSCIMProvision) {
+			AbortSCIM(c, http.StatusForbidden, "api key lacks the scim:provision scope")
+			return
+		}
+
+		if wait := apiKeyLimiter.take(key.ID, key.RateLimitPerMinute); wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			AbortSCIM(c, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+
+		c.Set(CtxUserID, key.CreatedBy)
+		c.Set(CtxUserEmail, key.Owner.Email)
+		c.Set(CtxUserRole, key.Owner.Role)
+		c.Set(CtxAPIKeyID, key.ID)
+		c.Set(CtxAPIKeyScopes, key.Scopes)
+
+		c.Next()
+	}
+}
+
+func AbortSCIM(c *gin.Context, status int, detail string) {
+	c.Header("Content-Type", "application/scim+json")
+	c.AbortWithStatusJSON(status, gin.H{
+		"schemas": []string{service.SCIMErrorSchema},
+		"status":  strconv.Itoa(status),
+		"detail":  detail,
+	})
+}
@@ -4,25 +4,27 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"rbac/config"
+	"rbac/dto"
 	"rbac/utils"
 )
 
-func Temp2FAMiddleware(cfg *config.Config) gin.HandlerFunc {
+func Temp2FAMiddleware(keys *utils.KeyRing) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		raw := c.GetHeader("X-2FA-Token")
 		if raw == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "missing 2fa token",
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Error: "missing 2fa token",
+				Code:  dto.CodeTwoFASessionInvalid,
 			})
 			c.Abort()
 			return
 		}
 
-		claims, err := utils.Parse2FAToken(raw, cfg.JWT.AccessSecret)
+		claims, err := utils.Parse2FAToken(raw, keys)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "invalid or expired 2fa session",
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Error: "invalid or expired 2fa session",
+				Code:  dto.CodeTwoFASessionInvalid,
 			})
 			c.Abort()
 			return
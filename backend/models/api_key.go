@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scopes an API key can be granted (machine-to-machine integrations)
+type APIScope string
+
+const (
+	ScopeTicketsRead  APIScope = "tickets:read"
+	ScopeTicketsWrite APIScope = "tickets:write"
+	ScopeAMCRead      APIScope = "amc:read"
+	ScopeAMCWrite     APIScope = "amc:write"
+	ScopeProductsRead APIScope = "products:read"
+
+	// Identity provider pushing staff accounts to /scim/v2
+	ScopeSCIMProvision APIScope = "scim:provision"
+)
+
+var APIScopes = []APIScope{
+	ScopeTicketsRead,
+	ScopeTicketsWrite,
+	ScopeAMCRead,
+	ScopeAMCWrite,
+	ScopeProductsRead,
+	ScopeSCIMProvision,
+}
+
+func (s APIScope) Valid() bool {
+	for _, known := range APIScopes {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+type APIKey struct {
+	ID     uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name   string     `gorm:"not null"`
+	Prefix string     `gorm:"uniqueIndex;not null"` // public part, shown in listings
+	Hash   string     `gorm:"not null"`             // SHA-256 of the full key
+	Scopes []APIScope `gorm:"serializer:json;type:text"`
+
+	RateLimitPerMinute int `gorm:"not null;default:60"`
+
+	ExpiresAt  *time.Time
+	RevokedAt  *time.Time
+	LastUsedAt *time.Time
+	LastUsedIP string
+
+	// Requests run as this admin (audit trail, created_by columns)
+	CreatedBy uuid.UUID `gorm:"type:uuid;index;not null"`
+	CreatedAt time.Time
+
+	// Loaded by APIKeyRepository: users has its own created_by, so a gorm
+	// association here resolves to users.created_by → api_keys.id
+	Owner User `gorm:"-"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+func (k *APIKey) HasScope(scope APIScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -15,6 +15,9 @@ type AuditLog struct {
 	IP          string
 	UserAgent   string
 	CreatedAt   time.Time
+
+	// Set on everything done under break-glass elevation
+	ElevationID *uuid.UUID `gorm:"type:uuid;index"`
 }
 
 func (AuditLog) TableName() string {
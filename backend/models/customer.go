@@ -6,6 +6,8 @@ import (
 	"github.com/google/uuid"
 )
 
+// A client company. Its users are CustomerMembers; UserID is the
+// founding contact the account was created for.
 type Customer struct {
 	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
 	UserID    uuid.UUID `gorm:"type:uuid;uniqueIndex"` // RoleCustomer
@@ -18,3 +20,36 @@ type Customer struct {
 
 	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 }
+
+// Which tickets a member sees in the customer portal
+type TicketVisibility string
+
+const (
+	TicketVisibilityOwn TicketVisibility = "own" // tickets they raised
+	TicketVisibilityAll TicketVisibility = "all" // every ticket of the company
+)
+
+// One user in one customer organization. IsAdmin is the customer-admin
+// sub-role: invites colleagues and sets their ticket visibility.
+type CustomerMember struct {
+	ID               uuid.UUID        `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	CustomerID       uuid.UUID        `gorm:"type:uuid;index;not null"`
+	UserID           uuid.UUID        `gorm:"type:uuid;uniqueIndex;not null"`
+	IsAdmin          bool             `gorm:"default:false"`
+	TicketVisibility TicketVisibility `gorm:"type:varchar(10);default:own"`
+	InvitedBy        *uuid.UUID       `gorm:"type:uuid"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+
+	Customer Customer `gorm:"foreignKey:CustomerID;constraint:OnDelete:CASCADE"`
+	User     User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (CustomerMember) TableName() string {
+	return "customer_members"
+}
+
+// Company-wide view: customer-admins always have it
+func (m *CustomerMember) SeesAllTickets() bool {
+	return m.IsAdmin || m.TicketVisibility == TicketVisibilityAll
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ElevationStatus string
+
+const (
+	ElevationPending ElevationStatus = "pending"
+	ElevationActive  ElevationStatus = "active"
+	ElevationDenied  ElevationStatus = "denied"
+	ElevationExpired ElevationStatus = "expired" // ran out, or never approved in time
+	ElevationEnded   ElevationStatus = "ended"   // given back early by the user
+	ElevationRevoked ElevationStatus = "revoked" // cut short by an admin
+)
+
+// Break-glass: a staff user holds Role instead of BaseRole for Duration
+// minutes, starting when another admin approves.
+type ElevationGrant struct {
+	ID       uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID   uuid.UUID       `gorm:"type:uuid;index;not null"`
+	BaseRole Role            `gorm:"type:varchar(20);not null"`
+	Role     Role            `gorm:"type:varchar(20);not null"`
+	Reason   string          `gorm:"type:text;not null"`
+	Duration int             `gorm:"not null"` // minutes
+	Status   ElevationStatus `gorm:"type:varchar(20);index;not null"`
+
+	DecidedBy *uuid.UUID `gorm:"type:uuid"` // approved / denied by
+	DecidedAt *time.Time
+	ExpiresAt *time.Time `gorm:"index"`
+	EndedAt   *time.Time
+	EndedBy   *uuid.UUID `gorm:"type:uuid"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (ElevationGrant) TableName() string {
+	return "elevation_grants"
+}
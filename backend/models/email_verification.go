@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EmailVerificationPurpose string
+
+const (
+	EmailPurposeVerify EmailVerificationPurpose = "verify" // confirm the current address
+	EmailPurposeChange EmailVerificationPurpose = "change" // move the account to Email
+)
+
+// Single-use link mailed to Email (stored hashed)
+type EmailVerificationToken struct {
+	ID        uuid.UUID                `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    uuid.UUID                `gorm:"type:uuid;index;not null"`
+	Email     string                   `gorm:"not null"`
+	Purpose   EmailVerificationPurpose `gorm:"type:varchar(20);not null"`
+	TokenHash string                   `gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time                `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (EmailVerificationToken) TableName() string {
+	return "email_verification_tokens"
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationExpired  InvitationStatus = "expired" // derived: pending past ExpiresAt
+	InvitationRevoked  InvitationStatus = "revoked"
+)
+
+// Admin-created account waiting for its owner to set a password.
+// ResetTokenID points at the PasswordResetToken from the latest send.
+type Invitation struct {
+	ID           uuid.UUID        `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID       uuid.UUID        `gorm:"type:uuid;index;not null"`
+	InvitedBy    uuid.UUID        `gorm:"type:uuid;not null"`
+	ResetTokenID *uuid.UUID       `gorm:"type:uuid"`
+	Status       InvitationStatus `gorm:"type:varchar(20);index;not null;default:pending"`
+	ExpiresAt    time.Time        `gorm:"not null"`
+
+	// Delivery is best effort; failures are recorded, not returned
+	SendCount     int `gorm:"not null;default:0"`
+	LastSentAt    *time.Time
+	LastSendError string `gorm:"type:text"`
+
+	AcceptedAt *time.Time
+	RevokedAt  *time.Time
+	RevokedBy  *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (Invitation) TableName() string {
+	return "invitations"
+}
+
+// Pending invitations past their deadline report as expired
+func (i *Invitation) EffectiveStatus(now time.Time) InvitationStatus {
+	if i.Status == InvitationPending && now.After(i.ExpiresAt) {
+		return InvitationExpired
+	}
+	return i.Status
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type LoginOutcome string
+
+const (
+	LoginSucceeded  LoginOutcome = "success"
+	LoginFailed     LoginOutcome = "failure"
+	LoginLocked     LoginOutcome = "locked"
+	LoginChallenged LoginOutcome = "2fa_required" // password ok, second factor pending
+)
+
+type LoginMethod string
+
+const (
+	LoginMethodPassword LoginMethod = "password"
+	LoginMethodPasskey  LoginMethod = "passkey"
+	LoginMethodSSO      LoginMethod = "sso"
+	LoginMethodTwoFA    LoginMethod = "2fa"     // second step of a password login
+	LoginMethodRefresh  LoginMethod = "refresh" // session moved to a new IP / agent
+)
+
+// One sign-in attempt. UserID is nil when the email matched no account.
+type LoginEvent struct {
+	ID          uuid.UUID    `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID      *uuid.UUID   `gorm:"type:uuid;index:idx_login_events_user_created,priority:1"`
+	Email       string       // as typed on the login form
+	Method      LoginMethod  `gorm:"type:varchar(20);not null"`
+	TwoFAMethod string       `gorm:"column:two_fa_method;type:varchar(20)"`
+	Outcome     LoginOutcome `gorm:"type:varchar(20);not null"`
+	Reason      string       `gorm:"type:varchar(50)"` // failure code (dto.ErrorCode)
+
+	IPAddress  string
+	IPRange    string // /24 (IPv4) or /48 (IPv6) the address belongs to
+	UserAgent  string
+	DeviceHash string // sha256 of the user agent
+
+	// Unseen on any earlier successful login (drives the alert email)
+	NewDevice  bool `gorm:"default:false"`
+	NewIPRange bool `gorm:"default:false"`
+
+	CreatedAt time.Time `gorm:"index:idx_login_events_user_created,priority:2"`
+
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (LoginEvent) TableName() string {
+	return "login_events"
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Failed-attempt counters used for login / 2FA brute-force protection
+const (
+	ThrottleScopeAccount = "account" // key = normalized email
+	ThrottleScopeIP      = "ip"      // key = client IP
+	ThrottleScopeTwoFA   = "2fa"     // key = user ID
+)
+
+type LoginThrottle struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Scope         string    `gorm:"not null;uniqueIndex:idx_login_throttle_scope_key"`
+	Key           string    `gorm:"not null;uniqueIndex:idx_login_throttle_scope_key"`
+	Failures      int       `gorm:"not null;default:0"`
+	LockedUntil   *time.Time
+	LastFailureAt time.Time
+	UpdatedAt     time.Time
+}
+
+func (LoginThrottle) TableName() string {
+	return "login_throttles"
+}
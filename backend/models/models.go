@@ -14,6 +14,14 @@ const (
 	RoleCustomer Role = "customer"
 )
 
+type TwoFAMethod string
+
+const (
+	TwoFAMethodEmail    TwoFAMethod = "email"
+	TwoFAMethodTOTP     TwoFAMethod = "totp"
+	TwoFAMethodWebAuthn TwoFAMethod = "webauthn"
+)
+
 // type User struct {
 // 	ID                uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
 // 	Name              string    `gorm:"type:varchar(100)"` // 👈 pointer
@@ -39,9 +47,33 @@ type User struct {
 	MustResetPassword bool       `gorm:"default:false"`
 	CreatedBy         *uuid.UUID `gorm:"type:uuid;index"`
 
-	TwoFAEnabled bool `gorm:"column:two_fa_enabled;default:false"`
+	// Set once the user proves they read mail at Email (link, invite, SSO)
+	EmailVerifiedAt *time.Time
+
+	TwoFAEnabled bool        `gorm:"column:two_fa_enabled;default:false"`
+	TwoFAMethod  TwoFAMethod `gorm:"column:two_fa_method;type:varchar(20);default:email"`
 	LastLoginAt  *time.Time
 
+	// TOTP (authenticator app) — secret is AES-GCM encrypted
+	TOTPSecret      string     `gorm:"column:totp_secret;type:text"`
+	TOTPConfirmedAt *time.Time `gorm:"column:totp_confirmed_at"`
+	TOTPLastStep    int64      `gorm:"column:totp_last_step;default:0"`
+
+	// New secret from SetupTOTP; replaces TOTPSecret only once confirmed
+	TOTPPendingSecret string `gorm:"column:totp_pending_secret;type:text"`
+
+	// Corporate IdP `sub` (staff SSO), linked on first OIDC login
+	OIDCSubject *string `gorm:"column:oidc_subject;uniqueIndex"`
+
+	// IdP's id for the user when provisioned over SCIM
+	SCIMExternalID *string `gorm:"column:scim_external_id;uniqueIndex"`
+
+	// Lifecycle: deactivation is reversible, offboarding is not
+	// (credentials are wiped and the email is released)
+	DeactivatedAt *time.Time
+	DeactivatedBy *uuid.UUID `gorm:"type:uuid"`
+	OffboardedAt  *time.Time
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -52,7 +84,22 @@ type RefreshToken struct {
 	Token     string    `gorm:"uniqueIndex;not null"`
 	ExpiresAt time.Time `gorm:"not null"`
 	IsRevoked bool      `gorm:"default:false"`
-	CreatedAt time.Time
+
+	// Rotation chain: every token minted from one login shares a family.
+	// RotatedAt is set when the token is exchanged for its successor, so a
+	// rotated token showing up again means it was replayed.
+	FamilyID  uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();index;not null"`
+	RotatedAt *time.Time
+
+	// Original login (auth_time / amr), copied into every refreshed access token
+	AuthTime    *time.Time
+	AuthMethods []string `gorm:"serializer:json;type:text"`
+
+	// Session metadata (shown in /profile/sessions)
+	UserAgent  string
+	IPAddress  string
+	LastUsedAt *time.Time
+	CreatedAt  time.Time // carried over on rotation = session start
 
 	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 }
@@ -80,6 +127,7 @@ type TwoFAOTP struct {
 	Code      string
 	ExpiresAt time.Time
 	Used      bool
+	Attempts  int `gorm:"not null;default:0"` // wrong guesses against this code
 	CreatedAt time.Time
 }
 
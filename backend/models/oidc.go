@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Pending OIDC authorization-code flow (state → nonce + PKCE verifier)
+type OIDCLoginState struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	State        string    `gorm:"uniqueIndex;not null"` // SHA-256 of the state param
+	Nonce        string    `gorm:"not null"`
+	CodeVerifier string    `gorm:"not null"`
+	ExpiresAt    time.Time `gorm:"index;not null"`
+	CreatedAt    time.Time
+}
+
+func (OIDCLoginState) TableName() string {
+	return "oidc_login_states"
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Previous password hash, kept to stop users cycling back to old passwords
+type PasswordHistory struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;not null"`
+	Hash      string    `gorm:"not null"`
+	CreatedAt time.Time
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_histories"
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// What a role may do; routes declare the permission they need
+type Permission string
+
+const (
+	PermDashboardView Permission = "dashboard.view"
+
+	PermUserRead        Permission = "user.read"
+	PermUserCreate      Permission = "user.create"
+	PermUserUnlock      Permission = "user.unlock"
+	PermUserSessions    Permission = "user.sessions"
+	PermUserDeactivate  Permission = "user.deactivate"
+	PermUserOffboard    Permission = "user.offboard"
+	PermUserImpersonate Permission = "user.impersonate"
+
+	PermRoleManage       Permission = "role.manage"
+	PermAPIKeyManage     Permission = "apikey.manage"
+	PermElevationApprove Permission = "elevation.approve"
+
+	PermProductRead   Permission = "product.read"
+	PermProductCreate Permission = "product.create"
+	PermProductAssign Permission = "product.assign"
+	PermCatalogManage Permission = "catalog.manage" // categories, brands, models
+
+	PermAMCRead   Permission = "amc.read"
+	PermAMCCreate Permission = "amc.create"
+
+	PermTicketRead   Permission = "ticket.read"
+	PermTicketCreate Permission = "ticket.create" // on behalf of a customer
+	PermTicketAssign Permission = "ticket.assign"
+	PermTicketWork   Permission = "ticket.work" // start / close assigned tickets
+
+	PermTeamManage Permission = "team.manage"
+)
+
+type PermissionInfo struct {
+	Key         Permission `json:"key"`
+	Description string     `json:"description"`
+
+	// Escalation paths (roles, keys, acting as someone, removing
+	// accounts) stay with the built-in admin role
+	AdminOnly bool `json:"admin_only"`
+}
+
+var PermissionCatalog = []PermissionInfo{
+	{Key: PermDashboardView, Description: "View the admin dashboard"},
+
+	{Key: PermUserRead, Description: "List users and support engineers"},
+	{Key: PermUserCreate, Description: "Invite users and resend pending invitations"},
+	{Key: PermUserUnlock, Description: "Unlock accounts locked by failed logins"},
+	{Key: PermUserSessions, Description: "View and revoke user sessions, devices and login history"},
+	{Key: PermUserDeactivate, Description: "Deactivate and reactivate users, revoke invitations", AdminOnly: true},
+	{Key: PermUserOffboard, Description: "Offboard users permanently", AdminOnly: true},
+	{Key: PermUserImpersonate, Description: "Impersonate users", AdminOnly: true},
+
+	{Key: PermRoleManage, Description: "Define roles and assign them to users", AdminOnly: true},
+	{Key: PermAPIKeyManage, Description: "Issue and revoke API keys", AdminOnly: true},
+	{Key: PermElevationApprove, Description: "Approve, deny and revoke break-glass elevation", AdminOnly: true},
+
+	{Key: PermProductRead, Description: "View products, customer products and lookups"},
+	{Key: PermProductCreate, Description: "Create products"},
+	{Key: PermProductAssign, Description: "Assign products to customers"},
+	{Key: PermCatalogManage, Description: "Create categories, brands and models"},
+
+	{Key: PermAMCRead, Description: "View all AMC contracts"},
+	{Key: PermAMCCreate, Description: "Create AMC contracts"},
+
+	{Key: PermTicketRead, Description: "View all tickets"},
+	{Key: PermTicketCreate, Description: "Raise tickets on behalf of customers"},
+	{Key: PermTicketAssign, Description: "Assign tickets to support engineers"},
+	{Key: PermTicketWork, Description: "Work assigned tickets (start, close with proof)"},
+
+	{Key: PermTeamManage, Description: "Create support teams, set their leads and members, and queue tickets to them"},
+}
+
+func (p Permission) Info() (PermissionInfo, bool) {
+	for _, info := range PermissionCatalog {
+		if info.Key == p {
+			return info, true
+		}
+	}
+	return PermissionInfo{}, false
+}
+
+func AllPermissions() []Permission {
+	all := make([]Permission, 0, len(PermissionCatalog))
+	for _, info := range PermissionCatalog {
+		all = append(all, info.Key)
+	}
+	return all
+}
+
+/*
+=====================
+ Roles
+=====================
+ users.role holds Name. admin, support and customer are built in
+ (IsSystem); admins can add staff roles as permission sets.
+*/
+
+type RoleDefinition struct {
+	ID          uuid.UUID    `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name        Role         `gorm:"type:varchar(20);uniqueIndex;not null"`
+	DisplayName string       `gorm:"type:varchar(100);not null"`
+	Description string       `gorm:"type:text"`
+	Permissions []Permission `gorm:"serializer:json;type:text"`
+	IsSystem    bool         `gorm:"default:false"`
+
+	CreatedBy *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (RoleDefinition) TableName() string {
+	return "roles"
+}
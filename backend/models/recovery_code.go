@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Single-use 2FA recovery code (stored hashed)
+type RecoveryCode struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;not null"`
+	CodeHash  string    `gorm:"not null;uniqueIndex"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
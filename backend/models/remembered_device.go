@@ -7,13 +7,14 @@ import (
 )
 
 type RememberedDevice struct {
-	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	UserID    uuid.UUID `gorm:"type:uuid;not null"`
-	Token     string    `gorm:"not null;uniqueIndex"`
-	UserAgent string
-	IPAddress string
-	ExpiresAt time.Time `gorm:"not null"`
-	CreatedAt time.Time
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null"`
+	Token      string    `gorm:"not null;uniqueIndex"`
+	UserAgent  string
+	IPAddress  string
+	ExpiresAt  time.Time `gorm:"not null"`
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
 }
 
 func (RememberedDevice) TableName() string {
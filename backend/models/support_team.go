@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A group of support engineers (a region, a product line, ...). Tickets
+// can be queued to a team; leads see and reassign their team's tickets.
+type SupportTeam struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name        string    `gorm:"type:varchar(100);uniqueIndex;not null"`
+	Description string    `gorm:"type:text"`
+	CreatedBy   uuid.UUID `gorm:"type:uuid"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+
+	Members []SupportTeamMember `gorm:"foreignKey:TeamID;constraint:OnDelete:CASCADE"`
+}
+
+func (SupportTeam) TableName() string {
+	return "support_teams"
+}
+
+// An engineer may sit in several teams and lead any of them
+type SupportTeamMember struct {
+	ID                uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TeamID            uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_team_engineer;not null"`
+	SupportEngineerID uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_team_engineer;not null"`
+	IsLead            bool      `gorm:"default:false"`
+	AddedBy           uuid.UUID `gorm:"type:uuid"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+
+	Engineer SupportEngineer `gorm:"foreignKey:SupportEngineerID;constraint:OnDelete:CASCADE"`
+}
+
+func (SupportTeamMember) TableName() string {
+	return "support_team_members"
+}
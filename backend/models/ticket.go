@@ -59,6 +59,12 @@ type Ticket struct {
 	CreatedBy         uuid.UUID `gorm:"type:uuid"`
 	CreatedAt         time.Time
 	UpdatedAt         time.Time
+
+	// Set when the assigned engineer was deactivated with no successor
+	NeedsReassignment bool `gorm:"default:false;index"`
+
+	// Support team queue (nil = unscoped)
+	TeamID *uuid.UUID `gorm:"type:uuid;index"`
 }
 
 type TicketAssignment struct {
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Single access token killed before expiry (logout)
+type RevokedAccessToken struct {
+	JTI       string    `gorm:"primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;index"`
+	ExpiresAt time.Time `gorm:"index;not null"` // row can be purged after this
+	CreatedAt time.Time
+}
+
+func (RevokedAccessToken) TableName() string {
+	return "revoked_access_tokens"
+}
+
+// Every access token of the user issued before NotBefore is rejected
+// (password change / reset, deactivation, "sign out everywhere")
+type UserTokenCutoff struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	NotBefore time.Time `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+func (UserTokenCutoff) TableName() string {
+	return "user_token_cutoffs"
+}
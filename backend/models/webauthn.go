@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Registered passkey / security key
+type WebAuthnCredential struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID       uuid.UUID `gorm:"type:uuid;index;not null"`
+	CredentialID []byte    `gorm:"type:bytea;uniqueIndex;not null"`
+	Name         string    `gorm:"type:varchar(100)"`
+	Data         string    `gorm:"type:text;not null"` // webauthn.Credential as JSON
+	LastUsedAt   *time.Time
+	CreatedAt    time.Time
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
+
+// In-flight registration / assertion ceremony
+type WebAuthnSession struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    *uuid.UUID `gorm:"type:uuid;index"` // nil for passwordless login
+	Ceremony  string     `gorm:"type:varchar(30);not null"`
+	Data      string     `gorm:"type:text;not null"` // webauthn.SessionData as JSON
+	ExpiresAt time.Time  `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+func (WebAuthnSession) TableName() string {
+	return "webauthn_sessions"
+}
@@ -28,7 +28,8 @@ func (r *AMCRepository) FindAll() ([]models.AMCContract, error) {
 	return amcs, err
 }
 
-// Customer: fetch AMC contracts using CUSTOMER'S USER ID (UUID)
+// Customer: AMC contracts of the organization the USER ID belongs to
+// (contract → customer product → organization → member)
 func (r *AMCRepository) FindByCustomerUserID(
 	userID uuid.UUID,
 ) ([]models.AMCContract, error) {
@@ -36,14 +37,23 @@ func (r *AMCRepository) FindByCustomerUserID(
 	var amcs []models.AMCContract
 
 	err := r.db.
-		Joins("JOIN customers c ON c.id = amc_contracts.customer_id").
-		Where("c.user_id = ?", userID).
+		Joins("JOIN customer_products cp ON cp.id = amc_contracts.customer_product_id").
+		Joins("JOIN customer_members m ON m.customer_id = cp.customer_id").
+		Where("m.user_id = ?", userID).
 		Order("amc_contracts.end_date ASC").
 		Find(&amcs).
 		Error
 
 	return amcs, err
 }
+func (r *AMCRepository) FindByID(id uuid.UUID) (*models.AMCContract, error) {
+	var amc models.AMCContract
+	if err := r.db.First(&amc, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &amc, nil
+}
+
 func (r *AMCRepository) Create(contract *models.AMCContract) error {
 	return r.db.Create(contract).Error
 }
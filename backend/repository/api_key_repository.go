@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/models"
+)
+
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) Create(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *APIKeyRepository) FindOwner(userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := r.db.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *APIKeyRepository) FindAll() ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// Usable = not revoked, not expired, owner still active
+func (r *APIKeyRepository) FindUsableByPrefix(prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+
+	err := r.db.
+		Where(
+			"prefix = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)",
+			prefix,
+			time.Now(),
+		).
+		First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.db.First(&key.Owner, "id = ?", key.CreatedBy).Error; err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *APIKeyRepository) Revoke(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// At most one write per key per minute, not one per request
+func (r *APIKeyRepository) TouchLastUsed(id uuid.UUID, ip string) error {
+	now := time.Now()
+	return r.db.Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-time.Minute)).
+		Updates(map[string]interface{}{
+			"last_used_at": now,
+			"last_used_ip": ip,
+		}).Error
+}
@@ -33,3 +33,23 @@ func (r *AuditRepository) Log(
 		UserAgent:  userAgent,
 	}).Error
 }
+
+// Flagged with the grant so elevated activity can be pulled out later
+func (r *AuditRepository) LogElevated(
+	grantID uuid.UUID,
+	action string,
+	performedBy uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	return r.db.Create(&models.AuditLog{
+		Entity:      "elevation",
+		EntityID:    grantID,
+		Action:      action,
+		PerformedBy: performedBy,
+		IP:          ip,
+		UserAgent:   userAgent,
+		ElevationID: &grantID,
+	}).Error
+}
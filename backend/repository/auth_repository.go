@@ -51,6 +51,41 @@ func (r *AuthRepository) FindUserByID(id uuid.UUID) (*models.User, error) {
 	return &user, nil
 }
 
+/* =====================
+   SSO (OIDC)
+===================== */
+
+// Includes inactive users so SSO can tell "disabled" from "unknown"
+func (r *AuthRepository) FindUserByOIDCSubject(subject string) (*models.User, error) {
+	var user models.User
+	err := r.db.Where("oidc_subject = ?", subject).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *AuthRepository) FindAnyUserByEmail(email string) (*models.User, error) {
+	var user models.User
+	err := r.db.Where("LOWER(email) = LOWER(?)", email).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *AuthRepository) LinkOIDCSubject(userID uuid.UUID, subject string) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("oidc_subject", subject).Error
+}
+
+func (r *AuthRepository) UpdateUserRole(userID uuid.UUID, role models.Role) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("role", role).Error
+}
+
 func (r *AuthRepository) CreateUser(user *models.User) error {
 	return r.db.Create(user).Error
 }
@@ -74,6 +109,62 @@ func (r *AuthRepository) UpdateUserPassword(
 		}).Error
 }
 
+// The address proved itself (invite / reset link, verified SSO claim)
+func (r *AuthRepository) MarkEmailVerified(userID uuid.UUID) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ? AND email_verified_at IS NULL", userID).
+		Update("email_verified_at", time.Now()).Error
+}
+
+/* =====================
+   Password History
+===================== */
+
+// Stores the outgoing hash and keeps only the newest `keep` rows
+func (r *AuthRepository) AddPasswordHistory(
+	userID uuid.UUID,
+	hash string,
+	keep int,
+) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&models.PasswordHistory{
+			UserID: userID,
+			Hash:   hash,
+		}).Error; err != nil {
+			return err
+		}
+
+		keepIDs := tx.Model(&models.PasswordHistory{}).
+			Select("id").
+			Where("user_id = ?", userID).
+			Order("created_at DESC").
+			Limit(keep)
+
+		return tx.Where("user_id = ? AND id NOT IN (?)", userID, keepIDs).
+			Delete(&models.PasswordHistory{}).Error
+	})
+}
+
+func (r *AuthRepository) RecentPasswordHashes(
+	userID uuid.UUID,
+	limit int,
+) ([]string, error) {
+	var hashes []string
+	err := r.db.Model(&models.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Pluck("hash", &hashes).Error
+	return hashes, err
+}
+
+// Rehash on login: same password, stronger hash; nothing else changes
+func (r *AuthRepository) UpdatePasswordHash(userID uuid.UUID, hash string) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("password", hash).Error
+}
+
 /* =====================
    Refresh Tokens
 ===================== */
@@ -108,6 +199,53 @@ func (r *AuthRepository) FindRefreshToken(tokenHash string) (*models.RefreshToke
 	return &rt, nil
 }
 
+// Unlike FindRefreshToken this also returns revoked / expired tokens,
+// so rotation can tell a replayed token apart from an unknown one.
+func (r *AuthRepository) FindRefreshTokenAny(tokenHash string) (*models.RefreshToken, error) {
+	var rt models.RefreshToken
+
+	err := r.db.
+		Preload("User").
+		Where("token = ?", tokenHash).
+		First(&rt).
+		Error
+
+	if err != nil {
+		return nil, errors.New("invalid or expired refresh token")
+	}
+
+	return &rt, nil
+}
+
+// Marks the token as exchanged; fails if someone else got there first
+func (r *AuthRepository) RotateRefreshToken(tokenHash string) error {
+	now := time.Now()
+
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("token = ? AND is_revoked = false", tokenHash).
+		Updates(map[string]interface{}{
+			"is_revoked": true,
+			"rotated_at": now,
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New("refresh token already revoked or not found")
+	}
+
+	return nil
+}
+
+func (r *AuthRepository) RevokeTokenFamily(familyID uuid.UUID) (int64, error) {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND is_revoked = false", familyID).
+		Update("is_revoked", true)
+	return result.RowsAffected, result.Error
+}
+
 func (r *AuthRepository) RevokeRefreshToken(tokenHash string) error {
 	result := r.db.Model(&models.RefreshToken{}).
 		Where("token = ? AND is_revoked = false", tokenHash).
@@ -124,6 +262,51 @@ func (r *AuthRepository) RevokeRefreshToken(tokenHash string) error {
 	return nil
 }
 
+/* =====================
+   Sessions
+===================== */
+
+func (r *AuthRepository) ListActiveSessions(
+	userID uuid.UUID,
+) ([]models.RefreshToken, error) {
+	var sessions []models.RefreshToken
+	err := r.db.
+		Where(
+			"user_id = ? AND is_revoked = false AND expires_at > ?",
+			userID,
+			time.Now(),
+		).
+		Order("created_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Revokes the live refresh token and hands it back (nil = not found),
+// so the caller can deny the access tokens of its family too
+func (r *AuthRepository) RevokeSessionByID(
+	userID uuid.UUID,
+	id uuid.UUID,
+) (*models.RefreshToken, error) {
+	var rt models.RefreshToken
+	err := r.db.
+		Where("id = ? AND user_id = ? AND is_revoked = false", id, userID).
+		First(&rt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND is_revoked = false", id).
+		Update("is_revoked", true)
+	if result.Error != nil || result.RowsAffected != 1 {
+		return nil, result.Error
+	}
+	return &rt, nil
+}
+
 /* =====================
    Password Reset
 ===================== */
@@ -185,6 +368,20 @@ func (r *AuthRepository) FindValid2FAOTP(
 	return &otp, nil
 }
 
+// Counts a wrong guess against the user's live OTP and burns it once
+// maxAttempts is reached, so a 6-digit code can't be brute-forced.
+func (r *AuthRepository) RegisterOTPFailure(
+	userID uuid.UUID,
+	maxAttempts int,
+) error {
+	return r.db.Model(&models.TwoFAOTP{}).
+		Where("user_id = ? AND used = false AND expires_at > NOW()", userID).
+		Updates(map[string]interface{}{
+			"attempts": gorm.Expr("attempts + 1"),
+			"used":     gorm.Expr("attempts + 1 >= ?", maxAttempts),
+		}).Error
+}
+
 func (r *AuthRepository) MarkOTPUsed(id uuid.UUID) error {
 	return r.db.Model(&models.TwoFAOTP{}).
 		Where("id = ?", id).
@@ -215,6 +412,138 @@ func (r *AuthRepository) Disable2FA(userID uuid.UUID) error {
 		Update("two_fa_enabled", false).Error
 }
 
+func (r *AuthRepository) SetTwoFAMethod(
+	userID uuid.UUID,
+	method models.TwoFAMethod,
+) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"two_fa_enabled": true,
+			"two_fa_method":  method,
+		}).Error
+}
+
+/* =====================
+   2FA TOTP
+===================== */
+
+// Stores a pending (unconfirmed) secret, replacing any previous pending
+// one. A working authenticator stays in use until ConfirmTOTP.
+func (r *AuthRepository) SetPendingTOTPSecret(
+	userID uuid.UUID,
+	encryptedSecret string,
+) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("totp_pending_secret", encryptedSecret).Error
+}
+
+// Swaps the confirmed pending secret in. false = setup was restarted
+// (pending secret changed) in the meantime.
+func (r *AuthRepository) ConfirmTOTP(
+	userID uuid.UUID,
+	pendingSecret string,
+	step int64,
+) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_pending_secret = ?", userID, pendingSecret).
+		Updates(map[string]interface{}{
+			"totp_secret":         pendingSecret,
+			"totp_pending_secret": "",
+			"totp_confirmed_at":   time.Now(),
+			"totp_last_step":      step,
+			"two_fa_enabled":      true,
+			"two_fa_method":       models.TwoFAMethodTOTP,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *AuthRepository) ClearTOTP(userID uuid.UUID) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"totp_secret":         "",
+			"totp_pending_secret": "",
+			"totp_confirmed_at":   nil,
+			"totp_last_step":      0,
+			"two_fa_method":       models.TwoFAMethodEmail,
+		}).Error
+}
+
+// Atomically records the last accepted TOTP step.
+// Returns false if the step was already used (replay).
+func (r *AuthRepository) ConsumeTOTPStep(
+	userID uuid.UUID,
+	step int64,
+) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+/* =====================
+   2FA Recovery Codes
+===================== */
+
+// Replaces every code of the user with a fresh set
+func (r *AuthRepository) ReplaceRecoveryCodes(
+	userID uuid.UUID,
+	codeHashes []string,
+) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).
+			Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]models.RecoveryCode, 0, len(codeHashes))
+		for _, h := range codeHashes {
+			codes = append(codes, models.RecoveryCode{
+				UserID:   userID,
+				CodeHash: h,
+			})
+		}
+
+		return tx.Create(&codes).Error
+	})
+}
+
+// Atomically marks a code as used. Returns false if it doesn't exist or was used.
+func (r *AuthRepository) UseRecoveryCode(
+	userID uuid.UUID,
+	codeHash string,
+) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *AuthRepository) CountUnusedRecoveryCodes(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *AuthRepository) DeleteRecoveryCodes(userID uuid.UUID) error {
+	return r.db.Where("user_id = ?", userID).
+		Delete(&models.RecoveryCode{}).Error
+}
+
 /* =====================
    Audit
 ===================== */
@@ -235,22 +564,26 @@ func (r *AuthRepository) UpdateLastLogin(
 func (r *AuthRepository) GetUsersPaginated(
 	limit int,
 	offset int,
+	unverifiedOnly bool,
 ) ([]models.User, int64, error) {
 
 	var users []models.User
 	var total int64
 
+	base := r.db.Model(&models.User{}).Where("is_active = true")
+	if unverifiedOnly {
+		base = base.Where("email_verified_at IS NULL")
+	}
+
 	// Count total active users
-	if err := r.db.
-		Model(&models.User{}).
-		Where("is_active = true").
+	if err := base.Session(&gorm.Session{}).
 		Count(&total).
 		Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Fetch paginated users
-	query := r.db.Where("is_active = true")
+	query := base.Session(&gorm.Session{})
 
 	if err := query.Order("created_at DESC").
 		Limit(limit).
@@ -263,6 +596,22 @@ func (r *AuthRepository) GetUsersPaginated(
 	return users, total, nil
 }
 
+func (r *AuthRepository) RoleExists(role models.Role) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RoleDefinition{}).
+		Where("name = ?", role).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *AuthRepository) RolePermissions(role models.Role) ([]models.Permission, error) {
+	var def models.RoleDefinition
+	if err := r.db.Where("name = ?", role).First(&def).Error; err != nil {
+		return nil, err
+	}
+	return def.Permissions, nil
+}
+
 func (r *AuthRepository) GetUsersByRole(role models.Role) ([]models.User, error) {
 	var users []models.User
 	err := r.db.Where("role = ? AND is_active = true", role).
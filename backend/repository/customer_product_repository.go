@@ -39,7 +39,8 @@ func (r *CustomerProductRepository) GetCustomerByUserID(
 
 	var customer models.Customer
 	err := r.db.
-		Where("user_id = ?", userID).
+		Joins("JOIN customer_members m ON m.customer_id = customers.id").
+		Where("m.user_id = ?", userID).
 		First(&customer).Error
 
 	return &customer, err
@@ -64,6 +65,14 @@ func (r *CustomerProductRepository) Assign(
 		}).Error
 }
 
+func (r *CustomerProductRepository) GetByID(id uuid.UUID) (*models.CustomerProduct, error) {
+	var cp models.CustomerProduct
+	if err := r.db.Preload("Product").First(&cp, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
 func (r *CustomerProductRepository) GetByCustomerID(customerID uuid.UUID) ([]models.CustomerProduct, error) {
 	var results []models.CustomerProduct
 	err := r.db.Preload("Product").Where("customer_id = ? AND is_active = true", customerID).
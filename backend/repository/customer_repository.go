@@ -23,6 +23,7 @@ func (r *CustomerRepository) Create(
 	return tx.Create(customer).Error
 }
 
+// The organization the user belongs to (any member, not only the founder)
 func (r *CustomerRepository) FindByUserID(
 	userID uuid.UUID,
 ) (*models.Customer, error) {
@@ -31,7 +32,8 @@ func (r *CustomerRepository) FindByUserID(
 
 	err := r.db.
 		Preload("User").
-		Where("user_id = ?", userID).
+		Joins("JOIN customer_members m ON m.customer_id = customers.id").
+		Where("m.user_id = ?", userID).
 		First(&customer).Error
 
 	if err != nil {
@@ -44,6 +46,14 @@ func (r *CustomerRepository) FindByUserID(
 	return &customer, nil
 }
 
+func (r *CustomerRepository) FindByID(id uuid.UUID) (*models.Customer, error) {
+	var customer models.Customer
+	if err := r.db.First(&customer, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
 func (r *CustomerRepository) GetAllPaginated(
 	page int,
 	limit int,
@@ -65,3 +75,73 @@ func (r *CustomerRepository) GetAllPaginated(
 
 	return customers, total, err
 }
+
+/* =====================
+   Members
+===================== */
+
+func (r *CustomerRepository) CreateMember(
+	tx *gorm.DB,
+	member *models.CustomerMember,
+) error {
+	return tx.Create(member).Error
+}
+
+func (r *CustomerRepository) FindMembership(userID uuid.UUID) (*models.CustomerMember, error) {
+	var member models.CustomerMember
+	err := r.db.
+		Preload("Customer").
+		Where("user_id = ?", userID).
+		First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// Offboarded users are gone from the organization
+func (r *CustomerRepository) ListMembers(customerID uuid.UUID) ([]models.CustomerMember, error) {
+	var members []models.CustomerMember
+	err := r.db.
+		Preload("User").
+		Joins("JOIN users u ON u.id = customer_members.user_id").
+		Where("customer_members.customer_id = ? AND u.offboarded_at IS NULL", customerID).
+		Order("customer_members.created_at ASC").
+		Find(&members).Error
+	return members, err
+}
+
+func (r *CustomerRepository) UpdateMember(
+	customerID uuid.UUID,
+	userID uuid.UUID,
+	fields map[string]interface{},
+) (bool, error) {
+	result := r.db.Model(&models.CustomerMember{}).
+		Where("customer_id = ? AND user_id = ?", customerID, userID).
+		Updates(fields)
+	return result.RowsAffected == 1, result.Error
+}
+
+// Active customer-admins left in the organization
+func (r *CustomerRepository) CountAdmins(customerID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.CustomerMember{}).
+		Joins("JOIN users u ON u.id = customer_members.user_id").
+		Where("customer_members.customer_id = ? AND customer_members.is_admin = true AND u.is_active = true", customerID).
+		Count(&count).Error
+	return count, err
+}
+
+// Customers created before organizations existed: the founding user
+// becomes the organization's customer-admin
+func (r *CustomerRepository) EnsureFounderMemberships() error {
+	return r.db.Exec(`
+		INSERT INTO customer_members
+			(customer_id, user_id, is_admin, ticket_visibility, created_at, updated_at)
+		SELECT id, user_id, true, ?, NOW(), NOW()
+		FROM customers
+		WHERE user_id IS NOT NULL
+		ON CONFLICT (user_id) DO NOTHING`,
+		models.TicketVisibilityAll,
+	).Error
+}
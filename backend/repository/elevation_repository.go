@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/models"
+)
+
+type ElevationRepository struct {
+	db *gorm.DB
+}
+
+func NewElevationRepository(db *gorm.DB) *ElevationRepository {
+	return &ElevationRepository{db: db}
+}
+
+func (r *ElevationRepository) Create(grant *models.ElevationGrant) error {
+	return r.db.Create(grant).Error
+}
+
+func (r *ElevationRepository) FindByID(id uuid.UUID) (*models.ElevationGrant, error) {
+	var grant models.ElevationGrant
+	if err := r.db.Preload("User").First(&grant, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+func (r *ElevationRepository) FindUser(id uuid.UUID) (*models.User, error) {
+	var user models.User
+	err := r.db.
+		Where("id = ? AND offboarded_at IS NULL", id).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Pending or active; a user has at most one
+func (r *ElevationRepository) HasOpen(userID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.ElevationGrant{}).
+		Where("user_id = ? AND status IN ?", userID, []models.ElevationStatus{
+			models.ElevationPending,
+			models.ElevationActive,
+		}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *ElevationRepository) ListForUser(userID uuid.UUID) ([]models.ElevationGrant, error) {
+	var grants []models.ElevationGrant
+	err := r.db.
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(50).
+		Find(&grants).Error
+	return grants, err
+}
+
+// status "" = all
+func (r *ElevationRepository) List(status models.ElevationStatus) ([]models.ElevationGrant, error) {
+	var grants []models.ElevationGrant
+	q := r.db.Preload("User").Order("created_at DESC").Limit(200)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	err := q.Find(&grants).Error
+	return grants, err
+}
+
+// Moves the grant only if it is still in one of `from`, so two admins (or
+// the expiry job) racing on the same grant can't both win
+func (r *ElevationRepository) Transition(
+	id uuid.UUID,
+	from []models.ElevationStatus,
+	fields map[string]interface{},
+) (bool, error) {
+
+	result := r.db.Model(&models.ElevationGrant{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(fields)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Checked by AuthMiddleware on every elevated request
+func (r *ElevationRepository) IsActive(id, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.ElevationGrant{}).
+		Where(
+			"id = ? AND user_id = ? AND status = ? AND expires_at > ?",
+			id, userID, models.ElevationActive, time.Now(),
+		).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *ElevationRepository) ActiveExpired(now time.Time) ([]models.ElevationGrant, error) {
+	var grants []models.ElevationGrant
+	err := r.db.
+		Preload("User").
+		Where("status = ? AND expires_at <= ?", models.ElevationActive, now).
+		Find(&grants).Error
+	return grants, err
+}
+
+func (r *ElevationRepository) ExpirePendingBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Model(&models.ElevationGrant{}).
+		Where("status = ? AND created_at < ?", models.ElevationPending, cutoff).
+		Updates(map[string]interface{}{
+			"status":   models.ElevationExpired,
+			"ended_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// Who gets start / end notifications
+func (r *ElevationRepository) ActiveAdmins() ([]models.User, error) {
+	var admins []models.User
+	err := r.db.
+		Where("role = ? AND is_active = true", models.RoleAdmin).
+		Find(&admins).Error
+	return admins, err
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/models"
+)
+
+type EmailVerificationRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailVerificationRepository(db *gorm.DB) *EmailVerificationRepository {
+	return &EmailVerificationRepository{db: db}
+}
+
+func (r *EmailVerificationRepository) WithTransaction(
+	fn func(tx *EmailVerificationRepository) error,
+) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewEmailVerificationRepository(tx))
+	})
+}
+
+// Only the newest link per user + purpose stays usable
+func (r *EmailVerificationRepository) Create(t *models.EmailVerificationToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.EmailVerificationToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", t.UserID, t.Purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(t).Error
+	})
+}
+
+func (r *EmailVerificationRepository) FindValid(tokenHash string) (*models.EmailVerificationToken, error) {
+	var t models.EmailVerificationToken
+	err := r.db.
+		Preload("User").
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, time.Now()).
+		First(&t).Error
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Atomically marks the link used. Returns false if it was already used.
+func (r *EmailVerificationRepository) Consume(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.EmailVerificationToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Any other account (active or not) already holding the address?
+func (r *EmailVerificationRepository) EmailTaken(email string, exceptUserID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).
+		Where("LOWER(email) = LOWER(?) AND id <> ?", email, exceptUserID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Sets the (possibly new) address and stamps it verified
+func (r *EmailVerificationRepository) ApplyEmail(userID uuid.UUID, email string) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"email":             email,
+			"email_verified_at": time.Now(),
+		}).Error
+}
+
+// Pending change links die once the address they'd replace is gone
+func (r *EmailVerificationRepository) InvalidateForUser(userID uuid.UUID) error {
+	return r.db.Model(&models.EmailVerificationToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"rbac/models"
 )
@@ -16,3 +17,33 @@ func NewFeedbackRepository(db *gorm.DB) *FeedbackRepository {
 func (r *FeedbackRepository) Create(f *models.TicketFeedback) error {
 	return r.db.Create(f).Error
 }
+
+func (r *FeedbackRepository) FindTicket(ticketID uuid.UUID) (*models.Ticket, error) {
+	var ticket models.Ticket
+	if err := r.db.First(&ticket, "id = ?", ticketID).Error; err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// Engineer on the latest assignment; uuid.Nil if never assigned
+func (r *FeedbackRepository) CurrentAssignee(ticketID uuid.UUID) (uuid.UUID, error) {
+	var assignments []models.TicketAssignment
+	err := r.db.
+		Where("ticket_id = ?", ticketID).
+		Order("assigned_at DESC").
+		Limit(1).
+		Find(&assignments).Error
+	if err != nil || len(assignments) == 0 {
+		return uuid.Nil, err
+	}
+	return assignments[0].EngineerID, nil
+}
+
+func (r *FeedbackRepository) ExistsForTicket(ticketID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.TicketFeedback{}).
+		Where("ticket_id = ?", ticketID).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/models"
+)
+
+type InvitationRepository struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) *InvitationRepository {
+	return &InvitationRepository{db: db}
+}
+
+func (r *InvitationRepository) CreateTx(tx *gorm.DB, inv *models.Invitation) error {
+	return tx.Create(inv).Error
+}
+
+func (r *InvitationRepository) FindByID(id uuid.UUID) (*models.Invitation, error) {
+	var inv models.Invitation
+	if err := r.db.Preload("User").First(&inv, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// status "" = outstanding (pending, including expired)
+func (r *InvitationRepository) List(status models.InvitationStatus) ([]models.Invitation, error) {
+	var invitations []models.Invitation
+
+	q := r.db.Preload("User").Order("created_at DESC")
+	now := time.Now()
+
+	switch status {
+	case "":
+		q = q.Where("status = ?", models.InvitationPending)
+	case models.InvitationPending:
+		q = q.Where("status = ? AND expires_at > ?", models.InvitationPending, now)
+	case models.InvitationExpired:
+		q = q.Where("status = ? AND expires_at <= ?", models.InvitationPending, now)
+	default:
+		q = q.Where("status = ?", status)
+	}
+
+	err := q.Find(&invitations).Error
+	return invitations, err
+}
+
+// New link (resend): back to pending with a fresh deadline
+func (r *InvitationRepository) Reissue(
+	id uuid.UUID,
+	resetTokenID uuid.UUID,
+	expiresAt time.Time,
+) error {
+	return r.db.Model(&models.Invitation{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":         models.InvitationPending,
+			"reset_token_id": resetTokenID,
+			"expires_at":     expiresAt,
+		}).Error
+}
+
+// sendErr "" = delivered
+func (r *InvitationRepository) RecordSend(id uuid.UUID, sendErr string) error {
+	return r.db.Model(&models.Invitation{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"send_count":      gorm.Expr("send_count + 1"),
+			"last_sent_at":    time.Now(),
+			"last_send_error": sendErr,
+		}).Error
+}
+
+func (r *InvitationRepository) AcceptForUser(userID uuid.UUID) error {
+	return r.db.Model(&models.Invitation{}).
+		Where("user_id = ? AND status = ?", userID, models.InvitationPending).
+		Updates(map[string]interface{}{
+			"status":      models.InvitationAccepted,
+			"accepted_at": time.Now(),
+		}).Error
+}
+
+func (r *InvitationRepository) Revoke(id uuid.UUID, revokedBy uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.Invitation{}).
+		Where("id = ? AND status = ?", id, models.InvitationPending).
+		Updates(map[string]interface{}{
+			"status":     models.InvitationRevoked,
+			"revoked_at": time.Now(),
+			"revoked_by": revokedBy,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Old set-password links stop working once a new one is sent / revoked
+func (r *InvitationRepository) InvalidateResetTokens(userID uuid.UUID) error {
+	return r.db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used = false", userID).
+		Update("used", true).Error
+}
+
+func (r *InvitationRepository) CreateResetTokenTx(
+	tx *gorm.DB,
+	reset *models.PasswordResetToken,
+) error {
+	return tx.Create(reset).Error
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/models"
+)
+
+type LoginEventRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginEventRepository(db *gorm.DB) *LoginEventRepository {
+	return &LoginEventRepository{db: db}
+}
+
+func (r *LoginEventRepository) Create(e *models.LoginEvent) error {
+	return r.db.Create(e).Error
+}
+
+// Newest first
+func (r *LoginEventRepository) ListByUser(
+	userID uuid.UUID,
+	limit int,
+	offset int,
+) ([]models.LoginEvent, int64, error) {
+
+	var events []models.LoginEvent
+	var total int64
+
+	q := r.db.Model(&models.LoginEvent{}).Where("user_id = ?", userID)
+
+	if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := q.Session(&gorm.Session{}).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&events).Error
+
+	return events, total, err
+}
+
+// What earlier successful logins already vouched for
+type LoginFamiliarity struct {
+	HasHistory  bool
+	KnownDevice bool
+	KnownRange  bool
+}
+
+func (r *LoginEventRepository) Familiarity(
+	userID uuid.UUID,
+	deviceHash string,
+	ipRange string,
+) (*LoginFamiliarity, error) {
+
+	var row struct {
+		Total   int64
+		Devices int64
+		Ranges  int64
+	}
+
+	err := r.db.Model(&models.LoginEvent{}).
+		Select(
+			"COUNT(*) AS total, "+
+				"COUNT(*) FILTER (WHERE device_hash = ?) AS devices, "+
+				"COUNT(*) FILTER (WHERE ip_range = ?) AS ranges",
+			deviceHash,
+			ipRange,
+		).
+		Where("user_id = ? AND outcome = ?", userID, models.LoginSucceeded).
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginFamiliarity{
+		HasHistory:  row.Total > 0,
+		KnownDevice: row.Devices > 0,
+		KnownRange:  row.Ranges > 0,
+	}, nil
+}
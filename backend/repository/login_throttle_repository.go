@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"rbac/models"
+)
+
+type LoginThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginThrottleRepository(db *gorm.DB) *LoginThrottleRepository {
+	return &LoginThrottleRepository{db: db}
+}
+
+func (r *LoginThrottleRepository) Find(
+	scope string,
+	key string,
+) (*models.LoginThrottle, error) {
+	var t models.LoginThrottle
+	err := r.db.
+		Where("scope = ? AND key = ?", scope, key).
+		First(&t).Error
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// RecordFailure bumps the counter under a row lock; lockFor decides the
+// lockout (0 = none) from the new failure count.
+func (r *LoginThrottleRepository) RecordFailure(
+	scope string,
+	key string,
+	window time.Duration,
+	lockFor func(failures int) time.Duration,
+) (*models.LoginThrottle, error) {
+
+	var t models.LoginThrottle
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Make sure the row exists, then lock it
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.LoginThrottle{
+				Scope:         scope,
+				Key:           key,
+				LastFailureAt: time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("scope = ? AND key = ?", scope, key).
+			First(&t).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		// Old failures age out
+		if t.Failures > 0 && now.Sub(t.LastFailureAt) > window {
+			t.Failures = 0
+		}
+
+		t.Failures++
+		t.LastFailureAt = now
+
+		if d := lockFor(t.Failures); d > 0 {
+			until := now.Add(d)
+			t.LockedUntil = &until
+		}
+
+		return tx.Save(&t).Error
+	})
+
+	return &t, err
+}
+
+func (r *LoginThrottleRepository) Reset(scope string, key string) error {
+	return r.db.
+		Where("scope = ? AND key = ?", scope, key).
+		Delete(&models.LoginThrottle{}).Error
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"rbac/models"
+)
+
+type OIDCRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCRepository(db *gorm.DB) *OIDCRepository {
+	return &OIDCRepository{db: db}
+}
+
+func (r *OIDCRepository) CreateState(state *models.OIDCLoginState) error {
+	return r.db.Create(state).Error
+}
+
+// Single-use: fetch + delete in one transaction
+func (r *OIDCRepository) TakeState(stateHash string) (*models.OIDCLoginState, error) {
+	var state models.OIDCLoginState
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("state = ? AND expires_at > ?", stateHash, time.Now()).
+			First(&state).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&models.OIDCLoginState{}, "id = ?", state.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func (r *OIDCRepository) DeleteExpiredStates() error {
+	return r.db.
+		Where("expires_at < ?", time.Now()).
+		Delete(&models.OIDCLoginState{}).Error
+}
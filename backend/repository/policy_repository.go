@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/models"
+)
+
+// Read-only lookups of who a resource belongs to. Missing resources come
+// back as gorm.ErrRecordNotFound.
+type PolicyRepository struct {
+	db *gorm.DB
+}
+
+func NewPolicyRepository(db *gorm.DB) *PolicyRepository {
+	return &PolicyRepository{db: db}
+}
+
+// tickets.customer_id holds the customer's USER id
+func (r *PolicyRepository) TicketOwner(ticketID uuid.UUID) (uuid.UUID, error) {
+	var ticket models.Ticket
+	err := r.db.
+		Select("id", "customer_id").
+		Where("id = ?", ticketID).
+		First(&ticket).Error
+	return ticket.CustomerID, err
+}
+
+// Latest assignment wins; a reassigned engineer loses access.
+// uuid.Nil when the ticket was never assigned.
+func (r *PolicyRepository) TicketAssignee(ticketID uuid.UUID) (uuid.UUID, error) {
+	var assignments []models.TicketAssignment
+	err := r.db.
+		Where("ticket_id = ?", ticketID).
+		Order("assigned_at DESC").
+		Limit(1).
+		Find(&assignments).Error
+	if err != nil || len(assignments) == 0 {
+		return uuid.Nil, err
+	}
+	return assignments[0].EngineerID, nil
+}
+
+// Leads of the ticket's queue team and of every team the assignee is in
+func (r *PolicyRepository) TicketTeamLeads(ticketID, assigneeID uuid.UUID) ([]uuid.UUID, error) {
+	var leads []uuid.UUID
+	err := r.db.
+		Table("support_team_members tm").
+		Distinct("se.user_id").
+		Joins("JOIN support_engineers se ON se.id = tm.support_engineer_id").
+		Where(`tm.is_lead = true AND tm.team_id IN (
+			SELECT team_id FROM tickets WHERE id = ? AND team_id IS NOT NULL
+			UNION
+			SELECT m.team_id FROM support_team_members m
+			JOIN support_engineers e ON e.id = m.support_engineer_id
+			WHERE e.user_id = ?)`, ticketID, assigneeID).
+		Pluck("se.user_id", &leads).Error
+	return leads, err
+}
+
+// amc_contracts → customer_products → organization id
+func (r *PolicyRepository) AMCOrganization(amcID uuid.UUID) (uuid.UUID, error) {
+	var row struct{ CustomerID uuid.UUID }
+	err := r.db.
+		Table("amc_contracts").
+		Select("cp.customer_id").
+		Joins("JOIN customer_products cp ON cp.id = amc_contracts.customer_product_id").
+		Where("amc_contracts.id = ?", amcID).
+		Take(&row).Error
+	return row.CustomerID, err
+}
+
+func (r *PolicyRepository) CustomerProductOrganization(id uuid.UUID) (uuid.UUID, error) {
+	var cp models.CustomerProduct
+	err := r.db.
+		Select("id", "customer_id").
+		Where("id = ?", id).
+		First(&cp).Error
+	return cp.CustomerID, err
+}
+
+// nil when the user belongs to no customer organization
+func (r *PolicyRepository) Membership(userID uuid.UUID) (*models.CustomerMember, error) {
+	var members []models.CustomerMember
+	err := r.db.
+		Where("user_id = ?", userID).
+		Limit(1).
+		Find(&members).Error
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+	return &members[0], nil
+}
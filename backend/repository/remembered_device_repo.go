@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"rbac/models"
@@ -34,3 +36,33 @@ func (r *RememberedDeviceRepo) DeleteByUser(userID uuid.UUID) error {
 	return r.db.Where("user_id = ?", userID).
 		Delete(&models.RememberedDevice{}).Error
 }
+
+func (r *RememberedDeviceRepo) Touch(
+	userID uuid.UUID,
+	hashedToken string,
+) error {
+	return r.db.Model(&models.RememberedDevice{}).
+		Where("user_id = ? AND token = ?", userID, hashedToken).
+		Update("last_used_at", time.Now()).Error
+}
+
+func (r *RememberedDeviceRepo) ListValidByUser(
+	userID uuid.UUID,
+) ([]models.RememberedDevice, error) {
+	var devices []models.RememberedDevice
+	err := r.db.
+		Where("user_id = ? AND expires_at > NOW()", userID).
+		Order("created_at DESC").
+		Find(&devices).Error
+	return devices, err
+}
+
+func (r *RememberedDeviceRepo) DeleteByID(
+	userID uuid.UUID,
+	id uuid.UUID,
+) (bool, error) {
+	result := r.db.
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&models.RememberedDevice{})
+	return result.RowsAffected == 1, result.Error
+}
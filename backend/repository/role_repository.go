@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"rbac/models"
+)
+
+type RoleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+func (r *RoleRepository) FindAll() ([]models.RoleDefinition, error) {
+	var roles []models.RoleDefinition
+	err := r.db.Order("is_system DESC, name ASC").Find(&roles).Error
+	return roles, err
+}
+
+func (r *RoleRepository) FindByName(name models.Role) (*models.RoleDefinition, error) {
+	var role models.RoleDefinition
+	if err := r.db.Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RoleRepository) Create(role *models.RoleDefinition) error {
+	return r.db.Create(role).Error
+}
+
+func (r *RoleRepository) Update(role *models.RoleDefinition) error {
+	return r.db.Model(role).
+		Select("display_name", "description", "permissions", "updated_at").
+		Updates(role).Error
+}
+
+func (r *RoleRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&models.RoleDefinition{}, "id = ?", id).Error
+}
+
+// Offboarded accounts keep their role but no longer count
+func (r *RoleRepository) CountUsers(name models.Role) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).
+		Where("role = ? AND offboarded_at IS NULL", name).
+		Count(&count).Error
+	return count, err
+}
+
+// Inserts missing built-in roles; existing rows (and edits to them) win
+func (r *RoleRepository) EnsureSystemRoles(roles []models.RoleDefinition) error {
+	if len(roles) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoNothing: true,
+	}).Create(&roles).Error
+}
+
+/* =====================
+   Assignment
+===================== */
+
+func (r *RoleRepository) FindUser(id uuid.UUID) (*models.User, error) {
+	var user models.User
+	err := r.db.
+		Where("id = ? AND offboarded_at IS NULL", id).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *RoleRepository) SetUserRole(userID uuid.UUID, role models.Role) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("role", role).Error
+}
+
+func (r *RoleRepository) CountActiveAdmins() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).
+		Where("role = ? AND is_active = true", models.RoleAdmin).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"rbac/models"
+)
+
+// SCIM only ever sees staff (every role but customer, custom roles
+// included): customers are managed in the app, offboarded accounts are
+// gone for good.
+type SCIMRepository struct {
+	db *gorm.DB
+}
+
+func NewSCIMRepository(db *gorm.DB) *SCIMRepository {
+	return &SCIMRepository{db: db}
+}
+
+func (r *SCIMRepository) WithTransaction(fn func(tx *SCIMRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewSCIMRepository(tx))
+	})
+}
+
+func (r *SCIMRepository) staff() *gorm.DB {
+	return r.db.Model(&models.User{}).
+		Where("role <> ? AND offboarded_at IS NULL", models.RoleCustomer)
+}
+
+// where is a parsed SCIM filter (column names already whitelisted)
+func (r *SCIMRepository) ListUsers(
+	where []clause.Expression,
+	offset int,
+	limit int,
+) ([]models.User, int64, error) {
+
+	var users []models.User
+	var total int64
+
+	q := r.staff()
+	if len(where) > 0 {
+		q = q.Clauses(clause.Where{Exprs: where})
+	}
+
+	if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := q.Session(&gorm.Session{}).
+		Order("created_at ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&users).Error
+
+	return users, total, err
+}
+
+func (r *SCIMRepository) FindUser(id uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := r.staff().Where("id = ?", id).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *SCIMRepository) ListByRole(role models.Role) ([]models.User, error) {
+	var users []models.User
+	err := r.staff().
+		Where("role = ?", role).
+		Order("name ASC").
+		Find(&users).Error
+	return users, err
+}
+
+// Support group: staff on any role but admin
+func (r *SCIMRepository) ListNonAdmins() ([]models.User, error) {
+	var users []models.User
+	err := r.staff().
+		Where("role <> ?", models.RoleAdmin).
+		Order("name ASC").
+		Find(&users).Error
+	return users, err
+}
+
+// Any account (customers and deactivated users included) holding the address?
+func (r *SCIMRepository) EmailTaken(email string, exceptID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).
+		Where("LOWER(email) = LOWER(?) AND id <> ?", email, exceptID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *SCIMRepository) CreateUser(user *models.User) error {
+	return r.db.Create(user).Error
+}
+
+func (r *SCIMRepository) UpdateUser(userID uuid.UUID, fields map[string]interface{}) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(fields).Error
+}
+
+func (r *SCIMRepository) SetRole(userID uuid.UUID, role models.Role) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("role", role).Error
+}
+
+func (r *SCIMRepository) CountActiveAdmins() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).
+		Where("role = ? AND is_active = true", models.RoleAdmin).
+		Count(&count).Error
+	return count, err
+}
+
+/* =====================
+   Support Engineer Profile
+===================== */
+
+func (r *SCIMRepository) Designations(userIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	result := map[uuid.UUID]string{}
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []models.SupportEngineer
+	if err := r.db.Where("user_id IN ?", userIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.UserID] = row.Designation
+	}
+	return result, nil
+}
+
+// Creates the profile on first use; title nil leaves the designation alone
+func (r *SCIMRepository) EnsureSupportProfile(
+	userID uuid.UUID,
+	active bool,
+	title *string,
+) error {
+
+	profile := models.SupportEngineer{
+		UserID:   userID,
+		IsActive: active,
+	}
+	if title != nil {
+		profile.Designation = *title
+	}
+
+	update := []string{"updated_at"}
+	if title != nil {
+		update = append(update, "designation")
+	}
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns(update),
+	}).Create(&profile).Error
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"rbac/models"
+)
+
+type SupportTeamRepository struct {
+	db *gorm.DB
+}
+
+func NewSupportTeamRepository(db *gorm.DB) *SupportTeamRepository {
+	return &SupportTeamRepository{db: db}
+}
+
+/* =====================
+   Teams
+===================== */
+
+func (r *SupportTeamRepository) FindAll() ([]models.SupportTeam, error) {
+	var teams []models.SupportTeam
+	err := r.db.
+		Preload("Members.Engineer.User").
+		Order("name ASC").
+		Find(&teams).Error
+	return teams, err
+}
+
+func (r *SupportTeamRepository) FindByID(id uuid.UUID) (*models.SupportTeam, error) {
+	var team models.SupportTeam
+	err := r.db.
+		Preload("Members.Engineer.User").
+		First(&team, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+func (r *SupportTeamRepository) NameTaken(name string, exceptID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.SupportTeam{}).
+		Where("LOWER(name) = LOWER(?) AND id <> ?", name, exceptID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *SupportTeamRepository) Create(team *models.SupportTeam) error {
+	return r.db.Create(team).Error
+}
+
+func (r *SupportTeamRepository) Update(id uuid.UUID, fields map[string]interface{}) error {
+	return r.db.Model(&models.SupportTeam{}).
+		Where("id = ?", id).
+		Updates(fields).Error
+}
+
+// Queued tickets fall back to unscoped
+func (r *SupportTeamRepository) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Ticket{}).
+			Where("team_id = ?", id).
+			Update("team_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("team_id = ?", id).
+			Delete(&models.SupportTeamMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.SupportTeam{}, "id = ?", id).Error
+	})
+}
+
+/* =====================
+   Members
+===================== */
+
+func (r *SupportTeamRepository) FindUser(userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := r.db.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Staff created outside SCIM have no profile yet; one is made on first use
+func (r *SupportTeamRepository) EnsureEngineer(userID uuid.UUID) (*models.SupportEngineer, error) {
+	if err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoNothing: true,
+	}).Create(&models.SupportEngineer{
+		UserID:   userID,
+		IsActive: true,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	var engineer models.SupportEngineer
+	if err := r.db.First(&engineer, "user_id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &engineer, nil
+}
+
+// Adds the engineer, or just updates IsLead when already in the team
+func (r *SupportTeamRepository) UpsertMember(member *models.SupportTeamMember) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "team_id"}, {Name: "support_engineer_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"is_lead", "updated_at"}),
+	}).Create(member).Error
+}
+
+func (r *SupportTeamRepository) RemoveMember(teamID, userID uuid.UUID) (bool, error) {
+	result := r.db.
+		Where(
+			"team_id = ? AND support_engineer_id IN (SELECT id FROM support_engineers WHERE user_id = ?)",
+			teamID, userID,
+		).
+		Delete(&models.SupportTeamMember{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Every team the user is in, with the team preloaded
+func (r *SupportTeamRepository) MembershipsOf(userID uuid.UUID) ([]models.SupportTeamMember, error) {
+	var members []models.SupportTeamMember
+	err := r.db.
+		Joins("JOIN support_engineers se ON se.id = support_team_members.support_engineer_id").
+		Where("se.user_id = ?", userID).
+		Find(&members).Error
+	return members, err
+}
+
+func (r *SupportTeamRepository) TeamsByID(ids []uuid.UUID) ([]models.SupportTeam, error) {
+	var teams []models.SupportTeam
+	if len(ids) == 0 {
+		return teams, nil
+	}
+	err := r.db.Where("id IN ?", ids).Order("name ASC").Find(&teams).Error
+	return teams, err
+}
+
+func (r *SupportTeamRepository) LedTeamIDs(userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&models.SupportTeamMember{}).
+		Joins("JOIN support_engineers se ON se.id = support_team_members.support_engineer_id").
+		Where("se.user_id = ? AND support_team_members.is_lead = true", userID).
+		Pluck("support_team_members.team_id", &ids).Error
+	return ids, err
+}
+
+// Is the user in any of the teams?
+func (r *SupportTeamRepository) InAnyTeam(userID uuid.UUID, teamIDs []uuid.UUID) (bool, error) {
+	if len(teamIDs) == 0 {
+		return false, nil
+	}
+	var count int64
+	err := r.db.Model(&models.SupportTeamMember{}).
+		Joins("JOIN support_engineers se ON se.id = support_team_members.support_engineer_id").
+		Where("se.user_id = ? AND support_team_members.team_id IN ?", userID, teamIDs).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -67,6 +67,11 @@ func (r *TicketRepository) GetAll() ([]models.Ticket, error) {
 	return tickets, err
 }
 
+// The engineer on a ticket is its latest assignment
+const currentAssigneeSQL = `(SELECT ta.engineer_id FROM ticket_assignments ta
+	WHERE ta.ticket_id = tickets.id ORDER BY ta.assigned_at DESC LIMIT 1)`
+
+// Tickets currently assigned to the engineer (not ones reassigned away)
 func (r *TicketRepository) FindByEngineer(
 	engineerID uuid.UUID,
 ) ([]models.Ticket, error) {
@@ -74,14 +79,38 @@ func (r *TicketRepository) FindByEngineer(
 	var tickets []models.Ticket
 
 	err := r.db.
-		Joins("JOIN ticket_assignments ta ON ta.ticket_id = tickets.id").
-		Where("ta.engineer_id = ?", engineerID).
+		Where(currentAssigneeSQL+" = ?", engineerID).
+		Order("tickets.created_at DESC").
+		Find(&tickets).Error
+
+	return tickets, err
+}
+
+// A team's tickets: queued to it, or assigned to one of its members
+func (r *TicketRepository) FindByTeams(
+	teamIDs []uuid.UUID,
+) ([]models.Ticket, error) {
+
+	var tickets []models.Ticket
+	if len(teamIDs) == 0 {
+		return tickets, nil
+	}
+
+	err := r.db.
+		Where(
+			"tickets.team_id IN ? OR "+currentAssigneeSQL+` IN (
+				SELECT se.user_id FROM support_team_members tm
+				JOIN support_engineers se ON se.id = tm.support_engineer_id
+				WHERE tm.team_id IN ?)`,
+			teamIDs, teamIDs,
+		).
 		Order("tickets.created_at DESC").
 		Find(&tickets).Error
 
 	return tickets, err
 }
 
+// customerID is the customer's USER id (the member who raised the ticket)
 func (r *TicketRepository) FindByCustomer(
 	customerID uuid.UUID,
 ) ([]models.Ticket, error) {
@@ -96,6 +125,24 @@ func (r *TicketRepository) FindByCustomer(
 	return tickets, err
 }
 
+// Every ticket raised by any member of the organization
+func (r *TicketRepository) FindByOrganization(
+	organizationID uuid.UUID,
+) ([]models.Ticket, error) {
+
+	var tickets []models.Ticket
+
+	err := r.db.
+		Where(
+			"customer_id IN (SELECT user_id FROM customer_members WHERE customer_id = ?)",
+			organizationID,
+		).
+		Order("created_at DESC").
+		Find(&tickets).Error
+
+	return tickets, err
+}
+
 /*
 =====================
 
@@ -116,6 +163,34 @@ func (r *TicketRepository) CreateAssignment(
 	}).Error
 }
 
+// Hands the ticket to another engineer; work restarts from Assigned
+func (r *TicketRepository) Reassign(
+	ticket *models.Ticket,
+	assignment *models.TicketAssignment,
+) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Ticket{}).
+			Where("id = ?", ticket.ID).
+			Updates(map[string]interface{}{
+				"status":             models.StatusAssigned,
+				"needs_reassignment": false,
+			}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(assignment).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.TicketStatusHistory{
+			TicketID:  ticket.ID,
+			OldStatus: string(ticket.Status),
+			NewStatus: string(models.StatusAssigned),
+			ChangedBy: assignment.AssignedBy,
+		}).Error
+	})
+}
+
 func (r *TicketRepository) UpdateStatusNoTx(
 	ticketID uuid.UUID,
 	newStatus models.TicketStatus,
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"rbac/models"
+)
+
+/*
+=====================
+ Access-token Revocation
+=====================
+*/
+
+// Checked by AuthMiddleware on every request, so access tokens die
+// immediately instead of living out their 15 minutes.
+type TokenRevocationStore interface {
+	// Deny a single token (by jti), or every token of a session (by its
+	// sid), until it would have expired anyway
+	RevokeToken(jti string, userID uuid.UUID, expiresAt time.Time) error
+
+	// Deny every token of the user issued before `before`
+	RevokeUserTokens(userID uuid.UUID, before time.Time) error
+
+	IsRevoked(jti string, userID uuid.UUID, issuedAt time.Time) (bool, error)
+}
+
+// JWT iat has second precision: a token minted in the same second as
+// the cutoff (e.g. the fresh login after a password change) must survive.
+func issuedBeforeCutoff(issuedAt time.Time, notBefore time.Time) bool {
+	return issuedAt.Before(notBefore.Truncate(time.Second))
+}
+
+/* =====================
+   Postgres
+===================== */
+
+type PostgresTokenRevocationStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresTokenRevocationStore(db *gorm.DB) *PostgresTokenRevocationStore {
+	return &PostgresTokenRevocationStore{db: db}
+}
+
+func (s *PostgresTokenRevocationStore) RevokeToken(
+	jti string,
+	userID uuid.UUID,
+	expiresAt time.Time,
+) error {
+	return s.db.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.RevokedAccessToken{
+			JTI:       jti,
+			UserID:    userID,
+			ExpiresAt: expiresAt,
+		}).Error
+}
+
+func (s *PostgresTokenRevocationStore) RevokeUserTokens(
+	userID uuid.UUID,
+	before time.Time,
+) error {
+	return s.db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"not_before", "updated_at"}),
+		}).
+		Create(&models.UserTokenCutoff{
+			UserID:    userID,
+			NotBefore: before,
+		}).Error
+}
+
+func (s *PostgresTokenRevocationStore) IsRevoked(
+	jti string,
+	userID uuid.UUID,
+	issuedAt time.Time,
+) (bool, error) {
+
+	notBefore, err := s.userNotBefore(userID)
+	if err != nil {
+		return false, err
+	}
+	if issuedBeforeCutoff(issuedAt, notBefore) {
+		return true, nil
+	}
+
+	return s.jtiRevoked(jti)
+}
+
+func (s *PostgresTokenRevocationStore) jtiRevoked(jti string) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.RevokedAccessToken{}).
+		Where("jti = ?", jti).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Zero time = no cutoff
+func (s *PostgresTokenRevocationStore) userNotBefore(userID uuid.UUID) (time.Time, error) {
+	var cutoffs []models.UserTokenCutoff
+	err := s.db.
+		Where("user_id = ?", userID).
+		Limit(1).
+		Find(&cutoffs).Error
+	if err != nil || len(cutoffs) == 0 {
+		return time.Time{}, err
+	}
+	return cutoffs[0].NotBefore, nil
+}
+
+// Denied jtis past their expiry can't be replayed anyway
+func (s *PostgresTokenRevocationStore) PurgeExpired() error {
+	return s.db.
+		Where("expires_at < ?", time.Now()).
+		Delete(&models.RevokedAccessToken{}).Error
+}
+
+/* =====================
+   In-memory (cached)
+===================== */
+
+// Serves lookups from memory. With a backing store, writes go through
+// to it and misses are re-checked after `ttl` (picks up revocations made
+// by other instances). Without one it is a standalone store for
+// single-instance / development setups.
+type CachedTokenRevocationStore struct {
+	next TokenRevocationStore // may be nil
+	ttl  time.Duration
+
+	mu      sync.RWMutex
+	jtis    map[string]time.Time    // jti → token expiry
+	cutoffs map[uuid.UUID]time.Time // user → not-before
+	checked map[string]time.Time    // jti+user lookups confirmed clean, → when
+	purged  time.Time
+}
+
+func NewCachedTokenRevocationStore(
+	next TokenRevocationStore,
+	ttl time.Duration,
+) *CachedTokenRevocationStore {
+	return &CachedTokenRevocationStore{
+		next:    next,
+		ttl:     ttl,
+		jtis:    map[string]time.Time{},
+		cutoffs: map[uuid.UUID]time.Time{},
+		checked: map[string]time.Time{},
+	}
+}
+
+func (s *CachedTokenRevocationStore) RevokeToken(
+	jti string,
+	userID uuid.UUID,
+	expiresAt time.Time,
+) error {
+	if s.next != nil {
+		if err := s.next.RevokeToken(jti, userID, expiresAt); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	s.jtis[jti] = expiresAt
+	s.purge(time.Now())
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *CachedTokenRevocationStore) RevokeUserTokens(
+	userID uuid.UUID,
+	before time.Time,
+) error {
+	if s.next != nil {
+		if err := s.next.RevokeUserTokens(userID, before); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	s.cutoffs[userID] = before
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *CachedTokenRevocationStore) IsRevoked(
+	jti string,
+	userID uuid.UUID,
+	issuedAt time.Time,
+) (bool, error) {
+
+	now := time.Now()
+	key := userID.String() + ":" + jti
+
+	s.mu.RLock()
+	_, denied := s.jtis[jti]
+	notBefore := s.cutoffs[userID]
+	checkedAt, clean := s.checked[key]
+	s.mu.RUnlock()
+
+	if denied || issuedBeforeCutoff(issuedAt, notBefore) {
+		return true, nil
+	}
+
+	if s.next == nil || (clean && now.Sub(checkedAt) < s.ttl) {
+		return false, nil
+	}
+
+	revoked, err := s.next.IsRevoked(jti, userID, issuedAt)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	if revoked {
+		// Remember as denied; precise expiry unknown here, purge() uses ttl
+		s.jtis[jti] = now.Add(s.ttl)
+	} else {
+		s.checked[key] = now
+	}
+	s.purge(now)
+	s.mu.Unlock()
+
+	return revoked, nil
+}
+
+// Caller holds mu; sweeps at most once a minute
+func (s *CachedTokenRevocationStore) purge(now time.Time) {
+	if now.Sub(s.purged) < time.Minute {
+		return
+	}
+	s.purged = now
+
+	for jti, exp := range s.jtis {
+		if now.After(exp) {
+			delete(s.jtis, jti)
+		}
+	}
+	for key, at := range s.checked {
+		if now.Sub(at) >= s.ttl {
+			delete(s.checked, key)
+		}
+	}
+}
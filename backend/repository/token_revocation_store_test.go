@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rbac/models"
+	"rbac/testutil"
+)
+
+// iat is whole seconds: a token minted in the cutoff's own second (the
+// fresh login after a password change) survives, the one before doesn't
+func TestIssuedBeforeCutoffTruncatesToTheSecond(t *testing.T) {
+	cutoff := time.Date(2026, 1, 1, 12, 0, 0, 700_000_000, time.UTC)
+
+	cases := []struct {
+		name      string
+		issuedAt  time.Time
+		notBefore time.Time
+		want      bool
+	}{
+		{"same second as the cutoff", cutoff.Truncate(time.Second), cutoff, false},
+		{"after the cutoff", cutoff.Add(time.Second).Truncate(time.Second), cutoff, false},
+		{"second before the cutoff", cutoff.Add(-time.Second).Truncate(time.Second), cutoff, true},
+		{"no cutoff", cutoff, time.Time{}, false},
+	}
+
+	for _, tc := range cases {
+		if got := issuedBeforeCutoff(tc.issuedAt, tc.notBefore); got != tc.want {
+			t.Errorf("%s: revoked = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// Two API instances over one database: what one revokes, the other
+// picks up once its clean lookup is older than ttl
+func TestCachedStorePicksUpOtherInstancesAfterTTL(t *testing.T) {
+	db := testutil.NewDB(t)
+	shared := NewPostgresTokenRevocationStore(db)
+	const ttl = 30 * time.Second
+
+	a := NewCachedTokenRevocationStore(shared, ttl)
+	b := NewCachedTokenRevocationStore(shared, ttl)
+
+	userID := uuid.New()
+	jti := uuid.NewString()
+	issuedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	if revoked, err := b.IsRevoked(jti, userID, issuedAt); err != nil || revoked {
+		t.Fatalf("fresh token: revoked=%v err=%v", revoked, err)
+	}
+
+	// Instance a: password change cuts off every older token
+	if err := a.RevokeUserTokens(userID, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := a.IsRevoked(jti, userID, issuedAt); !revoked {
+		t.Fatal("writing instance still accepts the token")
+	}
+
+	// b answers from its cache until the clean result is ttl old
+	if revoked, _ := b.IsRevoked(jti, userID, issuedAt); revoked {
+		t.Fatal("cached clean lookup re-checked before ttl")
+	}
+	backdate(b, userID, jti, ttl)
+	if revoked, _ := b.IsRevoked(jti, userID, issuedAt); !revoked {
+		t.Fatal("cutoff from the other instance not picked up after ttl")
+	}
+
+	// Denied lookups stick without another trip to the database
+	db.Where("user_id = ?", userID).Delete(&models.UserTokenCutoff{})
+	if revoked, _ := b.IsRevoked(jti, userID, issuedAt); !revoked {
+		t.Fatal("denied token forgotten within ttl")
+	}
+}
+
+func TestCachedStorePicksUpJTIsFromOtherInstances(t *testing.T) {
+	db := testutil.NewDB(t)
+	shared := NewPostgresTokenRevocationStore(db)
+	const ttl = 30 * time.Second
+
+	a := NewCachedTokenRevocationStore(shared, ttl)
+	b := NewCachedTokenRevocationStore(shared, ttl)
+
+	userID := uuid.New()
+	jti := uuid.NewString()
+	issuedAt := time.Now().Truncate(time.Second)
+
+	if revoked, _ := b.IsRevoked(jti, userID, issuedAt); revoked {
+		t.Fatal("fresh token revoked")
+	}
+	if err := a.RevokeToken(jti, userID, time.Now().Add(15*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if revoked, _ := b.IsRevoked(jti, userID, issuedAt); revoked {
+		t.Fatal("cached clean lookup re-checked before ttl")
+	}
+	backdate(b, userID, jti, ttl)
+	if revoked, _ := b.IsRevoked(jti, userID, issuedAt); !revoked {
+		t.Fatal("jti denied by the other instance not picked up after ttl")
+	}
+	if revoked, _ := b.IsRevoked(uuid.NewString(), userID, issuedAt); revoked {
+		t.Fatal("denying one jti revoked another")
+	}
+}
+
+// Expired jtis and stale clean lookups are swept, at most once a minute
+func TestCachedStorePurgesExpiredEntries(t *testing.T) {
+	const ttl = 30 * time.Second
+	s := NewCachedTokenRevocationStore(nil, ttl)
+	userID := uuid.New()
+
+	if err := s.RevokeToken("expired", userID, time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, kept := s.jtis["expired"]; kept {
+		t.Fatal("expired jti kept by the first sweep")
+	}
+
+	// Sweep ran just now: the next write doesn't sweep again
+	s.jtis["expired-later"] = time.Now().Add(-time.Second)
+	s.checked["stale"] = time.Now().Add(-2 * ttl)
+	if err := s.RevokeToken("live", userID, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, kept := s.jtis["expired-later"]; !kept {
+		t.Fatal("swept twice within a minute")
+	}
+
+	s.purged = time.Now().Add(-2 * time.Minute)
+	if err := s.RevokeToken("live-2", userID, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, kept := s.jtis["expired-later"]; kept {
+		t.Fatal("expired jti survived the sweep")
+	}
+	if _, kept := s.checked["stale"]; kept {
+		t.Fatal("stale clean lookup survived the sweep")
+	}
+	for _, jti := range []string{"live", "live-2"} {
+		if revoked, _ := s.IsRevoked(jti, userID, time.Now()); !revoked {
+			t.Fatalf("%s no longer denied", jti)
+		}
+	}
+}
+
+// Ages a clean lookup as if ttl had passed
+func backdate(s *CachedTokenRevocationStore, userID uuid.UUID, jti string, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checked[userID.String()+":"+jti] = time.Now().Add(-ttl)
+}
@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/models"
+)
+
+// Deactivate / reactivate / offboard touch users, their role profiles,
+// credentials and tickets together, so everything here runs on one tx.
+type UserLifecycleRepository struct {
+	db *gorm.DB
+}
+
+func NewUserLifecycleRepository(db *gorm.DB) *UserLifecycleRepository {
+	return &UserLifecycleRepository{db: db}
+}
+
+func (r *UserLifecycleRepository) WithTransaction(
+	fn func(txRepo *UserLifecycleRepository) error,
+) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&UserLifecycleRepository{db: tx})
+	})
+}
+
+/* =====================
+   Users
+===================== */
+
+// Any state, including deactivated and offboarded users
+func (r *UserLifecycleRepository) FindUser(id uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("id = ?", id).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Has a SupportEngineer profile, whatever the role is called; activeOnly
+// skips deactivated profiles
+func (r *UserLifecycleRepository) IsEngineer(userID uuid.UUID, activeOnly bool) (bool, error) {
+	q := r.db.Model(&models.SupportEngineer{}).Where("user_id = ?", userID)
+	if activeOnly {
+		q = q.Where("is_active = true")
+	}
+
+	var count int64
+	err := q.Count(&count).Error
+	return count > 0, err
+}
+
+func (r *UserLifecycleRepository) CountActiveAdmins() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).
+		Where("role = ? AND is_active = true", models.RoleAdmin).
+		Count(&count).Error
+	return count, err
+}
+
+// Flips the user and cascades to the SupportEngineer profile. A customer
+// organization outlives any one member: it is only deactivated along with
+// its last active member, and comes back with the first one reactivated.
+func (r *UserLifecycleRepository) SetActive(
+	userID uuid.UUID,
+	active bool,
+	changedBy uuid.UUID,
+) error {
+
+	updates := map[string]interface{}{
+		"is_active":      active,
+		"deactivated_at": nil,
+		"deactivated_by": nil,
+	}
+	if !active {
+		updates["deactivated_at"] = time.Now()
+		updates["deactivated_by"] = changedBy
+	}
+
+	if err := r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(updates).Error; err != nil {
+		return err
+	}
+
+	if err := r.db.Model(&models.SupportEngineer{}).
+		Where("user_id = ?", userID).
+		Update("is_active", active).Error; err != nil {
+		return err
+	}
+
+	return r.syncCustomerActive(userID)
+}
+
+// Customer.IsActive mirrors "has at least one active member"
+func (r *UserLifecycleRepository) syncCustomerActive(userID uuid.UUID) error {
+	var member models.CustomerMember
+	err := r.db.Where("user_id = ?", userID).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var active int64
+	if err := r.db.Model(&models.CustomerMember{}).
+		Joins("JOIN users u ON u.id = customer_members.user_id").
+		Where("customer_members.customer_id = ? AND u.is_active = true", member.CustomerID).
+		Count(&active).Error; err != nil {
+		return err
+	}
+
+	return r.db.Model(&models.Customer{}).
+		Where("id = ?", member.CustomerID).
+		Update("is_active", active > 0).Error
+}
+
+/* =====================
+   Credentials
+===================== */
+
+// Refresh tokens + remembered devices; access tokens go through the
+// revocation store
+func (r *UserLifecycleRepository) RevokeSessions(userID uuid.UUID) error {
+	if err := r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND is_revoked = false", userID).
+		Update("is_revoked", true).Error; err != nil {
+		return err
+	}
+
+	return r.db.
+		Where("user_id = ?", userID).
+		Delete(&models.RememberedDevice{}).Error
+}
+
+// Offboarding: wipe every way back in and release the email address
+func (r *UserLifecycleRepository) Scrub(userID uuid.UUID) error {
+	now := time.Now()
+
+	if err := r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"name":                "Former user",
+			"email":               "offboarded+" + userID.String() + "@invalid",
+			"password":            "",
+			"must_reset_password": true,
+			"two_fa_enabled":      false,
+			"two_fa_method":       models.TwoFAMethodEmail,
+			"totp_secret":         "",
+			"totp_pending_secret": "",
+			"totp_confirmed_at":   nil,
+			"totp_last_step":      0,
+			"oidc_subject":        nil,
+			"scim_external_id":    nil,
+			"email_verified_at":   nil,
+			"offboarded_at":       now,
+		}).Error; err != nil {
+		return err
+	}
+
+	for _, model := range []interface{}{
+		&models.WebAuthnCredential{},
+		&models.RecoveryCode{},
+		&models.PasswordHistory{},
+		&models.EmailVerificationToken{},
+		&models.LoginEvent{},
+		&models.TwoFAOTP{},
+		&models.PasswordResetToken{},
+	} {
+		if err := r.db.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+
+	// Keys minted by this admin die with the account
+	return r.db.Model(&models.APIKey{}).
+		Where("created_by = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
+
+/* =====================
+   Tickets
+===================== */
+
+// Open tickets whose latest assignment is this engineer
+func (r *UserLifecycleRepository) FindOpenTicketsAssignedTo(
+	engineerID uuid.UUID,
+) ([]models.Ticket, error) {
+
+	var tickets []models.Ticket
+
+	err := r.db.
+		Joins("JOIN ticket_assignments ta ON ta.ticket_id = tickets.id").
+		Where("ta.engineer_id = ? AND tickets.status <> ?", engineerID, models.StatusClosed).
+		Where(`ta.assigned_at = (
+			SELECT MAX(assigned_at) FROM ticket_assignments
+			WHERE ticket_id = tickets.id
+		)`).
+		Find(&tickets).Error
+
+	return tickets, err
+}
+
+func (r *UserLifecycleRepository) ReassignTicket(
+	ticket *models.Ticket,
+	engineerID uuid.UUID,
+	adminID uuid.UUID,
+) error {
+
+	if err := r.db.Create(&models.TicketAssignment{
+		TicketID:   ticket.ID,
+		EngineerID: engineerID,
+		AssignedBy: adminID,
+		AssignedAt: time.Now(),
+	}).Error; err != nil {
+		return err
+	}
+
+	return r.db.Model(&models.Ticket{}).
+		Where("id = ?", ticket.ID).
+		Update("needs_reassignment", false).Error
+}
+
+// No successor: back to the admin queue as Open + flagged
+func (r *UserLifecycleRepository) FlagTicket(
+	ticket *models.Ticket,
+	adminID uuid.UUID,
+) error {
+
+	if err := r.db.Model(&models.Ticket{}).
+		Where("id = ?", ticket.ID).
+		Updates(map[string]interface{}{
+			"status":             models.StatusOpen,
+			"needs_reassignment": true,
+		}).Error; err != nil {
+		return err
+	}
+
+	return r.db.Create(&models.TicketStatusHistory{
+		TicketID:  ticket.ID,
+		OldStatus: string(ticket.Status),
+		NewStatus: string(models.StatusOpen),
+		ChangedBy: adminID,
+		ChangedAt: time.Now(),
+	}).Error
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/models"
+)
+
+type WebAuthnRepository struct {
+	db *gorm.DB
+}
+
+func NewWebAuthnRepository(db *gorm.DB) *WebAuthnRepository {
+	return &WebAuthnRepository{db: db}
+}
+
+/* =====================
+   Credentials
+===================== */
+
+func (r *WebAuthnRepository) CreateCredential(cred *models.WebAuthnCredential) error {
+	return r.db.Create(cred).Error
+}
+
+func (r *WebAuthnRepository) FindByUser(userID uuid.UUID) ([]models.WebAuthnCredential, error) {
+	var creds []models.WebAuthnCredential
+	err := r.db.
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&creds).Error
+	return creds, err
+}
+
+func (r *WebAuthnRepository) FindByCredentialID(credentialID []byte) (*models.WebAuthnCredential, error) {
+	var cred models.WebAuthnCredential
+	if err := r.db.
+		Where("credential_id = ?", credentialID).
+		First(&cred).Error; err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+func (r *WebAuthnRepository) UpdateCredentialData(id uuid.UUID, data string) error {
+	return r.db.Model(&models.WebAuthnCredential{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"data":         data,
+			"last_used_at": time.Now(),
+		}).Error
+}
+
+func (r *WebAuthnRepository) DeleteCredential(userID, id uuid.UUID) (bool, error) {
+	result := r.db.
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&models.WebAuthnCredential{})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *WebAuthnRepository) CountByUser(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.WebAuthnCredential{}).
+		Where("user_id = ?", userID).
+		Count(&count).Error
+	return count, err
+}
+
+/* =====================
+   Ceremony Sessions
+===================== */
+
+func (r *WebAuthnRepository) CreateSession(session *models.WebAuthnSession) error {
+	return r.db.Create(session).Error
+}
+
+// Sessions are single use → fetched and deleted together
+func (r *WebAuthnRepository) TakeSession(
+	id uuid.UUID,
+	ceremony string,
+) (*models.WebAuthnSession, error) {
+	var session models.WebAuthnSession
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Where("id = ? AND ceremony = ? AND expires_at > NOW()", id, ceremony).
+			First(&session).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&session).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *WebAuthnRepository) DeleteExpiredSessions() error {
+	return r.db.
+		Where("expires_at <= NOW()").
+		Delete(&models.WebAuthnSession{}).Error
+}
@@ -0,0 +1,158 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"rbac/middleware"
+	"rbac/service"
+)
+
+/*
+=====================
+ Route Manifest
+=====================
+ SetupRoutes registers through router, which forwards to gin and notes
+ each route with the guards in front of it. Guards are middleware with
+ a description (see requires); plain middleware is not listed.
+
+ testdata/route_manifest.json is the committed copy, so guard changes
+ show up in review and between releases. TestRouteManifest fails when
+ it is stale; go generate rewrites it.
+*/
+
+//go:generate go test . -run ^TestRouteManifest$ -update
+
+type guard struct {
+	handler     gin.HandlerFunc
+	requirement service.RouteRequirement
+}
+
+func requires(handler gin.HandlerFunc, kind service.RequirementKind, value string) guard {
+	return guard{
+		handler:     handler,
+		requirement: service.RouteRequirement{Kind: kind, Value: value},
+	}
+}
+
+type router struct {
+	group  *gin.RouterGroup
+	guards []service.RouteRequirement
+	rules  *[]service.RouteRule
+}
+
+func newRouter(r *gin.Engine) *router {
+	return &router{
+		group: &r.RouterGroup,
+		rules: &[]service.RouteRule{},
+	}
+}
+
+func (r *router) Group(relativePath string, handlers ...any) *router {
+	chain, guards, _ := split(handlers)
+	return &router{
+		group:  r.group.Group(relativePath, chain...),
+		guards: append(append([]service.RouteRequirement(nil), r.guards...), guards...),
+		rules:  r.rules,
+	}
+}
+
+func (r *router) Use(handlers ...any) {
+	chain, guards, _ := split(handlers)
+	r.group.Use(chain...)
+	r.guards = append(r.guards, guards...)
+}
+
+func (r *router) GET(relativePath string, handlers ...any) {
+	r.handle(http.MethodGet, relativePath, handlers)
+}
+
+func (r *router) POST(relativePath string, handlers ...any) {
+	r.handle(http.MethodPost, relativePath, handlers)
+}
+
+func (r *router) PUT(relativePath string, handlers ...any) {
+	r.handle(http.MethodPut, relativePath, handlers)
+}
+
+func (r *router) PATCH(relativePath string, handlers ...any) {
+	r.handle(http.MethodPatch, relativePath, handlers)
+}
+
+func (r *router) DELETE(relativePath string, handlers ...any) {
+	r.handle(http.MethodDelete, relativePath, handlers)
+}
+
+func (r *router) handle(method, relativePath string, handlers []any) {
+	chain, guards, name := split(handlers)
+	r.group.Handle(method, relativePath, chain...)
+
+	requirements := append(append([]service.RouteRequirement{}, r.guards...), guards...)
+	*r.rules = append(*r.rules, service.RouteRule{
+		Method:       method,
+		Path:         joinPath(r.group.BasePath(), relativePath),
+		Handler:      name,
+		Requirements: requirements,
+	})
+}
+
+// API key scopes are registered after the routes, so they are filled in last
+func (r *router) manifest() []service.RouteRule {
+	rules := *r.rules
+	for i := range rules {
+		if scope, ok := middleware.RouteScope(rules[i].Method, rules[i].Path); ok {
+			rules[i].APIKeyScope = scope
+		}
+	}
+	return rules
+}
+
+func split(handlers []any) ([]gin.HandlerFunc, []service.RouteRequirement, string) {
+	chain := make([]gin.HandlerFunc, 0, len(handlers))
+	var guards []service.RouteRequirement
+
+	for _, h := range handlers {
+		switch h := h.(type) {
+		case guard:
+			chain = append(chain, h.handler)
+			guards = append(guards, h.requirement)
+		case gin.HandlerFunc:
+			chain = append(chain, h)
+		case func(*gin.Context):
+			chain = append(chain, h)
+		default:
+			panic(fmt.Sprintf("routes: unsupported handler type %T", h))
+		}
+	}
+
+	name := ""
+	if len(chain) > 0 {
+		name = handlerName(chain[len(chain)-1])
+	}
+	return chain, guards, name
+}
+
+// "handler.(*AuthHandler).Login"
+func handlerName(h gin.HandlerFunc) string {
+	name := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+	name = strings.TrimSuffix(name, "-fm")
+	return strings.TrimPrefix(name, "rbac/")
+}
+
+// Mirrors gin: a trailing slash on the relative path is kept
+func joinPath(base, relative string) string {
+	if relative == "" {
+		return base
+	}
+	joined := path.Join(base, relative)
+	if strings.HasSuffix(relative, "/") && !strings.HasSuffix(joined, "/") {
+		return joined + "/"
+	}
+	return joined
+}
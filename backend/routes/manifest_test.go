@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rbac/testutil"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/route_manifest.json")
+
+var manifestGolden = filepath.Join("testdata", "route_manifest.json")
+
+// The committed manifest must match the routes; refresh it with
+// go generate ./routes and review the diff
+func TestRouteManifest(t *testing.T) {
+	cfg := testutil.Config()
+	cfg.JWT.ReauthMaxAge = 5 * time.Minute // shipped default, not the local env
+
+	_, svc := newTestRouter(t, testutil.NewDB(t), cfg)
+
+	manifest, err := svc.access.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(manifestGolden), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(manifestGolden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(manifestGolden)
+	if err != nil {
+		t.Fatalf("%v (run go generate ./routes)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s is stale: run go generate ./routes and commit the diff", manifestGolden)
+	}
+}
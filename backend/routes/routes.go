@@ -7,19 +7,44 @@ import (
 	"rbac/handler"
 	"rbac/middleware"
 	"rbac/models"
+	"rbac/repository"
+	"rbac/service"
+	"rbac/utils"
 )
 
 func SetupRoutes(
 	r *gin.Engine,
 	cfg *config.Config,
+	keys *utils.KeyRing,
+	revocations repository.TokenRevocationStore,
+	apiKeys *service.APIKeyService,
+	roles *service.RoleService,
+	policy *service.PolicyService,
+	access *service.AccessService,
+	elevations *service.ElevationService,
+	auditRepo *repository.AuditRepository,
 
 	// Auth
 	authHandler *handler.AuthHandler,
+	jwksHandler *handler.JWKSHandler,
+	oidcHandler *handler.OIDCHandler,
+	webauthnHandler *handler.WebAuthnHandler,
+	sessionHandler *handler.SessionHandler,
+	apiKeyHandler *handler.APIKeyHandler,
+	userLifecycleHandler *handler.UserLifecycleHandler,
+	invitationHandler *handler.InvitationHandler,
+	emailHandler *handler.EmailVerificationHandler,
+	scimHandler *handler.SCIMHandler,
+	roleHandler *handler.RoleHandler,
+	supportTeamHandler *handler.SupportTeamHandler,
+	accessHandler *handler.AccessHandler,
+	elevationHandler *handler.ElevationHandler,
 
 	// Dashboards
 	adminDashboard *handler.AdminDashboardHandler,
 	supportDashboard *handler.SupportDashboardHandler,
 	customerDashboard *handler.CustomerDashboardHandler,
+	customerOrgHandler *handler.CustomerOrgHandler,
 
 	// Core
 	ticketHandler *handler.TicketHandler,
@@ -34,7 +59,41 @@ func SetupRoutes(
 	modelHandler *handler.ModelHandler,
 ) {
 
-	api := r.Group("/api/v1")
+	// Every route goes through root so it lands in the route manifest
+	root := newRouter(r)
+
+	// Public keys for other services verifying our access tokens
+	root.GET("/.well-known/jwks.json", jwksHandler.JWKS)
+
+	/* =========================
+	   SCIM 2.0 (IdP PROVISIONING)
+	   Bearer API key with scim:provision
+	========================= */
+	scim := root.Group("/scim/v2")
+	scim.Use(requires(middleware.SCIMAuth(apiKeys), service.ReqSCIM, string(models.ScopeSCIMProvision)))
+	{
+		scim.GET("/ServiceProviderConfig", scimHandler.ServiceProviderConfig)
+		scim.GET("/ResourceTypes", scimHandler.ResourceTypes)
+
+		scim.GET("/Users", scimHandler.ListUsers)
+		scim.POST("/Users", scimHandler.CreateUser)
+		scim.GET("/Users/:id", scimHandler.GetUser)
+		scim.PUT("/Users/:id", scimHandler.ReplaceUser)
+		scim.PATCH("/Users/:id", scimHandler.PatchUser)
+		scim.DELETE("/Users/:id", scimHandler.DeleteUser)
+
+		scim.GET("/Groups", scimHandler.ListGroups)
+		scim.POST("/Groups", scimHandler.CreateGroup)
+		scim.GET("/Groups/:id", scimHandler.GetGroup)
+		scim.PUT("/Groups/:id", scimHandler.ReplaceGroup)
+		scim.PATCH("/Groups/:id", scimHandler.PatchGroup)
+		scim.DELETE("/Groups/:id", scimHandler.DeleteGroup)
+	}
+
+	api := root.Group("/api/v1")
+
+	// Temp token from the password step (login is not finished yet)
+	twoFAPending := requires(middleware.Temp2FAMiddleware(keys), service.ReqTwoFAPending, "")
 
 	/* =========================
 	   AUTH (PUBLIC)
@@ -45,99 +104,272 @@ func SetupRoutes(
 		auth.POST("/refresh", authHandler.RefreshToken)
 		auth.POST("/forgot-password", authHandler.ForgotPassword)
 		auth.POST("/reset-password", authHandler.ResetPassword)
+		auth.POST("/verify-email", emailHandler.Confirm)
+
+		// Staff SSO (OIDC authorization code + PKCE)
+		auth.GET("/oidc/login", oidcHandler.Login)
+		auth.GET("/oidc/callback", oidcHandler.Callback)
 
 		auth.POST(
 			"/verify-2fa",
-			middleware.Temp2FAMiddleware(cfg),
+			twoFAPending,
 			authHandler.Verify2FA,
 		)
+		auth.POST(
+			"/verify-2fa/email",
+			twoFAPending,
+			authHandler.SendEmailOTP,
+		)
+
+		// Passkey: passwordless primary login
+		auth.POST("/passkey/begin", webauthnHandler.BeginLogin)
+		auth.POST("/passkey/finish", webauthnHandler.FinishLogin)
+
+		// Passkey: second factor
+		auth.POST(
+			"/verify-2fa/passkey/begin",
+			twoFAPending,
+			webauthnHandler.BeginTwoFA,
+		)
+		auth.POST(
+			"/verify-2fa/passkey/finish",
+			twoFAPending,
+			webauthnHandler.FinishTwoFA,
+		)
 	}
 
 	/* =========================
 	   PROTECTED (JWT)
 	========================= */
 	protected := api.Group("")
-	protected.Use(middleware.AuthMiddleware(cfg))
+	protected.Use(
+		requires(middleware.AuthMiddleware(keys, revocations, apiKeys, elevations), service.ReqAuthenticated, ""),
+		middleware.ImpersonationGuard(auditRepo),
+		middleware.ElevationGuard(auditRepo),
+	)
+
+	// Sensitive operations: password / 2FA must have been proven recently
+	stepUp := requires(
+		middleware.RequireRecentAuth(cfg.JWT.ReauthMaxAge),
+		service.ReqRecentAuth,
+		cfg.JWT.ReauthMaxAge.String(),
+	)
+
+	// Route → permission; the caller's role must grant it
+	can := func(perm models.Permission) guard {
+		return requires(middleware.RequirePermission(roles, perm), service.ReqPermission, string(perm))
+	}
+
+	// Route → relationship to the :id resource (assignee, owner, company)
+	owns := func(action service.PolicyAction) guard {
+		g := requires(middleware.Authorize(policy, action, "id"), service.ReqPolicy, string(action))
+		g.requirement.Param = "id"
+		return g
+	}
 	{
 		/* ---------- COMMON ---------- */
+		protected.POST("/auth/reauth", authHandler.Reauthenticate)
+		protected.POST("/auth/reauth/email", authHandler.SendReauthOTP)
+
 		protected.POST("/logout", authHandler.Logout)
+		protected.DELETE("/impersonation", authHandler.EndImpersonation)
 		protected.GET("/profile", authHandler.GetMe)
+		protected.GET("/profile/permissions", roleHandler.MyPermissions)
+		protected.GET("/profile/recovery-codes", authHandler.GetRecoveryCodes)
+		protected.POST("/profile/recovery-codes", stepUp, authHandler.RegenerateRecoveryCodes)
+
+		protected.GET("/profile/sessions", sessionHandler.MySessions)
+		protected.DELETE("/profile/sessions", sessionHandler.RevokeAllMySessions)
+		protected.DELETE("/profile/sessions/:sessionId", sessionHandler.RevokeMySession)
+		protected.GET("/profile/devices", sessionHandler.MyDevices)
+		protected.DELETE("/profile/devices", sessionHandler.RevokeAllMyDevices)
+		protected.DELETE("/profile/devices/:deviceId", sessionHandler.RevokeMyDevice)
+		protected.GET("/profile/logins", sessionHandler.MyLogins)
 		protected.POST("/change-password", authHandler.ChangePassword)
+		protected.POST("/profile/email", stepUp, emailHandler.RequestChange)
+		protected.POST("/profile/email/verification", emailHandler.SendVerification)
+
+		protected.POST("/2fa/enable", stepUp, authHandler.Enable2FA)
+		protected.POST("/2fa/disable", stepUp, authHandler.Disable2FA)
+		protected.POST("/2fa/totp/setup", stepUp, authHandler.SetupTOTP)
+		protected.POST("/2fa/totp/confirm", stepUp, authHandler.ConfirmTOTP)
+
+		// Break-glass: staff ask for a role for a while; an admin approves
+		protected.POST("/elevation", stepUp, elevationHandler.Request)
+		protected.GET("/elevation", elevationHandler.Mine)
+		protected.POST("/elevation/:id/token", elevationHandler.Token)
+		protected.POST("/elevation/:id/end", elevationHandler.End)
 
-		protected.POST("/2fa/enable", authHandler.Enable2FA)
-		protected.POST("/2fa/disable", authHandler.Disable2FA)
+		/* ---------- SHARED RESOURCES (policy-checked) ---------- */
+		protected.GET("/tickets/:id", owns(service.ActionTicketView), ticketHandler.GetTicket)
+		protected.GET("/amc/:id", owns(service.ActionAMCView), amcHandler.GetAMC)
+		protected.GET("/customer-products/:id", owns(service.ActionCustomerProductView), customerProductHandler.Get)
+
+		// A new passkey is a new way in (passwordless login): prove it is you first
+		protected.POST("/webauthn/register/begin", stepUp, webauthnHandler.BeginRegistration)
+		protected.POST("/webauthn/register/finish", stepUp, webauthnHandler.FinishRegistration)
+		protected.GET("/webauthn/credentials", webauthnHandler.ListCredentials)
+		protected.DELETE("/webauthn/credentials/:id", stepUp, webauthnHandler.DeleteCredential)
 
 		/* =========================
-		   ADMIN
+		   ADMIN (STAFF)
+		   Every route names its permission; custom
+		   roles reach exactly what they are granted.
 		========================= */
 		admin := protected.Group("/admin")
-		admin.Use(middleware.RequireRole(models.RoleAdmin))
 		{
-			admin.GET("/dashboard", adminDashboard.Dashboard)
+			admin.GET("/dashboard", can(models.PermDashboardView), adminDashboard.Dashboard)
 
 			// USERS
-			admin.POST("/users", authHandler.CreateUser)
-			admin.GET("/users", authHandler.GetAllUsers)
-			admin.GET("/support-engineers", authHandler.GetSupportEngineers) // New
+			admin.POST("/users", can(models.PermUserCreate), stepUp, authHandler.CreateUser)
+			admin.GET("/users", can(models.PermUserRead), authHandler.GetAllUsers)
+			admin.GET("/support-engineers", can(models.PermUserRead), authHandler.GetSupportEngineers) // New
+			admin.POST("/users/:id/unlock", can(models.PermUserUnlock), authHandler.UnlockUser)
+			admin.POST("/users/:id/impersonate", can(models.PermUserImpersonate), stepUp, authHandler.Impersonate)
+			admin.POST("/users/:id/deactivate", can(models.PermUserDeactivate), stepUp, userLifecycleHandler.Deactivate)
+			admin.POST("/users/:id/reactivate", can(models.PermUserDeactivate), userLifecycleHandler.Reactivate)
+			admin.DELETE("/users/:id", can(models.PermUserOffboard), stepUp, userLifecycleHandler.Offboard)
+			admin.PUT("/users/:id/role", can(models.PermRoleManage), stepUp, roleHandler.AssignRole)
+
+			// ROLES & PERMISSIONS
+			admin.GET("/permissions", can(models.PermRoleManage), roleHandler.Catalog)
+			admin.GET("/roles", can(models.PermRoleManage), roleHandler.List)
+			admin.POST("/roles", can(models.PermRoleManage), stepUp, roleHandler.Create)
+			admin.PUT("/roles/:name", can(models.PermRoleManage), stepUp, roleHandler.Update)
+			admin.DELETE("/roles/:name", can(models.PermRoleManage), stepUp, roleHandler.Delete)
+
+			// ACCESS REVIEW: route manifest + "why was this user denied?"
+			admin.GET("/access/routes", can(models.PermRoleManage), accessHandler.Manifest)
+			admin.POST("/access/explain", can(models.PermRoleManage), accessHandler.Explain)
+
+			// BREAK-GLASS ELEVATION
+			admin.GET("/elevations", can(models.PermElevationApprove), elevationHandler.List)
+			admin.POST("/elevations/:id/approve", can(models.PermElevationApprove), stepUp, elevationHandler.Approve)
+			admin.POST("/elevations/:id/deny", can(models.PermElevationApprove), elevationHandler.Deny)
+			admin.POST("/elevations/:id/revoke", can(models.PermElevationApprove), elevationHandler.Revoke)
+
+			// INVITATIONS
+			admin.GET("/invitations", can(models.PermUserCreate), invitationHandler.List)
+			admin.POST("/invitations/:id/resend", can(models.PermUserCreate), invitationHandler.Resend)
+			// Revoking deactivates the invited account: admin-only like any deactivation
+			admin.DELETE("/invitations/:id", can(models.PermUserDeactivate), invitationHandler.Revoke)
+
+			// USER SESSIONS & TRUSTED DEVICES
+			admin.GET("/users/:id/sessions", can(models.PermUserSessions), sessionHandler.UserSessions)
+			admin.DELETE("/users/:id/sessions", can(models.PermUserSessions), sessionHandler.RevokeAllUserSessions)
+			admin.DELETE("/users/:id/sessions/:sessionId", can(models.PermUserSessions), sessionHandler.RevokeUserSession)
+			admin.GET("/users/:id/devices", can(models.PermUserSessions), sessionHandler.UserDevices)
+			admin.DELETE("/users/:id/devices", can(models.PermUserSessions), sessionHandler.RevokeAllUserDevices)
+			admin.DELETE("/users/:id/devices/:deviceId", can(models.PermUserSessions), sessionHandler.RevokeUserDevice)
+			admin.GET("/users/:id/logins", can(models.PermUserSessions), sessionHandler.UserLogins)
+
+			// API KEYS (machine-to-machine)
+			admin.POST("/api-keys", can(models.PermAPIKeyManage), stepUp, apiKeyHandler.Create)
+			admin.GET("/api-keys", can(models.PermAPIKeyManage), apiKeyHandler.List)
+			admin.DELETE("/api-keys/:id", can(models.PermAPIKeyManage), stepUp, apiKeyHandler.Revoke)
 
 			// PRODUCTS
-			admin.POST("/products", productHandler.Create)
-			admin.GET("/products", productHandler.GetAll)
+			admin.POST("/products", can(models.PermProductCreate), productHandler.Create)
+			admin.GET("/products", can(models.PermProductRead), productHandler.GetAll)
 
 			admin.POST(
 				"/customers/:id/products",
+				can(models.PermProductAssign),
 				customerProductHandler.AssignToCustomer,
 			)
 			admin.GET(
 				"/customers/:id/products",
+				can(models.PermProductRead),
 				customerProductHandler.GetCustomerProducts,
 			)
 
 			// LOOKUPS
-			admin.GET("/categories", categoryHandler.GetAll)
-			admin.POST("/categories", categoryHandler.Create)
+			admin.GET("/categories", can(models.PermProductRead), categoryHandler.GetAll)
+			admin.POST("/categories", can(models.PermCatalogManage), categoryHandler.Create)
 
-			admin.GET("/categories/:id/brands", brandHandler.GetByCategory)
+			admin.GET("/categories/:id/brands", can(models.PermProductRead), brandHandler.GetByCategory)
 
 			// BRANDS
-			admin.GET("/brands", brandHandler.GetAll)
-			admin.POST("/brands", brandHandler.Create)
+			admin.GET("/brands", can(models.PermProductRead), brandHandler.GetAll)
+			admin.POST("/brands", can(models.PermCatalogManage), brandHandler.Create)
 
-			admin.GET("/brands/:id/models", modelHandler.GetByBrand)
-			admin.POST("/models", modelHandler.Create)
+			admin.GET("/brands/:id/models", can(models.PermProductRead), modelHandler.GetByBrand)
+			admin.POST("/models", can(models.PermCatalogManage), modelHandler.Create)
 
 			// AMC
-			admin.POST("/amc", amcHandler.Create)
-			admin.GET("/amc", amcHandler.GetAllAMCs)
+			admin.POST("/amc", can(models.PermAMCCreate), amcHandler.Create)
+			admin.GET("/amc", can(models.PermAMCRead), amcHandler.GetAllAMCs)
 
 			// TICKETS
-			admin.GET("/tickets", ticketHandler.GetAdminTickets)    // New: List all tickets
-			admin.POST("/tickets", ticketHandler.AdminCreateTicket) // Admin Create on behalf
-			admin.POST("/tickets/:id/assign", ticketHandler.AssignTicket)
+			admin.GET("/tickets", can(models.PermTicketRead), ticketHandler.GetAdminTickets)      // New: List all tickets
+			admin.POST("/tickets", can(models.PermTicketCreate), ticketHandler.AdminCreateTicket) // Admin Create on behalf
+			admin.POST("/tickets/:id/assign", can(models.PermTicketAssign), ticketHandler.AssignTicket)
+			admin.PUT("/tickets/:id/team", can(models.PermTeamManage), supportTeamHandler.QueueTicket)
 			// admin.POST("/tickets/:id/close", ticketHandler.CloseTicket) // Removed Admin Close for now, as Support closes it.
+
+			// SUPPORT TEAMS
+			admin.GET("/teams", can(models.PermUserRead), supportTeamHandler.List)
+			admin.GET("/teams/:id", can(models.PermUserRead), supportTeamHandler.Get)
+			admin.POST("/teams", can(models.PermTeamManage), supportTeamHandler.Create)
+			admin.PUT("/teams/:id", can(models.PermTeamManage), supportTeamHandler.Update)
+			admin.DELETE("/teams/:id", can(models.PermTeamManage), supportTeamHandler.Delete)
+			admin.PUT("/teams/:id/members", can(models.PermTeamManage), supportTeamHandler.SetMember)
+			admin.DELETE("/teams/:id/members/:userId", can(models.PermTeamManage), supportTeamHandler.RemoveMember)
 		}
 
 		/* =========================
 		   SUPPORT
 		========================= */
 		support := protected.Group("/support")
-		support.Use(middleware.RequireRole(models.RoleSupport))
+		support.Use(can(models.PermTicketWork))
 		{
 			support.GET("/tickets", supportDashboard.MyTickets)
-			support.POST("/tickets/:id/start", ticketHandler.StartTicket) // New
-			support.POST("/tickets/:id/close", ticketHandler.CloseTicket) // Support Close (with proof)
+			support.GET("/teams", supportDashboard.MyTeams)
+			support.POST("/tickets/:id/reassign", owns(service.ActionTicketReassign), supportTeamHandler.Reassign) // Team leads
+			support.POST("/tickets/:id/start", owns(service.ActionTicketWork), ticketHandler.StartTicket)          // New
+			support.POST("/tickets/:id/close", owns(service.ActionTicketWork), ticketHandler.CloseTicket)          // Support Close (with proof)
 		}
 
 		/* =========================
 		   CUSTOMER
 		========================= */
+		// Bound to the caller's Customer profile, so role rather than permission
 		customer := protected.Group("/customer")
-		customer.Use(middleware.RequireRole(models.RoleCustomer))
+		customer.Use(requires(middleware.RequireRole(models.RoleCustomer), service.ReqRole, string(models.RoleCustomer)))
 		{
 			customer.GET("/tickets", customerDashboard.MyTickets)
 			customer.POST("/tickets", ticketHandler.CreateTicket)
-			customer.POST("/tickets/:id/feedback", feedbackHandler.Submit)
+			customer.POST("/tickets/:id/feedback", owns(service.ActionTicketFeedback), feedbackHandler.Submit)
 			customer.GET("/amc", amcHandler.GetMyAMCs)
+
+			// Organization; member management is customer-admin only
+			customer.GET("/organization", customerOrgHandler.Organization)
+			customer.GET("/members", customerOrgHandler.ListMembers)
+			customer.POST("/members", stepUp, customerOrgHandler.InviteMember)
+			customer.PATCH("/members/:userId", customerOrgHandler.UpdateMember)
 		}
 	}
+
+	/* =========================
+	   API KEY SCOPES
+	   Only routes listed here accept X-API-Key;
+	   everything else stays JWT-only.
+	========================= */
+	middleware.RegisterRouteScopes(map[string]models.APIScope{
+		"GET /api/v1/admin/tickets":             models.ScopeTicketsRead,
+		"POST /api/v1/admin/tickets":            models.ScopeTicketsWrite,
+		"POST /api/v1/admin/tickets/:id/assign": models.ScopeTicketsWrite,
+
+		"GET /api/v1/admin/amc":  models.ScopeAMCRead,
+		"POST /api/v1/admin/amc": models.ScopeAMCWrite,
+
+		"GET /api/v1/admin/products":               models.ScopeProductsRead,
+		"GET /api/v1/admin/customers/:id/products": models.ScopeProductsRead,
+		"GET /api/v1/admin/categories":             models.ScopeProductsRead,
+		"GET /api/v1/admin/categories/:id/brands":  models.ScopeProductsRead,
+		"GET /api/v1/admin/brands":                 models.ScopeProductsRead,
+		"GET /api/v1/admin/brands/:id/models":      models.ScopeProductsRead,
+	})
+
+	access.SetRoutes(root.manifest())
 }
@@ -0,0 +1,197 @@
+package routes
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/config"
+	"rbac/dto"
+	"rbac/models"
+	"rbac/repository"
+	"rbac/service"
+	"rbac/testutil"
+	"rbac/utils"
+)
+
+type testServices struct {
+	keys        *utils.KeyRing
+	revocations repository.TokenRevocationStore
+	auditRepo   *repository.AuditRepository
+	roles       *service.RoleService
+	access      *service.AccessService
+	elevations  *service.ElevationService
+}
+
+// Real guards in front of nil handlers: a request that gets past every
+// guard panics into a 500
+func newTestRouter(t *testing.T, db *gorm.DB, cfg *config.Config) (*gin.Engine, *testServices) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	keys, err := utils.LoadKeyRing("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auditRepo := repository.NewAuditRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	revocations := repository.NewPostgresTokenRevocationStore(db)
+
+	roles := service.NewRoleService(roleRepo, auditRepo, revocations)
+	if err := roles.EnsureSystemRoles(); err != nil {
+		t.Fatal(err)
+	}
+	policy := service.NewPolicyService(repository.NewPolicyRepository(db), roles, cfg)
+	access := service.NewAccessService(roles, policy, roleRepo)
+	elevations := service.NewElevationService(repository.NewElevationRepository(db), roles, auditRepo, keys, cfg)
+
+	r := gin.New()
+	r.Use(gin.RecoveryWithWriter(io.Discard))
+	SetupRoutes(
+		r, cfg, keys, revocations, nil, roles, policy, access, elevations, auditRepo,
+		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		nil, nil, nil, nil,
+		nil, nil, nil, nil, nil,
+		nil, nil, nil,
+	)
+
+	return r, &testServices{
+		keys:        keys,
+		revocations: revocations,
+		auditRepo:   auditRepo,
+		roles:       roles,
+		access:      access,
+		elevations:  elevations,
+	}
+}
+
+// A break-glass admin token must not reach the escalation paths: no
+// permanent role change, no approving other elevations
+func TestElevatedTokenRefusedOnAdminOnlyRoutes(t *testing.T) {
+	db := testutil.NewDB(t)
+	cfg := testutil.Config()
+	r, svc := newTestRouter(t, db, cfg)
+	keys, elevations := svc.keys, svc.elevations
+
+	admin := testutil.User(t, db, models.RoleAdmin)
+	lead := testutil.User(t, db, models.RoleSupport)
+	other := testutil.User(t, db, models.RoleSupport)
+
+	grant, err := elevations.Request(lead.ID, models.RoleAdmin, "incident", 30, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := elevations.Approve(grant.ID, admin.ID, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	pending, err := elevations.Request(other.ID, models.RoleAdmin, "incident", 30, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fresh auth_time, so step-up can't be what stops the request
+	elevated, err := elevations.Token(grant.ID, lead.ID, utils.AuthContext{
+		Time:    time.Now(),
+		Methods: []string{utils.AMRPassword},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(token, method, path, body string) (*httptest.ResponseRecorder, dto.ErrorResponse) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var resp dto.ErrorResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	refused := []struct {
+		method, path, body string
+	}{
+		{http.MethodPut, "/api/v1/admin/users/" + lead.ID.String() + "/role", `{"role":"admin"}`},
+		{http.MethodPost, "/api/v1/admin/elevations/" + pending.ID.String() + "/approve", ""},
+	}
+	for _, tc := range refused {
+		w, resp := call(elevated.AccessToken, tc.method, tc.path, tc.body)
+		if w.Code != http.StatusForbidden || resp.Code != dto.CodeForbidden {
+			t.Errorf("%s %s: got %d %s, want 403 forbidden", tc.method, tc.path, w.Code, w.Body.String())
+		}
+	}
+
+	// Same route, plain admin token: passes the permission, stops at step-up
+	adminToken, err := utils.GenerateAccessToken(admin, keys, cfg.JWT.AccessExpiry, utils.AuthContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, resp := call(adminToken, http.MethodPut, refused[0].path, refused[0].body); resp.Code != dto.CodeReauthRequired {
+		t.Errorf("admin token: got %d %s, want reauth_required", w.Code, w.Body.String())
+	}
+
+	var stored models.User
+	if err := db.First(&stored, "id = ?", lead.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Role != models.RoleSupport {
+		t.Errorf("lead role = %s, want support", stored.Role)
+	}
+
+	// Refused or not, every elevated request is on record against the grant
+	var flagged int64
+	db.Model(&models.AuditLog{}).
+		Where("elevation_id = ? AND action LIKE ?", grant.ID, "elevated_request:%").
+		Count(&flagged)
+	if flagged != int64(len(refused)) {
+		t.Errorf("flagged audit entries = %d, want %d", flagged, len(refused))
+	}
+}
+
+// A denied session id stops every access token carrying it in `sid`
+func TestRevokedSessionTokenRefused(t *testing.T) {
+	db := testutil.NewDB(t)
+	cfg := testutil.Config()
+	r, svc := newTestRouter(t, db, cfg)
+
+	user := testutil.User(t, db, models.RoleSupport)
+	revokedSession, liveSession := uuid.New(), uuid.New()
+
+	get := func(session uuid.UUID) (*httptest.ResponseRecorder, dto.ErrorResponse) {
+		token, err := utils.GenerateAccessToken(user, svc.keys, cfg.JWT.AccessExpiry, utils.AuthContext{Session: session})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/profile/sessions", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var resp dto.ErrorResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	if err := svc.revocations.RevokeToken(revokedSession.String(), user.ID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if w, resp := get(revokedSession); w.Code != http.StatusUnauthorized || resp.Code != dto.CodeTokenRevoked {
+		t.Errorf("revoked session: got %d %s, want 401 token_revoked", w.Code, w.Body.String())
+	}
+	// Past every guard into the nil handler
+	if w, _ := get(liveSession); w.Code != http.StatusInternalServerError {
+		t.Errorf("live session: got %d %s, want to reach the handler", w.Code, w.Body.String())
+	}
+}
@@ -7,18 +7,48 @@ CREATE EXTENSION IF NOT EXISTS "pgcrypto";
 -- 2. USER MANAGEMENT & AUTH
 -- =========================================================
 
+-- ROLES (permission sets; users.role references name)
+CREATE TABLE IF NOT EXISTS roles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(20) NOT NULL UNIQUE,
+    display_name VARCHAR(100) NOT NULL,
+    description TEXT,
+    permissions TEXT,
+    is_system BOOLEAN DEFAULT FALSE,
+    created_by UUID,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+INSERT INTO roles (name, display_name, description, permissions, is_system, created_at, updated_at) VALUES
+    ('admin', 'Administrator', 'Full access', '["dashboard.view","user.read","user.create","user.unlock","user.sessions","user.deactivate","user.offboard","user.impersonate","role.manage","apikey.manage","product.read","product.create","product.assign","catalog.manage","amc.read","amc.create","ticket.read","ticket.create","ticket.assign","ticket.work"]', TRUE, NOW(), NOW()),
+    ('support', 'Support Engineer', 'Works tickets assigned to them', '["ticket.work"]', TRUE, NOW(), NOW()),
+    ('customer', 'Customer', 'Customer portal', '[]', TRUE, NOW(), NOW())
+ON CONFLICT (name) DO NOTHING;
+
 -- USERS
 CREATE TABLE IF NOT EXISTS users (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     name VARCHAR(100),
     email TEXT NOT NULL UNIQUE,
     password TEXT NOT NULL,
-    role VARCHAR(20) NOT NULL CHECK (role IN ('admin', 'support', 'customer')),
+    role VARCHAR(20) NOT NULL REFERENCES roles(name),
     is_active BOOLEAN DEFAULT TRUE,
     must_reset_password BOOLEAN DEFAULT FALSE,
     created_by UUID,
+    email_verified_at TIMESTAMPTZ,
     two_fa_enabled BOOLEAN DEFAULT FALSE,
+    two_fa_method VARCHAR(20) DEFAULT 'email',
     last_login_at TIMESTAMPTZ,
+    totp_secret TEXT,
+    totp_confirmed_at TIMESTAMPTZ,
+    totp_last_step BIGINT DEFAULT 0,
+    totp_pending_secret TEXT, -- from setup, until the first code is confirmed
+    oidc_subject TEXT UNIQUE,
+    scim_external_id TEXT UNIQUE,
+    deactivated_at TIMESTAMPTZ,
+    deactivated_by UUID,
+    offboarded_at TIMESTAMPTZ,
     created_at TIMESTAMPTZ,
     updated_at TIMESTAMPTZ
 );
@@ -31,8 +61,16 @@ CREATE TABLE IF NOT EXISTS refresh_tokens (
     token TEXT NOT NULL UNIQUE,
     expires_at TIMESTAMPTZ NOT NULL,
     is_revoked BOOLEAN DEFAULT FALSE,
+    family_id UUID NOT NULL DEFAULT gen_random_uuid(),
+    rotated_at TIMESTAMPTZ,
+    auth_time TIMESTAMPTZ,
+    auth_methods TEXT,
+    user_agent TEXT,
+    ip_address TEXT,
+    last_used_at TIMESTAMPTZ,
     created_at TIMESTAMPTZ
 );
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
 CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
 
 -- PASSWORD RESET TOKENS
@@ -52,10 +90,106 @@ CREATE TABLE IF NOT EXISTS two_fa_otps (
     code TEXT,
     expires_at TIMESTAMPTZ,
     used BOOLEAN,
+    attempts INT NOT NULL DEFAULT 0,
     created_at TIMESTAMPTZ
 );
 CREATE INDEX IF NOT EXISTS idx_two_fa_otps_user_id ON two_fa_otps(user_id);
 
+-- OIDC LOGIN STATES (staff SSO)
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    state TEXT NOT NULL UNIQUE,
+    nonce TEXT NOT NULL,
+    code_verifier TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_oidc_login_states_expires_at ON oidc_login_states(expires_at);
+
+-- API KEYS (machine-to-machine)
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL UNIQUE,
+    hash TEXT NOT NULL,
+    scopes TEXT,
+    rate_limit_per_minute INT NOT NULL DEFAULT 60,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    last_used_ip TEXT,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_created_by ON api_keys(created_by);
+
+-- INVITATIONS (admin-created accounts awaiting a password)
+CREATE TABLE IF NOT EXISTS invitations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    invited_by UUID NOT NULL,
+    reset_token_id UUID,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'revoked')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    send_count INT NOT NULL DEFAULT 0,
+    last_sent_at TIMESTAMPTZ,
+    last_send_error TEXT,
+    accepted_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    revoked_by UUID,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_invitations_user_id ON invitations(user_id);
+CREATE INDEX IF NOT EXISTS idx_invitations_status ON invitations(status);
+
+-- ACCESS-TOKEN REVOCATION
+CREATE TABLE IF NOT EXISTS revoked_access_tokens (
+    jti TEXT PRIMARY KEY,
+    user_id UUID,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_access_tokens_user_id ON revoked_access_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_revoked_access_tokens_expires_at ON revoked_access_tokens(expires_at);
+
+CREATE TABLE IF NOT EXISTS user_token_cutoffs (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    not_before TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ
+);
+
+-- LOGIN THROTTLES (brute-force protection)
+CREATE TABLE IF NOT EXISTS login_throttles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    scope TEXT NOT NULL,
+    key TEXT NOT NULL,
+    failures INT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    last_failure_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_login_throttle_scope_key ON login_throttles(scope, key);
+
+-- LOGIN HISTORY (successes and failures; user_id NULL for unknown emails)
+CREATE TABLE IF NOT EXISTS login_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT,
+    method VARCHAR(20) NOT NULL,
+    two_fa_method VARCHAR(20),
+    outcome VARCHAR(20) NOT NULL,
+    reason VARCHAR(50),
+    ip_address TEXT,
+    ip_range TEXT,
+    user_agent TEXT,
+    device_hash TEXT,
+    new_device BOOLEAN DEFAULT FALSE,
+    new_ip_range BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_login_events_user_created ON login_events(user_id, created_at);
+
 -- REMEMBERED DEVICES
 CREATE TABLE IF NOT EXISTS remembered_devices (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -64,14 +198,91 @@ CREATE TABLE IF NOT EXISTS remembered_devices (
     user_agent TEXT,
     ip_address TEXT,
     expires_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
     created_at TIMESTAMPTZ
 );
 
+-- 2FA RECOVERY CODES
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL UNIQUE,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes(user_id);
+
+-- EMAIL VERIFICATION / EMAIL CHANGE LINKS
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    purpose VARCHAR(20) NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_email_verification_tokens_user_id ON email_verification_tokens(user_id);
+
+-- PASSWORD HISTORY (previous hashes, blocks reuse)
+CREATE TABLE IF NOT EXISTS password_histories (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_password_histories_user_id ON password_histories(user_id);
+
+-- WEBAUTHN CREDENTIALS (PASSKEYS)
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    credential_id BYTEA NOT NULL UNIQUE,
+    name VARCHAR(100),
+    data TEXT NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_webauthn_credentials_user_id ON webauthn_credentials(user_id);
+
+-- WEBAUTHN CEREMONY SESSIONS
+CREATE TABLE IF NOT EXISTS webauthn_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID,
+    ceremony VARCHAR(30) NOT NULL,
+    data TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_webauthn_sessions_user_id ON webauthn_sessions(user_id);
+
+-- BREAK-GLASS ELEVATION (role held temporarily; starts on approval)
+CREATE TABLE IF NOT EXISTS elevation_grants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    base_role VARCHAR(20) NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    reason TEXT NOT NULL,
+    duration INT NOT NULL, -- minutes
+    status VARCHAR(20) NOT NULL, -- pending | active | denied | expired | ended | revoked
+    decided_by UUID,
+    decided_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    ended_at TIMESTAMPTZ,
+    ended_by UUID,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_elevation_grants_user_id ON elevation_grants(user_id);
+CREATE INDEX IF NOT EXISTS idx_elevation_grants_status ON elevation_grants(status);
+CREATE INDEX IF NOT EXISTS idx_elevation_grants_expires_at ON elevation_grants(expires_at);
+
 -- =========================================================
 -- 3. PROFILES
 -- =========================================================
 
--- CUSTOMERS
+-- CUSTOMERS (one row per client organization; user_id = founding contact)
 CREATE TABLE IF NOT EXISTS customers (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     user_id UUID UNIQUE REFERENCES users(id) ON DELETE CASCADE,
@@ -83,6 +294,19 @@ CREATE TABLE IF NOT EXISTS customers (
     updated_at TIMESTAMPTZ
 );
 
+-- CUSTOMER MEMBERS (users of an organization; a user belongs to at most one)
+CREATE TABLE IF NOT EXISTS customer_members (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+    ticket_visibility VARCHAR(10) NOT NULL DEFAULT 'own' CHECK (ticket_visibility IN ('own', 'all')),
+    invited_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_customer_members_customer ON customer_members(customer_id);
+
 -- SUPPORT ENGINEERS
 CREATE TABLE IF NOT EXISTS support_engineers (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -93,6 +317,28 @@ CREATE TABLE IF NOT EXISTS support_engineers (
     updated_at TIMESTAMPTZ
 );
 
+-- SUPPORT TEAMS (region, product line, ...)
+CREATE TABLE IF NOT EXISTS support_teams (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+-- SUPPORT TEAM MEMBERS (an engineer may be in several teams; is_lead = team lead)
+CREATE TABLE IF NOT EXISTS support_team_members (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    team_id UUID NOT NULL REFERENCES support_teams(id) ON DELETE CASCADE,
+    support_engineer_id UUID NOT NULL REFERENCES support_engineers(id) ON DELETE CASCADE,
+    is_lead BOOLEAN NOT NULL DEFAULT FALSE,
+    added_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    UNIQUE(team_id, support_engineer_id)
+);
+
 -- =========================================================
 -- 4. PRODUCT CATALOG
 -- =========================================================
@@ -185,10 +431,14 @@ CREATE TABLE IF NOT EXISTS tickets (
     closed_at TIMESTAMPTZ,
     created_by UUID REFERENCES users(id),
     created_at TIMESTAMPTZ,
-    updated_at TIMESTAMPTZ
+    updated_at TIMESTAMPTZ,
+    needs_reassignment BOOLEAN DEFAULT FALSE,
+    team_id UUID REFERENCES support_teams(id) ON DELETE SET NULL -- queue; NULL = unscoped
 );
 CREATE INDEX IF NOT EXISTS idx_tickets_customer_id ON tickets(customer_id);
 CREATE INDEX IF NOT EXISTS idx_tickets_status ON tickets(status);
+CREATE INDEX IF NOT EXISTS idx_tickets_needs_reassignment ON tickets(needs_reassignment);
+CREATE INDEX IF NOT EXISTS idx_tickets_team ON tickets(team_id);
 
 -- TICKET ASSIGNMENTS
 CREATE TABLE IF NOT EXISTS ticket_assignments (
@@ -291,8 +541,10 @@ CREATE TABLE IF NOT EXISTS audit_logs (
     performed_by UUID NULL REFERENCES users(id),
     ip TEXT,
     user_agent TEXT,
+    elevation_id UUID, -- set for actions taken under break-glass elevation
     created_at TIMESTAMPTZ
 );
+CREATE INDEX IF NOT EXISTS idx_audit_logs_elevation_id ON audit_logs(elevation_id);
 
 -- =========================================================
 -- 8. ESCALATIONS
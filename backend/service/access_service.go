@@ -0,0 +1,422 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/models"
+	"rbac/repository"
+)
+
+/*
+=====================
+ Access Explain & Route Manifest
+=====================
+ routes.SetupRoutes records every route with the guards in front of it
+ (in chain order). The manifest is that list; Explain replays the guards
+ for one user against one method + path and reports the first denial.
+*/
+
+type RequirementKind string
+
+const (
+	ReqAuthenticated RequirementKind = "authenticated" // JWT (or API key on scoped routes)
+	ReqTwoFAPending  RequirementKind = "2fa_pending"   // temp token from the password step
+	ReqSCIM          RequirementKind = "scim"          // API key with scim:provision
+	ReqRole          RequirementKind = "role"
+	ReqPermission    RequirementKind = "permission"
+	ReqPolicy        RequirementKind = "policy"      // relationship to the :param resource
+	ReqRecentAuth    RequirementKind = "recent_auth" // step-up within Value
+)
+
+type RouteRequirement struct {
+	Kind  RequirementKind `json:"kind"`
+	Value string          `json:"value,omitempty"`
+	Param string          `json:"param,omitempty"` // policy only
+}
+
+// No requirements = public
+type RouteRule struct {
+	Method       string             `json:"method"`
+	Path         string             `json:"path"`
+	Handler      string             `json:"handler"`
+	Requirements []RouteRequirement `json:"requirements"`
+	APIKeyScope  models.APIScope    `json:"api_key_scope,omitempty"`
+}
+
+type AccessService struct {
+	roles  *RoleService
+	policy *PolicyService
+	users  *repository.RoleRepository
+
+	mu     sync.RWMutex
+	routes []RouteRule
+}
+
+func NewAccessService(
+	roles *RoleService,
+	policy *PolicyService,
+	users *repository.RoleRepository,
+) *AccessService {
+	return &AccessService{
+		roles:  roles,
+		policy: policy,
+		users:  users,
+	}
+}
+
+/*
+=====================
+ Response DTOs
+=====================
+*/
+
+type RouteManifest struct {
+	Digest string      `json:"digest"` // sha256 of routes; changes with any rule
+	Count  int         `json:"count"`
+	Routes []RouteRule `json:"routes"`
+}
+
+type AccessCheckResult string
+
+const (
+	CheckPass        AccessCheckResult = "pass"
+	CheckDeny        AccessCheckResult = "deny"
+	CheckConditional AccessCheckResult = "conditional" // depends on the session, not the user
+	CheckUndecided   AccessCheckResult = "undecided"   // depends on which resource
+	CheckNotReached  AccessCheckResult = "not_reached"
+)
+
+type AccessCheck struct {
+	Requirement RouteRequirement  `json:"requirement"`
+	Result      AccessCheckResult `json:"result"`
+	Reason      string            `json:"reason"`
+}
+
+type AccessSubject struct {
+	ID       uuid.UUID   `json:"id"`
+	Email    string      `json:"email"`
+	Role     models.Role `json:"role"`
+	IsActive bool        `json:"is_active"`
+}
+
+type AccessExplanation struct {
+	Allowed bool           `json:"allowed"`
+	Reason  string         `json:"reason"`
+	User    *AccessSubject `json:"user"`
+	Route   *RouteRule     `json:"route"`
+	Checks  []AccessCheck  `json:"checks"`
+}
+
+/*
+=====================
+ Manifest
+=====================
+*/
+
+// Called once by routes.SetupRoutes
+func (s *AccessService) SetRoutes(routes []RouteRule) {
+	sorted := append([]RouteRule(nil), routes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	s.mu.Lock()
+	s.routes = sorted
+	s.mu.Unlock()
+}
+
+func (s *AccessService) Manifest() (*RouteManifest, error) {
+	s.mu.RLock()
+	routes := s.routes
+	s.mu.RUnlock()
+
+	raw, err := json.Marshal(routes)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+
+	return &RouteManifest{
+		Digest: hex.EncodeToString(sum[:]),
+		Count:  len(routes),
+		Routes: routes,
+	}, nil
+}
+
+/*
+=====================
+ Explain
+=====================
+*/
+
+// path is a concrete request path ("/api/v1/tickets/<uuid>") or a route
+// pattern; policy checks need a concrete id to be decided
+func (s *AccessService) Explain(
+	userID uuid.UUID,
+	method string,
+	path string,
+) (*AccessExplanation, error) {
+
+	user, err := s.users.FindUser(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	route, params := s.match(strings.ToUpper(method), path)
+	if route == nil {
+		return nil, fmt.Errorf("%w: no route matches %s %s", ErrResourceNotFound, strings.ToUpper(method), path)
+	}
+
+	result := &AccessExplanation{
+		Allowed: true,
+		Reason:  "every requirement is met",
+		User: &AccessSubject{
+			ID:       user.ID,
+			Email:    user.Email,
+			Role:     user.Role,
+			IsActive: user.IsActive,
+		},
+		Route:  route,
+		Checks: make([]AccessCheck, 0, len(route.Requirements)),
+	}
+
+	if len(route.Requirements) == 0 {
+		result.Reason = "public route"
+		return result, nil
+	}
+
+	denied := false
+	for _, req := range route.Requirements {
+		if denied {
+			result.Checks = append(result.Checks, AccessCheck{
+				Requirement: req,
+				Result:      CheckNotReached,
+				Reason:      "an earlier requirement denied the request",
+			})
+			continue
+		}
+
+		check, err := s.check(user, req, params)
+		if err != nil {
+			return nil, err
+		}
+		result.Checks = append(result.Checks, *check)
+
+		switch check.Result {
+		case CheckDeny:
+			denied = true
+			result.Allowed = false
+			result.Reason = check.Reason
+		case CheckUndecided:
+			if result.Allowed {
+				result.Allowed = false
+				result.Reason = check.Reason
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (s *AccessService) check(
+	user *models.User,
+	req RouteRequirement,
+	params map[string]string,
+) (*AccessCheck, error) {
+
+	check := &AccessCheck{Requirement: req, Result: CheckPass}
+
+	switch req.Kind {
+	case ReqAuthenticated:
+		if !user.IsActive {
+			check.Result = CheckDeny
+			check.Reason = "account is deactivated"
+		} else {
+			check.Reason = "active account"
+		}
+
+	case ReqTwoFAPending:
+		check.Result = CheckConditional
+		check.Reason = "needs the temporary token issued after the password step"
+
+	case ReqSCIM:
+		check.Result = CheckDeny
+		check.Reason = "only API keys with " + string(models.ScopeSCIMProvision) + " can call SCIM routes"
+
+	case ReqRole:
+		allowed := strings.Split(req.Value, ",")
+		for _, role := range allowed {
+			if models.Role(role) == user.Role {
+				check.Reason = "user has role " + role
+				return check, nil
+			}
+		}
+		check.Result = CheckDeny
+		check.Reason = fmt.Sprintf("requires role %s; user has role %s", strings.Join(allowed, " or "), user.Role)
+
+	case ReqPermission:
+		perm := models.Permission(req.Value)
+		granted, err := s.roles.HasPermission(user.Role, perm)
+		if err != nil {
+			return nil, err
+		}
+		if granted {
+			check.Reason = fmt.Sprintf("role %s grants %s", user.Role, perm)
+		} else {
+			check.Result = CheckDeny
+			check.Reason = fmt.Sprintf("role %s does not grant %s", user.Role, perm)
+		}
+
+	case ReqPolicy:
+		return s.checkPolicy(user, req, params)
+
+	case ReqRecentAuth:
+		check.Result = CheckConditional
+		check.Reason = "session must have re-authenticated within " + req.Value
+
+	default:
+		check.Result = CheckUndecided
+		check.Reason = "unknown requirement " + string(req.Kind)
+	}
+
+	return check, nil
+}
+
+func (s *AccessService) checkPolicy(
+	user *models.User,
+	req RouteRequirement,
+	params map[string]string,
+) (*AccessCheck, error) {
+
+	check := &AccessCheck{Requirement: req, Result: CheckPass}
+	action := PolicyAction(req.Value)
+	rule := describePolicyRule(action)
+
+	resourceID, err := uuid.Parse(params[req.Param])
+	if err != nil {
+		check.Result = CheckUndecided
+		check.Reason = fmt.Sprintf("depends on the :%s resource (%s); pass a concrete id", req.Param, rule)
+		return check, nil
+	}
+
+	switch err := s.policy.Authorize(user.ID, user.Role, action, resourceID); {
+	case err == nil:
+		check.Reason = "allowed by " + rule
+	case errors.Is(err, ErrForbidden):
+		check.Result = CheckDeny
+		check.Reason = "user is not " + rule
+	case errors.Is(err, ErrResourceNotFound):
+		check.Result = CheckDeny
+		check.Reason = fmt.Sprintf(":%s %s does not exist", req.Param, resourceID)
+	default:
+		return nil, err
+	}
+
+	return check, nil
+}
+
+// "assignee, owner or holder of ticket.read"
+func describePolicyRule(action PolicyAction) string {
+	rule, ok := policyRules[action]
+	if !ok {
+		return "unknown policy " + string(action)
+	}
+
+	parts := make([]string, 0, len(rule.relations)+1)
+	for _, rel := range rule.relations {
+		parts = append(parts, string(rel))
+	}
+	if rule.override != "" {
+		parts = append(parts, "holder of "+string(rule.override))
+	}
+
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " or " + parts[len(parts)-1]
+}
+
+// Same precedence as gin: static segments beat :params beat *wildcards
+func (s *AccessService) match(method, path string) (*RouteRule, map[string]string) {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	segments := splitPath(path)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var (
+		best       *RouteRule
+		bestParams map[string]string
+		bestScore  = -1
+	)
+
+	for i := range s.routes {
+		route := &s.routes[i]
+		if route.Method != method {
+			continue
+		}
+
+		params, score, ok := matchPattern(splitPath(route.Path), segments)
+		if ok && score > bestScore {
+			best, bestParams, bestScore = route, params, score
+		}
+	}
+
+	if best == nil {
+		return nil, nil
+	}
+	copied := *best
+	return &copied, bestParams
+}
+
+func splitPath(p string) []string {
+	return strings.Split(strings.Trim(p, "/"), "/")
+}
+
+// score = number of static segments matched
+func matchPattern(pattern, segments []string) (map[string]string, int, bool) {
+	params := map[string]string{}
+	score := 0
+
+	for i, part := range pattern {
+		if strings.HasPrefix(part, "*") {
+			params[part[1:]] = "/" + strings.Join(segments[min(i, len(segments)):], "/")
+			return params, score, true
+		}
+		if i >= len(segments) {
+			return nil, 0, false
+		}
+
+		switch {
+		case strings.HasPrefix(part, ":"):
+			params[part[1:]] = segments[i]
+		case part == segments[i]:
+			score++
+		default:
+			return nil, 0, false
+		}
+	}
+
+	if len(pattern) != len(segments) {
+		return nil, 0, false
+	}
+	return params, score, true
+}
@@ -36,6 +36,11 @@ func (s *AMCService) GetCustomerAMCs(
 
 	return s.repo.FindByCustomerUserID(userID)
 }
+// Access is decided by the route policy (owner, company, amc.read)
+func (s *AMCService) GetByID(id uuid.UUID) (*models.AMCContract, error) {
+	return s.repo.FindByID(id)
+}
+
 func (s *AMCService) CreateAMC(
 	customerProductID uuid.UUID,
 	slaHours int,
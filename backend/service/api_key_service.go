@@ -0,0 +1,194 @@
+package service
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rbac/models"
+	"rbac/repository"
+	"rbac/utils"
+)
+
+const defaultAPIKeyRateLimit = 60 // requests per minute
+
+type APIKeyService struct {
+	repo      *repository.APIKeyRepository
+	auditRepo *repository.AuditRepository
+}
+
+func NewAPIKeyService(
+	repo *repository.APIKeyRepository,
+	auditRepo *repository.AuditRepository,
+) *APIKeyService {
+	return &APIKeyService{
+		repo:      repo,
+		auditRepo: auditRepo,
+	}
+}
+
+/*
+=====================
+ Response DTOs
+=====================
+*/
+
+type APIKeyInfo struct {
+	ID                 uuid.UUID         `json:"id"`
+	Name               string            `json:"name"`
+	Prefix             string            `json:"prefix"`
+	Scopes             []models.APIScope `json:"scopes"`
+	RateLimitPerMinute int               `json:"rate_limit_per_minute"`
+	ExpiresAt          *time.Time        `json:"expires_at"`
+	RevokedAt          *time.Time        `json:"revoked_at"`
+	LastUsedAt         *time.Time        `json:"last_used_at"`
+	LastUsedIP         string            `json:"last_used_ip"`
+	CreatedBy          uuid.UUID         `json:"created_by"`
+	CreatedAt          time.Time         `json:"created_at"`
+}
+
+func toAPIKeyInfo(k *models.APIKey) *APIKeyInfo {
+	return &APIKeyInfo{
+		ID:                 k.ID,
+		Name:               k.Name,
+		Prefix:             k.Prefix,
+		Scopes:             k.Scopes,
+		RateLimitPerMinute: k.RateLimitPerMinute,
+		ExpiresAt:          k.ExpiresAt,
+		RevokedAt:          k.RevokedAt,
+		LastUsedAt:         k.LastUsedAt,
+		LastUsedIP:         k.LastUsedIP,
+		CreatedBy:          k.CreatedBy,
+		CreatedAt:          k.CreatedAt,
+	}
+}
+
+/*
+=====================
+ Admin: Manage Keys
+=====================
+*/
+
+// Returns the raw key — shown once, never stored
+func (s *APIKeyService) Create(
+	name string,
+	scopes []models.APIScope,
+	expiresAt *time.Time,
+	rateLimit int,
+	createdBy uuid.UUID,
+	ip string,
+	userAgent string,
+) (string, *APIKeyInfo, error) {
+
+	// Keys only work while their owner is an active admin (Authenticate),
+	// so a break-glass admin can't mint one: it would never authenticate
+	owner, err := s.repo.FindOwner(createdBy)
+	if err != nil || !owner.IsActive || owner.Role != models.RoleAdmin {
+		return "", nil, ErrForbidden
+	}
+
+	if len(scopes) == 0 {
+		return "", nil, errors.New("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return "", nil, errors.New("unknown scope: " + string(scope))
+		}
+	}
+
+	if expiresAt != nil && expiresAt.Before(time.Now()) {
+		return "", nil, errors.New("expiry must be in the future")
+	}
+
+	if rateLimit <= 0 {
+		rateLimit = defaultAPIKeyRateLimit
+	}
+
+	raw, prefix, err := utils.GenerateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	key := &models.APIKey{
+		Name:               name,
+		Prefix:             prefix,
+		Hash:               utils.HashToken(raw),
+		Scopes:             scopes,
+		RateLimitPerMinute: rateLimit,
+		ExpiresAt:          expiresAt,
+		CreatedBy:          createdBy,
+	}
+
+	if err := s.repo.Create(key); err != nil {
+		return "", nil, err
+	}
+
+	_ = s.auditRepo.Log("api_key", key.ID, "api_key_created", createdBy, ip, userAgent)
+
+	return raw, toAPIKeyInfo(key), nil
+}
+
+func (s *APIKeyService) List() ([]*APIKeyInfo, error) {
+	keys, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*APIKeyInfo, 0, len(keys))
+	for i := range keys {
+		result = append(result, toAPIKeyInfo(&keys[i]))
+	}
+	return result, nil
+}
+
+func (s *APIKeyService) Revoke(
+	id uuid.UUID,
+	revokedBy uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+	revoked, err := s.repo.Revoke(id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("api key not found")
+	}
+
+	_ = s.auditRepo.Log("api_key", id, "api_key_revoked", revokedBy, ip, userAgent)
+
+	return nil
+}
+
+/*
+=====================
+ Authenticate (middleware)
+=====================
+*/
+
+func (s *APIKeyService) Authenticate(raw string, ip string) (*models.APIKey, error) {
+	prefix, ok := utils.ParseAPIKey(raw)
+	if !ok {
+		return nil, errors.New("invalid api key")
+	}
+
+	key, err := s.repo.FindUsableByPrefix(prefix)
+	if err != nil {
+		return nil, errors.New("invalid api key")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(utils.HashToken(raw))) != 1 {
+		return nil, errors.New("invalid api key")
+	}
+
+	// Keys die with the admin who issued them
+	if !key.Owner.IsActive || key.Owner.Role != models.RoleAdmin {
+		return nil, errors.New("api key owner inactive")
+	}
+
+	_ = s.repo.TouchLastUsed(key.ID, ip)
+
+	return key, nil
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"rbac/models"
+	"rbac/repository"
+	"rbac/testutil"
+)
+
+func TestAPIKeyAuthenticatesAsOwner(t *testing.T) {
+	db := testutil.NewDB(t)
+	keys := NewAPIKeyService(repository.NewAPIKeyRepository(db), repository.NewAuditRepository(db))
+
+	admin := testutil.User(t, db, models.RoleAdmin)
+
+	raw, _, err := keys.Create("ci", []models.APIScope{models.ScopeTicketsRead}, nil, 0, admin.ID, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := keys.Authenticate(raw, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if key.Owner.ID != admin.ID || key.Owner.Role != models.RoleAdmin {
+		t.Fatalf("owner = %s (%s), want %s (admin)", key.Owner.ID, key.Owner.Role, admin.ID)
+	}
+
+	if _, err := keys.Authenticate(raw+"x", "127.0.0.1"); err == nil {
+		t.Fatal("tampered key accepted")
+	}
+}
+
+// Refused at creation unless the owner is a stored admin: Authenticate
+// would reject such a key on every request afterwards
+func TestAPIKeyCreateRequiresStoredAdmin(t *testing.T) {
+	db := testutil.NewDB(t)
+	keys := NewAPIKeyService(repository.NewAPIKeyRepository(db), repository.NewAuditRepository(db))
+
+	lead := testutil.User(t, db, models.RoleSupport) // e.g. holding a break-glass admin token
+
+	_, _, err := keys.Create("ci", []models.APIScope{models.ScopeTicketsRead}, nil, 0, lead.ID, "", "")
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatalf("support creating key: err = %v, want ErrForbidden", err)
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"rbac/models"
+)
+
+/*
+=====================
+ Auth Errors
+=====================
+ Callers branch with errors.Is / errors.As — never on the message.
+*/
+
+var (
+	ErrInvalidCredentials    = errors.New("invalid credentials")
+	ErrPasswordResetRequired = errors.New("password reset required")
+	ErrAccountInactive       = errors.New("account inactive")
+	ErrUserNotFound          = errors.New("user not found")
+
+	ErrInvalidOTP          = errors.New("invalid or expired otp")
+	ErrInvalidRecoveryCode = errors.New("invalid recovery code")
+	ErrTwoFANotEnabled     = errors.New("2FA is not enabled")
+
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrInvalidResetToken   = errors.New("invalid or expired reset link")
+	ErrInvalidOldPassword  = errors.New("invalid old password")
+	ErrWeakPassword        = errors.New("weak password")
+	ErrPasswordReused      = errors.New("password was used recently, choose a different one")
+
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification link")
+	ErrEmailTaken               = errors.New("email already in use")
+	ErrEmailAlreadyVerified     = errors.New("email already verified")
+
+	ErrRoleNotFound = errors.New("role not found")
+	ErrRoleExists   = errors.New("role already exists")
+	ErrRoleInUse    = errors.New("role is still assigned to users")
+	ErrRoleReadOnly = errors.New("built-in role cannot be changed")
+	ErrInvalidRole  = errors.New("invalid role")
+	ErrLastAdmin    = errors.New("cannot remove the last active admin")
+	ErrForbidden    = errors.New("insufficient permissions")
+
+	ErrResourceNotFound = errors.New("resource not found")
+
+	ErrTeamExists    = errors.New("support team already exists")
+	ErrNotAnEngineer = errors.New("user cannot work tickets")
+	ErrNotTeamMember = errors.New("engineer is not in this team")
+
+	ErrElevationOpen     = errors.New("an elevation request is already open")
+	ErrElevationInactive = errors.New("elevation is not active")
+
+	ErrMailerUnavailable = errors.New("email service not configured")
+)
+
+// Too many failed attempts; RetryAfter is the remaining lockout
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// Keeps the policy reason in the message, matchable as ErrWeakPassword
+func weakPassword(reason error) error {
+	return fmt.Errorf("%w: %v", ErrWeakPassword, reason)
+}
+
+/*
+=====================
+ Login Result
+=====================
+*/
+
+// Exactly one of Tokens / TwoFA is set
+type LoginResult struct {
+	Tokens *LoginResponse
+	TwoFA  *TwoFAChallenge
+}
+
+// Second factor pending: the client continues with Token on /auth/verify-2fa*
+type TwoFAChallenge struct {
+	Method models.TwoFAMethod `json:"two_fa_method"`
+	Token  string             `json:"two_fa_token"`
+}
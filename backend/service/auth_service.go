@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -23,6 +24,14 @@ type AuthService struct {
 	repo         *repository.AuthRepository
 	deviceRepo   *repository.RememberedDeviceRepo
 	customerRepo *repository.CustomerRepository
+	auditRepo    *repository.AuditRepository
+	webauthnRepo *repository.WebAuthnRepository
+	throttleRepo *repository.LoginThrottleRepository
+	loginEvents  *repository.LoginEventRepository
+	keys         *utils.KeyRing
+	revocations  repository.TokenRevocationStore
+	invitations  *InvitationService
+	passwords    *utils.PasswordPolicy
 	mailer       *utils.Mailer
 	cfg          *config.Config
 }
@@ -32,6 +41,14 @@ func NewAuthService(
 	repo *repository.AuthRepository,
 	deviceRepo *repository.RememberedDeviceRepo,
 	customerRepo *repository.CustomerRepository,
+	auditRepo *repository.AuditRepository,
+	webauthnRepo *repository.WebAuthnRepository,
+	throttleRepo *repository.LoginThrottleRepository,
+	loginEvents *repository.LoginEventRepository,
+	keys *utils.KeyRing,
+	revocations repository.TokenRevocationStore,
+	invitations *InvitationService,
+	passwords *utils.PasswordPolicy,
 	cfg *config.Config,
 ) *AuthService {
 	return &AuthService{
@@ -39,6 +56,14 @@ func NewAuthService(
 		repo:         repo,
 		deviceRepo:   deviceRepo,
 		customerRepo: customerRepo,
+		auditRepo:    auditRepo,
+		webauthnRepo: webauthnRepo,
+		throttleRepo: throttleRepo,
+		loginEvents:  loginEvents,
+		keys:         keys,
+		revocations:  revocations,
+		invitations:  invitations,
+		passwords:    passwords,
 		mailer:       utils.NewMailer(cfg.Mail),
 		cfg:          cfg,
 	}
@@ -63,6 +88,11 @@ type UserInfo struct {
 	Role  models.Role `json:"role"`
 }
 
+type TOTPSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
 type GetuserInfo struct {
 	ID        uuid.UUID   `json:"id"`
 	Name      string      `json:"name"`
@@ -70,6 +100,9 @@ type GetuserInfo struct {
 	Role      models.Role `json:"role"`
 	CreatedAt time.Time   `json:"created_at"`
 	IsActive  bool        `json:"is_active"`
+
+	EmailVerified   bool       `json:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
 }
 
 /*
@@ -85,22 +118,41 @@ func (s *AuthService) Login(
 	rememberDevice bool, // 👈 checkbox from login page
 	ip string,
 	userAgent string,
-) (*LoginResponse, error) {
+) (*LoginResult, error) {
 
-	// 1️⃣ Find user
-	user, err := s.repo.FindUserByEmail(email)
+	// 🛑 Locked out (per account or per IP)?
+	if err := s.ensureLoginAllowed(email, ip); err != nil {
+		s.recordLoginAttempt(email, nil, models.LoginMethodPassword, models.LoginLocked, err, ip, userAgent)
+		return nil, err
+	}
+
+	// 1️⃣ Find user (inactive too, reported only after the password matched)
+	user, err := s.repo.FindAnyUserByEmail(email)
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		s.recordLoginFailure(email, nil, ip, userAgent)
+		s.recordLoginAttempt(email, nil, models.LoginMethodPassword, models.LoginFailed, ErrInvalidCredentials, ip, userAgent)
+		return nil, ErrInvalidCredentials
 	}
 
 	// 2️⃣ Verify password
 	if err := utils.CheckPassword(password, user.Password); err != nil {
-		return nil, errors.New("invalid credentials")
+		s.recordLoginFailure(email, user, ip, userAgent)
+		s.recordLoginAttempt(email, user, models.LoginMethodPassword, models.LoginFailed, ErrInvalidCredentials, ip, userAgent)
+		return nil, ErrInvalidCredentials
+	}
+
+	s.clearLoginFailures(email)
+	s.upgradePasswordHash(user.ID, user.Password, password)
+
+	if !user.IsActive {
+		s.recordLoginAttempt(email, user, models.LoginMethodPassword, models.LoginFailed, ErrAccountInactive, ip, userAgent)
+		return nil, ErrAccountInactive
 	}
 
 	// 3️⃣ Force password reset
 	if user.MustResetPassword {
-		return nil, errors.New("PASSWORD_RESET_REQUIRED")
+		s.recordLoginAttempt(email, user, models.LoginMethodPassword, models.LoginFailed, ErrPasswordResetRequired, ip, userAgent)
+		return nil, ErrPasswordResetRequired
 	}
 
 	// 4️⃣ 2FA FLOW (only after first successful login)
@@ -111,54 +163,49 @@ func (s *AuthService) Login(
 		if deviceToken != "" {
 			hashed := utils.HashRememberDeviceToken(deviceToken)
 			if s.deviceRepo.ExistsValid(user.ID, hashed) {
-				return s.issueTokens(user)
+				_ = s.deviceRepo.Touch(user.ID, hashed)
+
+				tokens, err := s.issueTokens(user, utils.NewAuthContext(utils.AMRPassword), ip, userAgent)
+				if err != nil {
+					return nil, err
+				}
+				return &LoginResult{Tokens: tokens}, nil
 			}
 		}
 
-		// ❌ Device not trusted → send OTP
-		if err := s.sendOTP(user); err != nil {
-			return nil, err
+		// ❌ Device not trusted → second factor required
+		method := activeTwoFAMethod(user)
+
+		// Authenticator app users don't depend on SMTP
+		if method == models.TwoFAMethodEmail {
+			if err := s.sendOTP(user); err != nil {
+				return nil, err
+			}
 		}
 
 		// 🔐 Issue short-lived 2FA token (carry remember intent)
 		twoFAToken, err := utils.Generate2FAToken(
 			user.ID,
 			rememberDevice,
-			s.cfg.JWT.AccessSecret,
+			s.keys,
 		)
 		if err != nil {
 			return nil, err
 		}
 
-		return nil, errors.New("TWO_FA_REQUIRED:" + twoFAToken)
-	}
+		s.recordLoginAttempt(email, user, models.LoginMethodPassword, models.LoginChallenged, nil, ip, userAgent)
 
-	// 5️⃣ Normal login (first login OR 2FA disabled)
-	accessToken, err := utils.GenerateAccessToken(
-		user,
-		s.cfg.JWT.AccessSecret,
-		s.cfg.JWT.AccessExpiry,
-	)
-	if err != nil {
-		return nil, err
+		return &LoginResult{
+			TwoFA: &TwoFAChallenge{Method: method, Token: twoFAToken},
+		}, nil
 	}
 
-	refreshRaw, err := utils.GenerateRefreshToken(
-		s.cfg.JWT.RefreshSecret,
-		s.cfg.JWT.RefreshExpiry,
-	)
+	// 5️⃣ Normal login (first login OR 2FA disabled)
+	tokens, err := s.issueTokens(user, utils.NewAuthContext(utils.AMRPassword), ip, userAgent)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := s.repo.CreateRefreshToken(&models.RefreshToken{
-		UserID:    user.ID,
-		Token:     utils.HashToken(refreshRaw),
-		ExpiresAt: time.Now().Add(s.cfg.JWT.RefreshExpiry),
-	}); err != nil {
-		return nil, err
-	}
-
 	// 6️⃣ First login → enable 2FA & set last login
 	now := time.Now()
 	if user.LastLoginAt == nil {
@@ -172,16 +219,7 @@ func (s *AuthService) Login(
 	log.Println("2FA enabled:", user.TwoFAEnabled)
 	log.Println("Last login:", user.LastLoginAt)
 
-	return &LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshRaw,
-		User: &UserInfo{
-			ID:    user.ID,
-			Name:  user.Name,
-			Email: user.Email,
-			Role:  user.Role,
-		},
-	}, nil
+	return &LoginResult{Tokens: tokens}, nil
 }
 
 /*
@@ -198,44 +236,81 @@ func (s *AuthService) RefreshAccessToken(
 
 	oldHash := utils.HashToken(oldRaw)
 
-	rt, err := s.repo.FindRefreshToken(oldHash)
+	rt, err := s.repo.FindRefreshTokenAny(oldHash)
 	if err != nil {
-		return nil, errors.New("invalid refresh token")
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// 🚨 Already rotated → this token was replayed (stolen copy or
+	// attacker racing the real client). Kill the whole chain.
+	if rt.RotatedAt != nil {
+		s.handleRefreshTokenReuse(rt, ip, userAgent)
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if rt.IsRevoked || time.Now().After(rt.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Deactivated since the session started
+	if !rt.User.IsActive {
+		_, _ = s.repo.RevokeTokenFamily(rt.FamilyID)
+		s.recordLoginAttempt("", &rt.User, models.LoginMethodRefresh, models.LoginFailed, ErrAccountInactive, ip, userAgent)
+		return nil, ErrAccountInactive
 	}
 
 	// 🔒 Revoke old refresh token (rotation)
-	if err := s.repo.RevokeRefreshToken(oldHash); err != nil {
-		return nil, err
+	if err := s.repo.RotateRefreshToken(oldHash); err != nil {
+		// Lost the race against another exchange of the same token
+		s.handleRefreshTokenReuse(rt, ip, userAgent)
+		return nil, ErrInvalidRefreshToken
 	}
 
 	// New access token
+	// Keep the original auth_time / amr: refreshing is not re-authenticating
+	auth := utils.AuthContext{Methods: rt.AuthMethods, Session: rt.FamilyID}
+	if rt.AuthTime != nil {
+		auth.Time = *rt.AuthTime
+	}
+
 	newAccess, err := utils.GenerateAccessToken(
 		&rt.User,
-		s.cfg.JWT.AccessSecret,
+		s.keys,
 		s.cfg.JWT.AccessExpiry,
+		auth,
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	// New refresh token
-	newRefresh, err := utils.GenerateRefreshToken(
-		s.cfg.JWT.RefreshSecret,
-		s.cfg.JWT.RefreshExpiry,
-	)
+	newRefresh, err := utils.GenerateRefreshToken()
 	if err != nil {
 		return nil, err
 	}
 
-	// Store new refresh token hash
+	// Store new refresh token hash (same session → keep its start time)
+	now := time.Now()
 	if err := s.repo.CreateRefreshToken(&models.RefreshToken{
-		UserID:    rt.UserID,
-		Token:     utils.HashToken(newRefresh),
-		ExpiresAt: time.Now().Add(s.cfg.JWT.RefreshExpiry),
+		UserID:      rt.UserID,
+		Token:       utils.HashToken(newRefresh),
+		FamilyID:    rt.FamilyID,
+		AuthTime:    rt.AuthTime,
+		AuthMethods: rt.AuthMethods,
+		ExpiresAt:   now.Add(s.cfg.JWT.RefreshExpiry),
+		UserAgent:   userAgent,
+		IPAddress:   ip,
+		LastUsedAt:  &now,
+		CreatedAt:   rt.CreatedAt,
 	}); err != nil {
 		return nil, err
 	}
 
+	// Same session, different network or client → worth a line in the history
+	if rt.IPAddress != ip || rt.UserAgent != userAgent {
+		s.recordLoginAttempt("", &rt.User, models.LoginMethodRefresh, models.LoginSucceeded, nil, ip, userAgent)
+	}
+
 	return &LoginResponse{
 		AccessToken:  newAccess,
 		RefreshToken: newRefresh,
@@ -247,6 +322,53 @@ func (s *AuthService) RefreshAccessToken(
 	}, nil
 }
 
+// Reuse of a rotated refresh token → revoke every token in its family,
+// record it and tell the user their session may have been stolen.
+func (s *AuthService) handleRefreshTokenReuse(
+	rt *models.RefreshToken,
+	ip string,
+	userAgent string,
+) {
+	revoked, err := s.repo.RevokeTokenFamily(rt.FamilyID)
+	if err != nil {
+		log.Println("refresh token family revoke failed:", err)
+	}
+
+	_ = s.auditRepo.Log(
+		"refresh_token_family",
+		rt.FamilyID,
+		"refresh_token_reuse_detected",
+		rt.UserID,
+		ip,
+		userAgent,
+	)
+
+	s.recordLoginEvent(&models.LoginEvent{
+		UserID:    &rt.UserID,
+		Email:     rt.User.Email,
+		Method:    models.LoginMethodRefresh,
+		Outcome:   models.LoginFailed,
+		Reason:    "refresh_token_reuse",
+		IPAddress: ip,
+		UserAgent: userAgent,
+	})
+
+	// Only mail once per family: later replays find nothing left to revoke
+	if revoked == 0 || s.mailer == nil {
+		return
+	}
+
+	body := fmt.Sprintf(`
+		<h2>Suspicious sign-in activity</h2>
+		<p>An old session token for your account was used again from %s.</p>
+		<p>This usually means the token was copied from one of your devices.
+		   As a precaution we signed that session out everywhere.</p>
+		<p>If you don't recognise this, change your password immediately.</p>
+	`, ip)
+
+	_ = s.mailer.Send(rt.User.Email, "Your session was signed out for security", body)
+}
+
 /*
 =====================
  Logout
@@ -255,11 +377,23 @@ func (s *AuthService) RefreshAccessToken(
 
 func (s *AuthService) Logout(
 	refreshRaw string,
+	accessJTI string, // 👈 current access token, denied right away
+	accessExpiresAt time.Time,
 	userID uuid.UUID,
 	role models.Role,
 	ip string,
 	userAgent string,
 ) error {
+
+	if accessJTI != "" {
+		if err := s.revocations.RevokeToken(accessJTI, userID, accessExpiresAt); err != nil {
+			return err
+		}
+	}
+
+	if refreshRaw == "" {
+		return nil
+	}
 	return s.repo.RevokeRefreshToken(utils.HashToken(refreshRaw))
 }
 
@@ -286,27 +420,14 @@ func (s *AuthService) ChangePassword(
 
 	user, err := s.repo.FindUserByID(userID)
 	if err != nil {
-		return errors.New("user not found")
+		return ErrUserNotFound
 	}
 
 	if err := utils.CheckPassword(oldPassword, user.Password); err != nil {
-		return errors.New("invalid old password")
-	}
-
-	if err := utils.ValidatePasswordStrength(newPassword); err != nil {
-		return err
-	}
-
-	hashed, err := utils.HashPassword(newPassword)
-	if err != nil {
-		return err
+		return ErrInvalidOldPassword
 	}
 
-	if err := s.repo.UpdateUserPassword(
-		userID,
-		hashed,
-		false,
-	); err != nil {
+	if err := s.setPassword(userID, user.Password, newPassword, false); err != nil {
 		return err
 	}
 
@@ -315,7 +436,8 @@ func (s *AuthService) ChangePassword(
 		return err
 	}
 
-	return nil
+	// 🔒 …and every access token issued so far
+	return s.revocations.RevokeUserTokens(userID, time.Now())
 }
 
 func (s *AuthService) GetUserByID(id uuid.UUID) (*models.User, error) {
@@ -353,30 +475,63 @@ func (s *AuthService) ResetPassword(
 
 	reset, err := s.repo.FindValidPasswordReset(hashed)
 	if err != nil {
-		return errors.New("invalid or expired reset link")
+		return ErrInvalidResetToken
 	}
 
-	if err := utils.ValidatePasswordStrength(newPassword); err != nil {
-		return err
+	user, err := s.repo.FindUserByID(reset.UserID)
+	if err != nil {
+		return ErrInvalidResetToken
 	}
 
-	hashedPwd, err := utils.HashPassword(newPassword)
-	if err != nil {
+	// 🔥 mustReset=false clears must_reset_password
+	if err := s.setPassword(user.ID, user.Password, newPassword, false); err != nil {
 		return err
 	}
 
-	if err := s.repo.UpdateUserPassword(
-		reset.UserID,
-		hashedPwd,
-		false, // 🔥 CLEAR must_reset_password
-	); err != nil {
+	// 🔒 Revoke all sessions (refresh + access tokens)
+	_ = s.repo.RevokeAllUserTokens(reset.UserID)
+	_ = s.revocations.RevokeUserTokens(reset.UserID, time.Now())
+
+	if err := s.repo.MarkPasswordResetUsed(reset.ID); err != nil {
 		return err
 	}
 
-	// 🔒 Revoke all sessions
-	_ = s.repo.RevokeAllUserTokens(reset.UserID)
+	// ✉️ The link arrived by email → address verified, invitation accepted
+	_ = s.repo.MarkEmailVerified(reset.UserID)
+	s.invitations.MarkAccepted(reset.UserID)
+
+	return nil
+}
+
+// Creating an account is granting its role: outside admin, the creator's
+// stored role must already hold every permission the new role has
+func (s *AuthService) canGrantRole(creatorID uuid.UUID, role models.Role) error {
+	creator, err := s.repo.FindUserByID(creatorID)
+	if err != nil || !creator.IsActive {
+		return ErrForbidden
+	}
+	if creator.Role == models.RoleAdmin {
+		return nil
+	}
+	if role == models.RoleAdmin {
+		return ErrForbidden
+	}
+
+	granted, err := s.repo.RolePermissions(role)
+	if err != nil {
+		return err
+	}
+	held, err := s.repo.RolePermissions(creator.Role)
+	if err != nil {
+		return ErrForbidden
+	}
 
-	return s.repo.MarkPasswordResetUsed(reset.ID)
+	for _, p := range granted {
+		if !slices.Contains(held, p) {
+			return fmt.Errorf("%w: role %s grants %s", ErrForbidden, role, p)
+		}
+	}
+	return nil
 }
 
 func (s *AuthService) CreateUser(
@@ -390,26 +545,46 @@ func (s *AuthService) CreateUser(
 	company string,
 	phone string,
 	address string,
-) (*models.User, error) {
+
+	// Customers only: nil founds a new organization with the user as its
+	// customer-admin; otherwise the user joins an existing one
+	seat *OrganizationSeat,
+) (*models.User, *InvitationInfo, error) {
+
+	// 0️⃣ Role must be defined and within the creator's reach
+	exists, err := s.repo.RoleExists(role)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !exists {
+		return nil, nil, fmt.Errorf("%w: unknown role %s", ErrInvalidRole, role)
+	}
+	if err := s.canGrantRole(createdBy, role); err != nil {
+		return nil, nil, err
+	}
 
 	// 1️⃣ Check existing user
 	existing, _ := s.repo.FindUserByEmail(email)
 	if existing != nil {
-		return nil, errors.New("user already exists")
+		return nil, nil, errors.New("user already exists")
 	}
 
 	// 2️⃣ Generate temp password
 	tempPassword, err := utils.GenerateRandomToken(16)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	hashed, err := utils.HashPassword(tempPassword)
+	hashed, err := s.passwords.Hash(tempPassword)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	var createdUser *models.User
+	var (
+		createdUser *models.User
+		invitation  *models.Invitation
+		rawToken    string
+	)
 
 	// 3️⃣ TRANSACTION START
 	err = s.db.Transaction(func(tx *gorm.DB) error {
@@ -429,61 +604,36 @@ func (s *AuthService) CreateUser(
 			return err
 		}
 
-		// ✅ Create customer profile ONLY for customers
+		// ✅ Customer organization + membership ONLY for customers
 		if role == models.RoleCustomer {
-			customer := &models.Customer{
-				UserID:   user.ID,
-				Company:  company,
-				Phone:    phone,
-				Address:  address,
-				IsActive: true,
-			}
-
-			if err := s.customerRepo.Create(tx, customer); err != nil {
+			if err := s.seatCustomer(tx, user, seat, createdBy, company, phone, address); err != nil {
 				return err
 			}
 		}
 
+		// ✅ Invitation + set-password token (TX SAFE)
+		inv, raw, err := s.invitations.CreateTx(tx, user, createdBy)
+		if err != nil {
+			return err
+		}
+
 		createdUser = user
+		invitation = inv
+		rawToken = raw
 		return nil
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	// 🔚 TRANSACTION END
 
-	// 4️⃣ Create password reset token
-	resetToken, err := s.CreatePasswordReset(createdUser)
-	if err != nil {
-		return nil, err
-	}
-
-	resetURL := s.cfg.FrontendURL + "/reset-password?token=" + resetToken
-
-	// 5️⃣ Send email
-	if s.mailer == nil {
-		return nil, errors.New("email service not configured")
-	}
+	// 4️⃣ Send the invite; a mail failure is recorded, the account stays
+	s.invitations.Deliver(invitation, createdUser, rawToken)
 
-	body := `
-		<h2>Welcome to RBAC App</h2>
-		<p>Your account has been created.</p>
-		<p>
-			<a href="` + resetURL + `">Click here to set your password</a>
-		</p>
-		<p>This link expires in 24 hours.</p>
-	`
-
-	if err := s.mailer.Send(
-		createdUser.Email,
-		"Set your password",
-		body,
-	); err != nil {
-		return nil, err
-	}
+	_ = s.auditRepo.Log("user", createdUser.ID, "user_invited", createdBy, ip, userAgent)
 
-	return createdUser, nil
+	return createdUser, toInvitationInfo(invitation, createdUser), nil
 }
 
 func (s *AuthService) SendPasswordResetEmail(email string) error {
@@ -511,7 +661,7 @@ func (s *AuthService) SendPasswordResetEmail(email string) error {
 	`
 
 	if s.mailer == nil {
-		return errors.New("email service not configured")
+		return ErrMailerUnavailable
 	}
 
 	return s.mailer.Send(
@@ -522,6 +672,10 @@ func (s *AuthService) SendPasswordResetEmail(email string) error {
 }
 func (s *AuthService) sendOTP(user *models.User) error {
 
+	if s.mailer == nil {
+		return ErrMailerUnavailable
+	}
+
 	code, err := generateOTP()
 	if err != nil {
 		return err
@@ -553,32 +707,56 @@ func (s *AuthService) sendOTP(user *models.User) error {
 func (s *AuthService) Verify2FA(
 	userID uuid.UUID,
 	code string,
+	recoveryCode string, // 👈 alternative when the factor is lost
 	remember bool, // 👈 from 2FA JWT
 	ip string,
 	userAgent string,
 ) (*LoginResponse, *string, error) {
 
-	// 1️⃣ Verify OTP
-	hashedOTP := utils.HashToken(code)
-
-	otp, err := s.repo.FindValid2FAOTP(userID, hashedOTP)
+	// 1️⃣ Load user
+	user, err := s.repo.FindUserByID(userID)
 	if err != nil {
-		return nil, nil, errors.New("invalid or expired otp")
+		return nil, nil, ErrUserNotFound
 	}
 
-	_ = s.repo.MarkOTPUsed(otp.ID)
+	if err := s.ensureTwoFAAllowed(user.ID); err != nil {
+		s.recordLoginAttempt("", user, models.LoginMethodTwoFA, models.LoginLocked, err, ip, userAgent)
+		return nil, nil, err
+	}
 
-	// 2️⃣ Load user
-	user, err := s.repo.FindUserByID(userID)
-	if err != nil {
-		return nil, nil, errors.New("user not found")
+	// 2️⃣ Verify TOTP / email OTP, or a one-time recovery code
+	if recoveryCode != "" {
+		if err := s.useRecoveryCode(user, recoveryCode, ip, userAgent); err != nil {
+			s.recordTwoFAFailure(user, ip, userAgent)
+			s.recordLoginAttempt("", user, models.LoginMethodTwoFA, models.LoginFailed, err, ip, userAgent)
+			return nil, nil, err
+		}
+	} else if !s.verifySecondFactor(user, code) {
+		s.recordTwoFAFailure(user, ip, userAgent)
+		s.recordLoginAttempt("", user, models.LoginMethodTwoFA, models.LoginFailed, ErrInvalidOTP, ip, userAgent)
+		return nil, nil, ErrInvalidOTP
 	}
 
+	_ = s.throttleRepo.Reset(models.ThrottleScopeTwoFA, user.ID.String())
+
+	auth := utils.NewAuthContext(utils.AMRPassword, utils.AMROTP, utils.AMRMultiFactor)
+	return s.completeTwoFALogin(user, remember, auth, ip, userAgent)
+}
+
+// Shared tail of every successful second factor (OTP, TOTP, passkey)
+func (s *AuthService) completeTwoFALogin(
+	user *models.User,
+	remember bool,
+	auth utils.AuthContext,
+	ip string,
+	userAgent string,
+) (*LoginResponse, *string, error) {
+
 	// 3️⃣ Update last login
 	now := time.Now()
 	_ = s.repo.UpdateLastLogin(user.ID, &now)
 
-	// 4️⃣ Remember device (ONLY after OTP success)
+	// 4️⃣ Remember device (ONLY after 2FA success)
 	var rememberDeviceToken *string
 
 	if remember {
@@ -599,7 +777,7 @@ func (s *AuthService) Verify2FA(
 	}
 
 	// 5️⃣ Issue access + refresh tokens
-	resp, err := s.issueTokens(user)
+	resp, err := s.issueTokens(user, auth, ip, userAgent)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -607,33 +785,50 @@ func (s *AuthService) Verify2FA(
 	return resp, rememberDeviceToken, nil
 }
 
-func (s *AuthService) issueTokens(user *models.User) (*LoginResponse, error) {
+func (s *AuthService) issueTokens(
+	user *models.User,
+	auth utils.AuthContext,
+	ip string,
+	userAgent string,
+) (*LoginResponse, error) {
+
+	// New login → new rotation chain; access tokens name it as their session
+	auth.Session = uuid.New()
 
 	accessToken, err := utils.GenerateAccessToken(
 		user,
-		s.cfg.JWT.AccessSecret,
+		s.keys,
 		s.cfg.JWT.AccessExpiry,
+		auth,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshRaw, err := utils.GenerateRefreshToken(
-		s.cfg.JWT.RefreshSecret,
-		s.cfg.JWT.RefreshExpiry,
-	)
+	refreshRaw, err := utils.GenerateRefreshToken()
 	if err != nil {
 		return nil, err
 	}
 
 	if err := s.repo.CreateRefreshToken(&models.RefreshToken{
-		UserID:    user.ID,
-		Token:     utils.HashToken(refreshRaw),
-		ExpiresAt: time.Now().Add(s.cfg.JWT.RefreshExpiry),
+		UserID:      user.ID,
+		Token:       utils.HashToken(refreshRaw),
+		FamilyID:    auth.Session,
+		AuthTime:    &auth.Time,
+		AuthMethods: auth.Methods,
+		ExpiresAt:   time.Now().Add(s.cfg.JWT.RefreshExpiry),
+		UserAgent:   userAgent,
+		IPAddress:   ip,
 	}); err != nil {
 		return nil, err
 	}
 
+	s.recordLoginSuccess(user, auth, ip, userAgent)
+
+	// Signed in some other way (SSO, passkey) than the invite link: the
+	// account is in use, so the invitation no longer is pending
+	s.invitations.MarkAccepted(user.ID)
+
 	return &LoginResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshRaw,
@@ -645,12 +840,319 @@ func (s *AuthService) issueTokens(user *models.User) (*LoginResponse, error) {
 		},
 	}, nil
 }
-func (s *AuthService) Enable2FA(userID uuid.UUID) error {
-	return s.repo.Enable2FA(userID)
+
+// Accepts a TOTP code (if enrolled) or an emailed OTP
+func (s *AuthService) verifySecondFactor(user *models.User, code string) bool {
+
+	if user.TOTPConfirmedAt != nil && user.TOTPSecret != "" {
+		secret, err := utils.DecryptSecret(user.TOTPSecret, s.cfg.TwoFA.EncryptionKey)
+		if err == nil {
+			if step, ok := utils.ValidateTOTP(secret, code, time.Now()); ok {
+				// 🔒 One code per time step (no replay)
+				fresh, err := s.repo.ConsumeTOTPStep(user.ID, step)
+				if err == nil && fresh {
+					return true
+				}
+			}
+		}
+	}
+
+	otp, err := s.repo.FindValid2FAOTP(user.ID, utils.HashToken(code))
+	if err != nil {
+		return false
+	}
+
+	_ = s.repo.MarkOTPUsed(otp.ID)
+	return true
+}
+
+func activeTwoFAMethod(user *models.User) models.TwoFAMethod {
+	switch {
+	case user.TwoFAMethod == models.TwoFAMethodTOTP && user.TOTPConfirmedAt != nil:
+		return models.TwoFAMethodTOTP
+	case user.TwoFAMethod == models.TwoFAMethodWebAuthn:
+		return models.TwoFAMethodWebAuthn
+	}
+	return models.TwoFAMethodEmail
+}
+
+// Email fallback while a 2FA session is pending (e.g. phone not at hand)
+func (s *AuthService) SendEmailOTP(userID uuid.UUID) error {
+	user, err := s.repo.FindUserByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	return s.sendOTP(user)
+}
+
+/*
+=====================
+ 2FA Management
+=====================
+*/
+
+func (s *AuthService) SetupTOTP(
+	userID uuid.UUID,
+	ip string,
+	userAgent string,
+) (*TOTPSetup, error) {
+
+	user, err := s.repo.FindUserByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := utils.EncryptSecret(secret, s.cfg.TwoFA.EncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pending until the first code is confirmed; any current
+	// authenticator keeps working until then
+	if err := s.repo.SetPendingTOTPSecret(user.ID, encrypted); err != nil {
+		return nil, err
+	}
+
+	_ = s.auditRepo.Log("user", user.ID, "totp_setup_started", user.ID, ip, userAgent)
+
+	return &TOTPSetup{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(s.cfg.TwoFA.Issuer, user.Email, secret),
+	}, nil
+}
+
+func (s *AuthService) ConfirmTOTP(
+	userID uuid.UUID,
+	code string,
+	ip string,
+	userAgent string,
+) ([]string, error) {
+
+	user, err := s.repo.FindUserByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if user.TOTPPendingSecret == "" {
+		return nil, errors.New("authenticator setup not started")
+	}
+
+	secret, err := utils.DecryptSecret(user.TOTPPendingSecret, s.cfg.TwoFA.EncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, errors.New("invalid authenticator code")
+	}
+
+	confirmed, err := s.repo.ConfirmTOTP(user.ID, user.TOTPPendingSecret, step)
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		return nil, errors.New("authenticator setup was restarted, scan the new code")
+	}
+
+	_ = s.auditRepo.Log("user", user.ID, "totp_enrolled", user.ID, ip, userAgent)
+
+	return s.initialRecoveryCodes(user.ID)
 }
 
-func (s *AuthService) Disable2FA(userID uuid.UUID) error {
-	return s.repo.Disable2FA(userID)
+func (s *AuthService) Enable2FA(
+	userID uuid.UUID,
+	method models.TwoFAMethod,
+	ip string,
+	userAgent string,
+) ([]string, error) {
+
+	if method == "" {
+		method = models.TwoFAMethodEmail
+	}
+
+	switch method {
+	case models.TwoFAMethodTOTP:
+		user, err := s.repo.FindUserByID(userID)
+		if err != nil {
+			return nil, ErrUserNotFound
+		}
+		if user.TOTPConfirmedAt == nil {
+			return nil, errors.New("authenticator app not enrolled")
+		}
+
+	case models.TwoFAMethodWebAuthn:
+		count, err := s.webauthnRepo.CountByUser(userID)
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, errors.New("no passkey registered")
+		}
+	}
+
+	if err := s.repo.SetTwoFAMethod(userID, method); err != nil {
+		return nil, err
+	}
+
+	_ = s.auditRepo.Log("user", userID, "2fa_enabled:"+string(method), userID, ip, userAgent)
+
+	// First time 2FA is on → hand out recovery codes (shown once)
+	return s.initialRecoveryCodes(userID)
+}
+
+// method = totp → remove authenticator, fall back to email OTP
+// method = ""   → turn 2FA off entirely
+func (s *AuthService) Disable2FA(
+	userID uuid.UUID,
+	method models.TwoFAMethod,
+	ip string,
+	userAgent string,
+) error {
+
+	if method == models.TwoFAMethodTOTP {
+		if err := s.repo.ClearTOTP(userID); err != nil {
+			return err
+		}
+
+		_ = s.auditRepo.Log("user", userID, "totp_removed", userID, ip, userAgent)
+		return nil
+	}
+
+	if err := s.repo.Disable2FA(userID); err != nil {
+		return err
+	}
+
+	// Codes are meaningless without 2FA
+	_ = s.repo.DeleteRecoveryCodes(userID)
+
+	_ = s.auditRepo.Log("user", userID, "2fa_disabled", userID, ip, userAgent)
+
+	return nil
+}
+
+/*
+=====================
+ 2FA Recovery Codes
+=====================
+*/
+
+const (
+	recoveryCodeCount    = 10
+	recoveryCodesLowMark = 2
+)
+
+func (s *AuthService) generateRecoveryCodes(userID uuid.UUID) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := utils.GenerateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Keeps any existing set; only creates one if the user has none left
+func (s *AuthService) initialRecoveryCodes(userID uuid.UUID) ([]string, error) {
+	remaining, err := s.repo.CountUnusedRecoveryCodes(userID)
+	if err != nil || remaining > 0 {
+		return nil, err
+	}
+
+	return s.generateRecoveryCodes(userID)
+}
+
+func (s *AuthService) RegenerateRecoveryCodes(
+	userID uuid.UUID,
+	ip string,
+	userAgent string,
+) ([]string, error) {
+
+	user, err := s.repo.FindUserByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if !user.TwoFAEnabled {
+		return nil, ErrTwoFANotEnabled
+	}
+
+	codes, err := s.generateRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.auditRepo.Log("user", user.ID, "recovery_codes_regenerated", user.ID, ip, userAgent)
+
+	return codes, nil
+}
+
+func (s *AuthService) CountRecoveryCodes(userID uuid.UUID) (int64, error) {
+	return s.repo.CountUnusedRecoveryCodes(userID)
+}
+
+func (s *AuthService) useRecoveryCode(
+	user *models.User,
+	code string,
+	ip string,
+	userAgent string,
+) error {
+
+	hashed := utils.HashToken(utils.NormalizeRecoveryCode(code))
+
+	ok, err := s.repo.UseRecoveryCode(user.ID, hashed)
+	if err != nil || !ok {
+		return ErrInvalidRecoveryCode
+	}
+
+	_ = s.auditRepo.Log("user", user.ID, "recovery_code_used", user.ID, ip, userAgent)
+
+	remaining, _ := s.repo.CountUnusedRecoveryCodes(user.ID)
+	s.notifyRecoveryCodeUsed(user, remaining, ip)
+
+	return nil
+}
+
+func (s *AuthService) notifyRecoveryCodeUsed(
+	user *models.User,
+	remaining int64,
+	ip string,
+) {
+	if s.mailer == nil {
+		return
+	}
+
+	warning := ""
+	if remaining <= recoveryCodesLowMark {
+		warning = `<p><b>You are running out of recovery codes.</b>
+			Generate a new set from your profile.</p>`
+	}
+
+	body := fmt.Sprintf(`
+		<h2>Recovery code used</h2>
+		<p>A recovery code was used to sign in to your account from %s.</p>
+		<p>Recovery codes remaining: <b>%d</b></p>
+		%s
+		<p>If this wasn't you, reset your password immediately.</p>
+	`, ip, remaining, warning)
+
+	_ = s.mailer.Send(user.Email, "A recovery code was used", body)
 }
 
 func generateOTP() (string, error) {
@@ -665,7 +1167,8 @@ func generateOTP() (string, error) {
    Admin: Get Users (Paginated)
 ===================== */
 
-func (s *AuthService) GetUsersPaginated(page int) ([]*GetuserInfo, int64, error) {
+// unverifiedOnly narrows the list to accounts whose email isn't confirmed
+func (s *AuthService) GetUsersPaginated(page int, unverifiedOnly bool) ([]*GetuserInfo, int64, error) {
 	const pageSize = 3
 
 	if page < 1 {
@@ -674,7 +1177,7 @@ func (s *AuthService) GetUsersPaginated(page int) ([]*GetuserInfo, int64, error)
 
 	offset := (page - 1) * pageSize
 
-	users, total, err := s.repo.GetUsersPaginated(pageSize, offset)
+	users, total, err := s.repo.GetUsersPaginated(pageSize, offset, unverifiedOnly)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -688,6 +1191,9 @@ func (s *AuthService) GetUsersPaginated(page int) ([]*GetuserInfo, int64, error)
 			Role:      u.Role,
 			CreatedAt: u.CreatedAt,
 			IsActive:  u.IsActive,
+
+			EmailVerified:   u.EmailVerifiedAt != nil,
+			EmailVerifiedAt: u.EmailVerifiedAt,
 		})
 	}
 
@@ -709,6 +1215,9 @@ func (s *AuthService) GetUsersByRole(role models.Role) ([]*GetuserInfo, error) {
 			Role:      u.Role,
 			CreatedAt: u.CreatedAt,
 			IsActive:  u.IsActive,
+
+			EmailVerified:   u.EmailVerifiedAt != nil,
+			EmailVerifiedAt: u.EmailVerifiedAt,
 		})
 	}
 	return result, nil
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"rbac/config"
+	"rbac/models"
+	"rbac/repository"
+	"rbac/testutil"
+	"rbac/utils"
+)
+
+func newTestAuthService(t *testing.T) (*AuthService, *gorm.DB, *config.Config) {
+	t.Helper()
+
+	db := testutil.NewDB(t)
+	cfg := testutil.Config()
+
+	keys, err := utils.LoadKeyRing("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	passwords, err := utils.NewPasswordPolicy(cfg.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auditRepo := repository.NewAuditRepository(db)
+	svc := NewAuthService(
+		db,
+		repository.NewAuthRepository(db),
+		repository.NewRememberedDeviceRepo(db),
+		repository.NewCustomerRepository(db),
+		auditRepo,
+		repository.NewWebAuthnRepository(db),
+		repository.NewLoginThrottleRepository(db),
+		repository.NewLoginEventRepository(db),
+		keys,
+		repository.NewPostgresTokenRevocationStore(db),
+		NewInvitationService(db, repository.NewInvitationRepository(db), auditRepo, cfg),
+		passwords,
+		cfg,
+	)
+	return svc, db, cfg
+}
+
+func storedTOTPSecret(t *testing.T, db *gorm.DB, cfg *config.Config, userID any) (string, *models.User) {
+	t.Helper()
+
+	var user models.User
+	if err := db.First(&user, "id = ?", userID).Error; err != nil {
+		t.Fatal(err)
+	}
+	secret, err := utils.DecryptSecret(user.TOTPSecret, cfg.TwoFA.EncryptionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return secret, &user
+}
+
+// Starting a new setup must not unseat the authenticator in use; the
+// new secret takes over only when a code from it is confirmed
+func TestSetupTOTPKeepsCurrentAuthenticatorUntilConfirmed(t *testing.T) {
+	auth, db, cfg := newTestAuthService(t)
+	user := testutil.User(t, db, models.RoleSupport)
+
+	first, err := auth.SetupTOTP(user.ID, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.ConfirmTOTP(user.ID, testutil.TOTPCode(t, first.Secret, time.Now()), "", ""); err != nil {
+		t.Fatalf("confirm first: %v", err)
+	}
+
+	second, err := auth.SetupTOTP(user.ID, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, stored := storedTOTPSecret(t, db, cfg, user.ID)
+	if secret != first.Secret || stored.TOTPConfirmedAt == nil || stored.TwoFAMethod != models.TwoFAMethodTOTP {
+		t.Fatal("pending setup replaced the confirmed authenticator")
+	}
+
+	if _, err := auth.ConfirmTOTP(user.ID, testutil.TOTPCode(t, first.Secret, time.Now().Add(-30*time.Second)), "", ""); err == nil {
+		t.Fatal("code from the old authenticator confirmed the new one")
+	}
+
+	if _, err := auth.ConfirmTOTP(user.ID, testutil.TOTPCode(t, second.Secret, time.Now()), "", ""); err != nil {
+		t.Fatalf("confirm second: %v", err)
+	}
+	if secret, stored := storedTOTPSecret(t, db, cfg, user.ID); secret != second.Secret || stored.TOTPPendingSecret != "" {
+		t.Fatal("confirmed setup was not swapped in")
+	}
+}
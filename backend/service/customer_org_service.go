@@ -0,0 +1,314 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/models"
+	"rbac/repository"
+)
+
+/*
+=====================
+ Customer Organizations
+=====================
+ A Customer is a client company with many users (CustomerMember).
+ Customer-admins invite colleagues and decide whether each one sees
+ every company ticket or only the tickets they raised.
+*/
+
+type CustomerOrgService struct {
+	repo      *repository.CustomerRepository
+	auth      *AuthService
+	auditRepo *repository.AuditRepository
+}
+
+func NewCustomerOrgService(
+	repo *repository.CustomerRepository,
+	auth *AuthService,
+	auditRepo *repository.AuditRepository,
+) *CustomerOrgService {
+	return &CustomerOrgService{
+		repo:      repo,
+		auth:      auth,
+		auditRepo: auditRepo,
+	}
+}
+
+/*
+=====================
+ Response DTOs
+=====================
+*/
+
+type OrganizationInfo struct {
+	ID      uuid.UUID   `json:"id"`
+	Company string      `json:"company"`
+	Phone   string      `json:"phone"`
+	Address string      `json:"address"`
+	Me      *MemberInfo `json:"me"`
+}
+
+type MemberInfo struct {
+	UserID           uuid.UUID               `json:"user_id"`
+	Name             string                  `json:"name,omitempty"`
+	Email            string                  `json:"email,omitempty"`
+	IsActive         bool                    `json:"is_active"`
+	IsAdmin          bool                    `json:"is_admin"`
+	TicketVisibility models.TicketVisibility `json:"ticket_visibility"`
+	JoinedAt         time.Time               `json:"joined_at"`
+}
+
+func toMemberInfo(m *models.CustomerMember) *MemberInfo {
+	return &MemberInfo{
+		UserID:           m.UserID,
+		Name:             m.User.Name,
+		Email:            m.User.Email,
+		IsActive:         m.User.IsActive,
+		IsAdmin:          m.IsAdmin,
+		TicketVisibility: m.TicketVisibility,
+		JoinedAt:         m.CreatedAt,
+	}
+}
+
+// Where a new customer user lands (see AuthService.CreateUser)
+type OrganizationSeat struct {
+	CustomerID       uuid.UUID
+	IsAdmin          bool
+	TicketVisibility models.TicketVisibility
+}
+
+/*
+=====================
+ Startup
+=====================
+*/
+
+// Customers that predate organizations get their founder as customer-admin
+func (s *CustomerOrgService) EnsureFounderMemberships() error {
+	return s.repo.EnsureFounderMemberships()
+}
+
+/*
+=====================
+ Members
+=====================
+*/
+
+func (s *CustomerOrgService) Organization(userID uuid.UUID) (*OrganizationInfo, error) {
+	me, err := s.membership(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OrganizationInfo{
+		ID:      me.Customer.ID,
+		Company: me.Customer.Company,
+		Phone:   me.Customer.Phone,
+		Address: me.Customer.Address,
+		Me: &MemberInfo{
+			UserID:           me.UserID,
+			IsActive:         true,
+			IsAdmin:          me.IsAdmin,
+			TicketVisibility: me.TicketVisibility,
+			JoinedAt:         me.CreatedAt,
+		},
+	}, nil
+}
+
+// Customer-admin only
+func (s *CustomerOrgService) ListMembers(actorID uuid.UUID) ([]*MemberInfo, error) {
+	me, err := s.requireCustomerAdmin(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.repo.ListMembers(me.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*MemberInfo, 0, len(members))
+	for i := range members {
+		result = append(result, toMemberInfo(&members[i]))
+	}
+	return result, nil
+}
+
+// Customer-admin only: a colleague gets an account + invitation email
+func (s *CustomerOrgService) InviteMember(
+	actorID uuid.UUID,
+	name string,
+	email string,
+	visibility models.TicketVisibility,
+	isAdmin bool,
+	ip string,
+	userAgent string,
+) (*models.User, *InvitationInfo, error) {
+
+	me, err := s.requireCustomerAdmin(actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if visibility == "" {
+		visibility = models.TicketVisibilityOwn
+	}
+	if !validTicketVisibility(visibility) {
+		return nil, nil, errors.New("ticket_visibility must be own or all")
+	}
+
+	return s.auth.CreateUser(
+		name,
+		email,
+		models.RoleCustomer,
+		actorID,
+		ip,
+		userAgent,
+
+		"",
+		"",
+		"",
+
+		&OrganizationSeat{
+			CustomerID:       me.CustomerID,
+			IsAdmin:          isAdmin,
+			TicketVisibility: visibility,
+		},
+	)
+}
+
+// Customer-admin only; nil fields are left alone
+func (s *CustomerOrgService) UpdateMember(
+	actorID uuid.UUID,
+	userID uuid.UUID,
+	visibility *models.TicketVisibility,
+	isAdmin *bool,
+	ip string,
+	userAgent string,
+) error {
+
+	me, err := s.requireCustomerAdmin(actorID)
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]interface{}{}
+	if visibility != nil {
+		if !validTicketVisibility(*visibility) {
+			return errors.New("ticket_visibility must be own or all")
+		}
+		fields["ticket_visibility"] = *visibility
+	}
+
+	if isAdmin != nil {
+		// Someone has to be able to manage the organization
+		if !*isAdmin && userID == actorID {
+			admins, err := s.repo.CountAdmins(me.CustomerID)
+			if err != nil {
+				return err
+			}
+			if admins <= 1 {
+				return ErrLastAdmin
+			}
+		}
+		fields["is_admin"] = *isAdmin
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	updated, err := s.repo.UpdateMember(me.CustomerID, userID, fields)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrUserNotFound
+	}
+
+	_ = s.auditRepo.Log("user", userID, "customer_member_updated", actorID, ip, userAgent)
+
+	return nil
+}
+
+/*
+=====================
+ Helpers
+=====================
+*/
+
+func (s *CustomerOrgService) membership(userID uuid.UUID) (*models.CustomerMember, error) {
+	member, err := s.repo.FindMembership(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrResourceNotFound
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+func (s *CustomerOrgService) requireCustomerAdmin(userID uuid.UUID) (*models.CustomerMember, error) {
+	member, err := s.membership(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !member.IsAdmin {
+		return nil, ErrForbidden
+	}
+	return member, nil
+}
+
+func validTicketVisibility(v models.TicketVisibility) bool {
+	return v == models.TicketVisibilityOwn || v == models.TicketVisibilityAll
+}
+
+// Inside CreateUser's transaction: found a new organization or take a
+// seat in an existing one
+func (s *AuthService) seatCustomer(
+	tx *gorm.DB,
+	user *models.User,
+	seat *OrganizationSeat,
+	invitedBy uuid.UUID,
+	company string,
+	phone string,
+	address string,
+) error {
+
+	if seat == nil {
+		customer := &models.Customer{
+			UserID:   user.ID,
+			Company:  company,
+			Phone:    phone,
+			Address:  address,
+			IsActive: true,
+		}
+		if err := s.customerRepo.Create(tx, customer); err != nil {
+			return err
+		}
+
+		seat = &OrganizationSeat{
+			CustomerID:       customer.ID,
+			IsAdmin:          true,
+			TicketVisibility: models.TicketVisibilityAll,
+		}
+	} else if _, err := s.customerRepo.FindByID(seat.CustomerID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: customer organization", ErrResourceNotFound)
+		}
+		return err
+	}
+
+	return s.customerRepo.CreateMember(tx, &models.CustomerMember{
+		CustomerID:       seat.CustomerID,
+		UserID:           user.ID,
+		IsAdmin:          seat.IsAdmin,
+		TicketVisibility: seat.TicketVisibility,
+		InvitedBy:        &invitedBy,
+	})
+}
@@ -75,3 +75,17 @@ func (s *CustomerProductService) GetCustomerProductsByUserID(userID uuid.UUID) (
 	}
 	return response, nil
 }
+
+// Access is decided by the route policy (owner, company, product.read)
+func (s *CustomerProductService) GetByID(id uuid.UUID) (*CustomerProductResponse, error) {
+	p, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CustomerProductResponse{
+		ID:          p.ID,
+		ProductID:   p.ProductID,
+		ProductName: p.Product.Name,
+	}, nil
+}
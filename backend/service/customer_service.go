@@ -1,11 +1,14 @@
 package service
 
 import (
+	"errors"
+
 	"rbac/models"
 	"rbac/repository"
 
+	"rbac/utils"
+
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
@@ -14,6 +17,7 @@ type CustomerService struct {
 	authRepo     *repository.AuthRepository
 	customerRepo *repository.CustomerRepository
 	ticketRepo   *repository.TicketRepository
+	passwords    *utils.PasswordPolicy
 }
 
 func NewCustomerService(
@@ -21,12 +25,14 @@ func NewCustomerService(
 	authRepo *repository.AuthRepository,
 	customerRepo *repository.CustomerRepository,
 	ticketRepo *repository.TicketRepository,
+	passwords *utils.PasswordPolicy,
 ) *CustomerService {
 	return &CustomerService{
 		db:           db,
 		authRepo:     authRepo,
 		customerRepo: customerRepo,
 		ticketRepo:   ticketRepo,
+		passwords:    passwords,
 	}
 }
 func (s *CustomerService) CreateCustomer(
@@ -38,20 +44,21 @@ func (s *CustomerService) CreateCustomer(
 	address string,
 ) error {
 
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	if err := s.passwords.Validate(password); err != nil {
+		return weakPassword(err)
+	}
 
-		hash, err := bcrypt.GenerateFromPassword(
-			[]byte(password),
-			bcrypt.DefaultCost,
-		)
-		if err != nil {
-			return err
-		}
+	hash, err := s.passwords.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
 
 		user := &models.User{
 			Name:     name,
 			Email:    email,
-			Password: string(hash),
+			Password: hash,
 			Role:     models.RoleCustomer,
 			IsActive: true,
 		}
@@ -69,7 +76,17 @@ func (s *CustomerService) CreateCustomer(
 			IsActive: true,
 		}
 
-		return s.customerRepo.Create(tx, customer)
+		if err := s.customerRepo.Create(tx, customer); err != nil {
+			return err
+		}
+
+		// Self-registered customers found their organization
+		return s.customerRepo.CreateMember(tx, &models.CustomerMember{
+			CustomerID:       customer.ID,
+			UserID:           user.ID,
+			IsAdmin:          true,
+			TicketVisibility: models.TicketVisibilityAll,
+		})
 	})
 }
 func (s *CustomerService) GetAllCustomers(
@@ -83,8 +100,18 @@ func (s *CustomerService) GetAllCustomers(
 
 	return s.customerRepo.GetAllPaginated(page, limit)
 }
+// Own tickets, or the whole organization's when the member may see them
 func (s *CustomerService) GetCustomerTickets(
-	customerID uuid.UUID,
+	userID uuid.UUID,
 ) ([]models.Ticket, error) {
-	return s.ticketRepo.FindByCustomer(customerID)
+
+	member, err := s.customerRepo.FindMembership(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if member != nil && member.SeesAllTickets() {
+		return s.ticketRepo.FindByOrganization(member.CustomerID)
+	}
+	return s.ticketRepo.FindByCustomer(userID)
 }
@@ -0,0 +1,528 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/config"
+	"rbac/models"
+	"rbac/repository"
+	"rbac/utils"
+)
+
+/*
+=====================
+ Break-glass Elevation
+=====================
+ A staff user asks for a role (usually admin) for up to an hour with a
+ reason; another admin approves and the clock starts. The user then
+ fetches short-lived elevated tokens; AuthMiddleware refuses them the
+ moment the grant expires, is ended or revoked. Admins are mailed when
+ an elevation starts and ends.
+*/
+
+type ElevationService struct {
+	repo      *repository.ElevationRepository
+	roles     *RoleService
+	auditRepo *repository.AuditRepository
+	mailer    *utils.Mailer
+	keys      *utils.KeyRing
+	cfg       *config.Config
+}
+
+func NewElevationService(
+	repo *repository.ElevationRepository,
+	roles *RoleService,
+	auditRepo *repository.AuditRepository,
+	keys *utils.KeyRing,
+	cfg *config.Config,
+) *ElevationService {
+	return &ElevationService{
+		repo:      repo,
+		roles:     roles,
+		auditRepo: auditRepo,
+		mailer:    utils.NewMailer(cfg.Mail),
+		keys:      keys,
+		cfg:       cfg,
+	}
+}
+
+/*
+=====================
+ Response DTOs
+=====================
+*/
+
+type ElevationInfo struct {
+	ID        uuid.UUID              `json:"id"`
+	UserID    uuid.UUID              `json:"user_id"`
+	UserEmail string                 `json:"user_email,omitempty"`
+	BaseRole  models.Role            `json:"base_role"`
+	Role      models.Role            `json:"role"`
+	Reason    string                 `json:"reason"`
+	Duration  int                    `json:"duration_minutes"`
+	Status    models.ElevationStatus `json:"status"`
+	DecidedBy *uuid.UUID             `json:"decided_by,omitempty"`
+	DecidedAt *time.Time             `json:"decided_at,omitempty"`
+	ExpiresAt *time.Time             `json:"expires_at,omitempty"`
+	EndedAt   *time.Time             `json:"ended_at,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+type ElevatedTokenResponse struct {
+	AccessToken string      `json:"access_token"`
+	ExpiresAt   time.Time   `json:"expires_at"` // token; re-fetch while the grant lasts
+	GrantEndsAt time.Time   `json:"grant_expires_at"`
+	Role        models.Role `json:"role"`
+}
+
+func toElevationInfo(g *models.ElevationGrant) *ElevationInfo {
+	return &ElevationInfo{
+		ID:        g.ID,
+		UserID:    g.UserID,
+		UserEmail: g.User.Email,
+		BaseRole:  g.BaseRole,
+		Role:      g.Role,
+		Reason:    g.Reason,
+		Duration:  g.Duration,
+		Status:    g.Status,
+		DecidedBy: g.DecidedBy,
+		DecidedAt: g.DecidedAt,
+		ExpiresAt: g.ExpiresAt,
+		EndedAt:   g.EndedAt,
+		CreatedAt: g.CreatedAt,
+	}
+}
+
+/*
+=====================
+ Requester
+=====================
+*/
+
+// minutes 0 = the configured maximum
+func (s *ElevationService) Request(
+	userID uuid.UUID,
+	role models.Role,
+	reason string,
+	minutes int,
+	ip string,
+	userAgent string,
+) (*ElevationInfo, error) {
+
+	user, err := s.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == models.RoleCustomer {
+		return nil, ErrForbidden
+	}
+
+	if role == "" {
+		role = models.RoleAdmin
+	}
+	if role == user.Role || role == models.RoleCustomer {
+		return nil, fmt.Errorf("%w: cannot elevate to %s", ErrInvalidRole, role)
+	}
+	if _, err := s.roles.findRole(role); err != nil {
+		return nil, err
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("a reason is required")
+	}
+
+	maxMinutes := int(s.cfg.Elevation.MaxDuration / time.Minute)
+	if minutes == 0 {
+		minutes = maxMinutes
+	}
+	if minutes < 1 || minutes > maxMinutes {
+		return nil, fmt.Errorf("duration must be between 1 and %d minutes", maxMinutes)
+	}
+
+	open, err := s.repo.HasOpen(userID)
+	if err != nil {
+		return nil, err
+	}
+	if open {
+		return nil, ErrElevationOpen
+	}
+
+	grant := &models.ElevationGrant{
+		UserID:   userID,
+		BaseRole: user.Role,
+		Role:     role,
+		Reason:   reason,
+		Duration: minutes,
+		Status:   models.ElevationPending,
+		User:     *user,
+	}
+	if err := s.repo.Create(grant); err != nil {
+		return nil, err
+	}
+
+	_ = s.auditRepo.Log("elevation", grant.ID, "elevation_requested:"+string(role), userID, ip, userAgent)
+
+	s.notifyAdmins(grant, "requested",
+		fmt.Sprintf("%s asks for the %s role for %d minutes: %s", user.Email, role, minutes, html.EscapeString(reason)))
+
+	return toElevationInfo(grant), nil
+}
+
+func (s *ElevationService) Mine(userID uuid.UUID) ([]*ElevationInfo, error) {
+	grants, err := s.repo.ListForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return toElevationInfos(grants), nil
+}
+
+// Short-lived token carrying the elevated role. auth is the caller's
+// current session, so step-up state carries over.
+func (s *ElevationService) Token(
+	grantID uuid.UUID,
+	userID uuid.UUID,
+	auth utils.AuthContext,
+) (*ElevatedTokenResponse, error) {
+
+	grant, err := s.grant(grantID)
+	if err != nil {
+		return nil, err
+	}
+	if grant.UserID != userID {
+		return nil, ErrResourceNotFound
+	}
+	if grant.Status != models.ElevationActive || grant.ExpiresAt == nil || !grant.ExpiresAt.After(time.Now()) {
+		return nil, ErrElevationInactive
+	}
+
+	user, err := s.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive || user.Role != grant.BaseRole {
+		// Deactivated or re-roled since the request
+		return nil, ErrElevationInactive
+	}
+
+	ttl := time.Until(*grant.ExpiresAt)
+	if ttl > s.cfg.JWT.AccessExpiry {
+		ttl = s.cfg.JWT.AccessExpiry
+	}
+
+	token, claims, err := utils.GenerateElevatedToken(user, grant.Role, grant.ID, s.keys, ttl, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ElevatedTokenResponse{
+		AccessToken: token,
+		ExpiresAt:   claims.ExpiresAt.Time,
+		GrantEndsAt: *grant.ExpiresAt,
+		Role:        grant.Role,
+	}, nil
+}
+
+// Give the role back before it runs out
+func (s *ElevationService) End(
+	grantID uuid.UUID,
+	userID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	grant, err := s.grant(grantID)
+	if err != nil {
+		return err
+	}
+	if grant.UserID != userID {
+		return ErrResourceNotFound
+	}
+
+	return s.finish(grant, models.ElevationEnded, userID, ip, userAgent)
+}
+
+/*
+=====================
+ Approver (admin)
+=====================
+*/
+
+func (s *ElevationService) List(status models.ElevationStatus) ([]*ElevationInfo, error) {
+	grants, err := s.repo.List(status)
+	if err != nil {
+		return nil, err
+	}
+	return toElevationInfos(grants), nil
+}
+
+// Starts the clock. Nobody approves their own request.
+func (s *ElevationService) Approve(
+	grantID uuid.UUID,
+	approverID uuid.UUID,
+	ip string,
+	userAgent string,
+) (*ElevationInfo, error) {
+
+	if err := s.requireApprover(approverID); err != nil {
+		return nil, err
+	}
+
+	grant, err := s.grant(grantID)
+	if err != nil {
+		return nil, err
+	}
+	if grant.UserID == approverID {
+		return nil, fmt.Errorf("%w: cannot approve your own elevation", ErrForbidden)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(grant.Duration) * time.Minute)
+
+	moved, err := s.repo.Transition(grant.ID, []models.ElevationStatus{models.ElevationPending}, map[string]interface{}{
+		"status":     models.ElevationActive,
+		"decided_by": approverID,
+		"decided_at": now,
+		"expires_at": expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !moved {
+		return nil, ErrElevationInactive
+	}
+
+	grant.Status = models.ElevationActive
+	grant.DecidedBy = &approverID
+	grant.DecidedAt = &now
+	grant.ExpiresAt = &expiresAt
+
+	_ = s.auditRepo.LogElevated(grant.ID, "elevation_started:"+string(grant.Role), approverID, ip, userAgent)
+
+	s.notifyAdmins(grant, "started",
+		fmt.Sprintf("%s now holds the %s role until %s. Reason: %s",
+			grant.User.Email, grant.Role, expiresAt.Format("15:04 MST"), html.EscapeString(grant.Reason)))
+
+	return toElevationInfo(grant), nil
+}
+
+func (s *ElevationService) Deny(
+	grantID uuid.UUID,
+	approverID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	if err := s.requireApprover(approverID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	moved, err := s.repo.Transition(grantID, []models.ElevationStatus{models.ElevationPending}, map[string]interface{}{
+		"status":     models.ElevationDenied,
+		"decided_by": approverID,
+		"decided_at": now,
+		"ended_at":   now,
+	})
+	if err != nil {
+		return err
+	}
+	if !moved {
+		if _, err := s.grant(grantID); err != nil {
+			return err
+		}
+		return ErrElevationInactive
+	}
+
+	_ = s.auditRepo.Log("elevation", grantID, "elevation_denied", approverID, ip, userAgent)
+
+	return nil
+}
+
+// Cuts an active (or still pending) elevation short
+func (s *ElevationService) Revoke(
+	grantID uuid.UUID,
+	adminID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	if err := s.requireApprover(adminID); err != nil {
+		return err
+	}
+
+	grant, err := s.grant(grantID)
+	if err != nil {
+		return err
+	}
+
+	return s.finish(grant, models.ElevationRevoked, adminID, ip, userAgent)
+}
+
+/*
+=====================
+ Enforcement & Expiry
+=====================
+*/
+
+// AuthMiddleware: is the grant behind an elevated token still live?
+func (s *ElevationService) IsActive(grantID, userID uuid.UUID) (bool, error) {
+	return s.repo.IsActive(grantID, userID)
+}
+
+// Background job: closes grants whose time is up and drops requests
+// nobody approved in time
+func (s *ElevationService) ExpireDue() error {
+	now := time.Now()
+
+	due, err := s.repo.ActiveExpired(now)
+	if err != nil {
+		return err
+	}
+	for i := range due {
+		if err := s.finish(&due[i], models.ElevationExpired, uuid.Nil, "", "elevation-expiry-job"); err != nil &&
+			!errors.Is(err, ErrElevationInactive) {
+			log.Printf("⚠️  expiring elevation %s failed: %v", due[i].ID, err)
+		}
+	}
+
+	_, err = s.repo.ExpirePendingBefore(now.Add(-s.cfg.Elevation.RequestTTL))
+	return err
+}
+
+// Active → ended / revoked / expired (pending → revoked too), then tell
+// the admins if the elevation had actually started
+func (s *ElevationService) finish(
+	grant *models.ElevationGrant,
+	status models.ElevationStatus,
+	by uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	from := []models.ElevationStatus{models.ElevationActive}
+	if status == models.ElevationRevoked {
+		from = append(from, models.ElevationPending)
+	}
+
+	fields := map[string]interface{}{
+		"status":   status,
+		"ended_at": time.Now(),
+	}
+	if by != uuid.Nil {
+		fields["ended_by"] = by
+	}
+
+	moved, err := s.repo.Transition(grant.ID, from, fields)
+	if err != nil {
+		return err
+	}
+	if !moved {
+		return ErrElevationInactive
+	}
+
+	actor := by
+	if actor == uuid.Nil {
+		actor = grant.UserID
+	}
+	_ = s.auditRepo.LogElevated(grant.ID, "elevation_"+string(status), actor, ip, userAgent)
+
+	if grant.Status == models.ElevationActive {
+		s.notifyAdmins(grant, "ended",
+			fmt.Sprintf("%s no longer holds the %s role (%s).", grant.User.Email, grant.Role, status))
+	}
+
+	return nil
+}
+
+/*
+=====================
+ Helpers
+=====================
+*/
+
+func (s *ElevationService) grant(id uuid.UUID) (*models.ElevationGrant, error) {
+	grant, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrResourceNotFound
+		}
+		return nil, err
+	}
+	return grant, nil
+}
+
+func (s *ElevationService) user(id uuid.UUID) (*models.User, error) {
+	user, err := s.repo.FindUser(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// Decisions go by the approver's stored role, never an elevated token's
+func (s *ElevationService) requireApprover(userID uuid.UUID) error {
+	user, err := s.user(userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return ErrForbidden
+	}
+
+	allowed, err := s.roles.HasPermission(user.Role, models.PermElevationApprove)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func toElevationInfos(grants []models.ElevationGrant) []*ElevationInfo {
+	result := make([]*ElevationInfo, 0, len(grants))
+	for i := range grants {
+		result = append(result, toElevationInfo(&grants[i]))
+	}
+	return result
+}
+
+// Mails every active admin; never blocks or fails the caller
+func (s *ElevationService) notifyAdmins(grant *models.ElevationGrant, event, summary string) {
+	if s.mailer == nil {
+		return
+	}
+
+	go func() {
+		admins, err := s.repo.ActiveAdmins()
+		if err != nil {
+			log.Printf("⚠️  elevation %s: loading admins failed: %v", grant.ID, err)
+			return
+		}
+
+		subject := fmt.Sprintf("Break-glass elevation %s: %s", event, grant.User.Email)
+		body := fmt.Sprintf(`
+			<h2>Break-glass elevation %s</h2>
+			<p>%s</p>
+			<p><b>Request:</b> %s</p>
+		`, event, summary, grant.ID)
+
+		for _, admin := range admins {
+			if err := s.mailer.Send(admin.Email, subject, body); err != nil {
+				log.Printf("⚠️  elevation notice to %s failed: %v", admin.Email, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"rbac/models"
+	"rbac/repository"
+	"rbac/testutil"
+	"rbac/utils"
+)
+
+// Only a stored admin decides; an approved elevation doesn't qualify
+func TestElevationApproverNeedsStoredPermission(t *testing.T) {
+	roles, db := newTestRoleService(t)
+	cfg := testutil.Config()
+
+	keys, err := utils.LoadKeyRing("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	elevations := NewElevationService(
+		repository.NewElevationRepository(db),
+		roles,
+		repository.NewAuditRepository(db),
+		keys,
+		cfg,
+	)
+
+	admin := testutil.User(t, db, models.RoleAdmin)
+	lead := testutil.User(t, db, models.RoleSupport)
+	other := testutil.User(t, db, models.RoleSupport)
+
+	grant, err := elevations.Request(lead.ID, models.RoleAdmin, "incident", 30, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := elevations.Approve(grant.ID, admin.ID, "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := elevations.Request(other.ID, models.RoleAdmin, "incident", 30, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := elevations.Approve(pending.ID, lead.ID, "", ""); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("elevated support approving: err = %v, want ErrForbidden", err)
+	}
+	if err := elevations.Deny(pending.ID, lead.ID, "", ""); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("elevated support denying: err = %v, want ErrForbidden", err)
+	}
+	if err := elevations.Revoke(grant.ID, lead.ID, "", ""); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("elevated support revoking: err = %v, want ErrForbidden", err)
+	}
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rbac/config"
+	"rbac/models"
+	"rbac/repository"
+	"rbac/utils"
+)
+
+/*
+=====================
+ Email Verification & Change
+=====================
+ Both flows mail a single-use link to the address being proven. A change
+ only touches users.email once the new address confirms; the old address
+ gets a heads-up when the change is requested and when it goes through.
+*/
+
+type EmailVerificationService struct {
+	repo      *repository.EmailVerificationRepository
+	authRepo  *repository.AuthRepository
+	auditRepo *repository.AuditRepository
+	mailer    *utils.Mailer
+	cfg       *config.Config
+}
+
+func NewEmailVerificationService(
+	repo *repository.EmailVerificationRepository,
+	authRepo *repository.AuthRepository,
+	auditRepo *repository.AuditRepository,
+	cfg *config.Config,
+) *EmailVerificationService {
+	return &EmailVerificationService{
+		repo:      repo,
+		authRepo:  authRepo,
+		auditRepo: auditRepo,
+		mailer:    utils.NewMailer(cfg.Mail),
+		cfg:       cfg,
+	}
+}
+
+/*
+=====================
+ Response DTOs
+=====================
+*/
+
+type EmailConfirmResult struct {
+	Email   string                          `json:"email"`
+	Purpose models.EmailVerificationPurpose `json:"purpose"`
+}
+
+/*
+=====================
+ Request
+=====================
+*/
+
+// Confirmation link for the address already on the account
+func (s *EmailVerificationService) SendVerification(userID uuid.UUID) error {
+	user, err := s.authRepo.FindUserByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	rawToken, err := s.issue(user.ID, user.Email, models.EmailPurposeVerify)
+	if err != nil {
+		return err
+	}
+
+	return s.send(user.Email, "Verify your email", `
+		<h2>Verify your email</h2>
+		<p>Confirm this address for your RBAC App account.</p>
+	`, rawToken)
+}
+
+func (s *EmailVerificationService) RequestChange(
+	userID uuid.UUID,
+	newEmail string,
+	ip string,
+	userAgent string,
+) error {
+
+	newEmail = strings.ToLower(strings.TrimSpace(newEmail))
+
+	user, err := s.authRepo.FindUserByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if strings.EqualFold(user.Email, newEmail) {
+		return errors.New("new email is the same as the current one")
+	}
+
+	// SSO accounts take their address from the IdP
+	if user.OIDCSubject != nil {
+		return errors.New("email is managed by your sso provider")
+	}
+
+	taken, err := s.repo.EmailTaken(newEmail, user.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrEmailTaken
+	}
+
+	rawToken, err := s.issue(user.ID, newEmail, models.EmailPurposeChange)
+	if err != nil {
+		return err
+	}
+
+	if err := s.send(newEmail, "Confirm your new email", `
+		<h2>Confirm your new email</h2>
+		<p>Your RBAC App account will move to this address once you confirm.</p>
+	`, rawToken); err != nil {
+		return err
+	}
+
+	// 📨 Heads-up to the current owner; best effort
+	s.notify(user.Email, "Email change requested", fmt.Sprintf(`
+		<h2>Email change requested</h2>
+		<p>A request was made from %s to move your account to <b>%s</b>.</p>
+		<p>Nothing changes until the new address is confirmed.
+		If this wasn't you, change your password immediately.</p>
+	`, ip, newEmail))
+
+	_ = s.auditRepo.Log("user", user.ID, "email_change_requested", user.ID, ip, userAgent)
+
+	return nil
+}
+
+/*
+=====================
+ Confirm (public link)
+=====================
+*/
+
+func (s *EmailVerificationService) Confirm(
+	rawToken string,
+	ip string,
+	userAgent string,
+) (*EmailConfirmResult, error) {
+
+	token, err := s.repo.FindValid(utils.HashToken(rawToken))
+	if err != nil {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	user := &token.User
+	if !user.IsActive {
+		return nil, ErrAccountInactive
+	}
+
+	// A verify link is only good for the address it was sent to
+	if token.Purpose == models.EmailPurposeVerify && !strings.EqualFold(user.Email, token.Email) {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	oldEmail := user.Email
+
+	err = s.repo.WithTransaction(func(tx *repository.EmailVerificationRepository) error {
+		ok, err := tx.Consume(token.ID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidVerificationToken
+		}
+
+		if token.Purpose == models.EmailPurposeChange {
+			taken, err := tx.EmailTaken(token.Email, user.ID)
+			if err != nil {
+				return err
+			}
+			if taken {
+				return ErrEmailTaken
+			}
+
+			if err := tx.InvalidateForUser(user.ID); err != nil {
+				return err
+			}
+		}
+
+		return tx.ApplyEmail(user.ID, token.Email)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	action := "email_verified"
+	if token.Purpose == models.EmailPurposeChange {
+		action = "email_changed"
+
+		s.notify(oldEmail, "Your email was changed", fmt.Sprintf(`
+			<h2>Your email was changed</h2>
+			<p>Your RBAC App account now uses <b>%s</b>.</p>
+			<p>If this wasn't you, contact an administrator immediately.</p>
+		`, token.Email))
+	}
+
+	_ = s.auditRepo.Log("user", user.ID, action, user.ID, ip, userAgent)
+
+	return &EmailConfirmResult{
+		Email:   token.Email,
+		Purpose: token.Purpose,
+	}, nil
+}
+
+/*
+=====================
+ Helpers
+=====================
+*/
+
+func (s *EmailVerificationService) issue(
+	userID uuid.UUID,
+	email string,
+	purpose models.EmailVerificationPurpose,
+) (string, error) {
+
+	if s.mailer == nil {
+		return "", ErrMailerUnavailable
+	}
+
+	rawToken, err := utils.GenerateRandomToken(48)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.repo.Create(&models.EmailVerificationToken{
+		UserID:    userID,
+		Email:     email,
+		Purpose:   purpose,
+		TokenHash: utils.HashToken(rawToken),
+		ExpiresAt: time.Now().Add(s.cfg.EmailVerify.TTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return rawToken, nil
+}
+
+func (s *EmailVerificationService) send(to, subject, intro, rawToken string) error {
+	link := s.cfg.FrontendURL + "/verify-email?token=" + rawToken
+
+	body := intro + `
+		<p><a href="` + link + `">Click here to confirm</a></p>
+		<p>This link expires in ` + fmt.Sprintf("%d", int(s.cfg.EmailVerify.TTL.Hours())) + ` hours.</p>
+	`
+
+	return s.mailer.Send(to, subject, body)
+}
+
+func (s *EmailVerificationService) notify(to, subject, body string) {
+	if s.mailer == nil {
+		return
+	}
+	if err := s.mailer.Send(to, subject, body); err != nil {
+		log.Printf("⚠️  email notice to %s failed: %v", to, err)
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 
 	"rbac/models"
 	"rbac/repository"
@@ -22,13 +23,35 @@ func NewFeedbackService(r *repository.FeedbackRepository) *FeedbackService {
 
 
 
+// Ownership is checked by the route policy; the rated engineer is
+// whoever closed out the ticket, not what the client claims
 func (s *FeedbackService) Submit(
 	ticketID uuid.UUID,
-	engineerID uuid.UUID,
 	rating int,
 	comment string,
 ) error {
 
+	ticket, err := s.repo.FindTicket(ticketID)
+	if err != nil {
+		return err
+	}
+	if ticket.Status != models.StatusClosed {
+		return errors.New("feedback can only be given on closed tickets")
+	}
+
+	exists, err := s.repo.ExistsForTicket(ticketID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("feedback already submitted for this ticket")
+	}
+
+	engineerID, err := s.repo.CurrentAssignee(ticketID)
+	if err != nil {
+		return err
+	}
+
 	return s.repo.Create(&models.TicketFeedback{
 		TicketID:   ticketID,
 		EngineerID: engineerID,
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rbac/models"
+	"rbac/utils"
+)
+
+/*
+=====================
+ Admin Impersonation ("view as user")
+=====================
+*/
+
+const impersonationTTL = 10 * time.Minute
+
+type ImpersonationResponse struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	User        *UserInfo `json:"user"`
+	ActorID     uuid.UUID `json:"impersonated_by"`
+}
+
+func (s *AuthService) Impersonate(
+	adminID uuid.UUID,
+	targetID uuid.UUID,
+	ip string,
+	userAgent string,
+) (*ImpersonationResponse, error) {
+
+	if adminID == targetID {
+		return nil, errors.New("cannot impersonate yourself")
+	}
+
+	admin, err := s.repo.FindUserByID(adminID)
+	if err != nil || admin.Role != models.RoleAdmin {
+		return nil, errors.New("only admins can impersonate")
+	}
+
+	target, err := s.repo.FindUserByID(targetID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	// Admin → admin would be a privilege-laundering path
+	if target.Role == models.RoleAdmin {
+		return nil, errors.New("cannot impersonate another admin")
+	}
+
+	token, claims, err := utils.GenerateImpersonationToken(
+		target,
+		admin,
+		s.keys,
+		impersonationTTL,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.auditRepo.Log("user", target.ID, "impersonation_started", admin.ID, ip, userAgent)
+
+	return &ImpersonationResponse{
+		AccessToken: token,
+		ExpiresAt:   claims.ExpiresAt.Time,
+		User: &UserInfo{
+			ID:    target.ID,
+			Name:  target.Name,
+			Email: target.Email,
+			Role:  target.Role,
+		},
+		ActorID: admin.ID,
+	}, nil
+}
+
+// Kills the impersonation token right away instead of waiting for expiry
+func (s *AuthService) EndImpersonation(
+	jti string,
+	expiresAt time.Time,
+	targetID uuid.UUID,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	if err := s.revocations.RevokeToken(jti, targetID, expiresAt); err != nil {
+		return err
+	}
+
+	_ = s.auditRepo.Log("user", targetID, "impersonation_ended", actorID, ip, userAgent)
+
+	return nil
+}
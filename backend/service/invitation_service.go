@@ -0,0 +1,319 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/config"
+	"rbac/models"
+	"rbac/repository"
+	"rbac/utils"
+)
+
+type InvitationService struct {
+	db        *gorm.DB
+	repo      *repository.InvitationRepository
+	auditRepo *repository.AuditRepository
+	mailer    *utils.Mailer
+	cfg       *config.Config
+}
+
+func NewInvitationService(
+	db *gorm.DB,
+	repo *repository.InvitationRepository,
+	auditRepo *repository.AuditRepository,
+	cfg *config.Config,
+) *InvitationService {
+	return &InvitationService{
+		db:        db,
+		repo:      repo,
+		auditRepo: auditRepo,
+		mailer:    utils.NewMailer(cfg.Mail),
+		cfg:       cfg,
+	}
+}
+
+/*
+=====================
+ Response DTOs
+=====================
+*/
+
+type InvitationInfo struct {
+	ID            uuid.UUID               `json:"id"`
+	UserID        uuid.UUID               `json:"user_id"`
+	Name          string                  `json:"name"`
+	Email         string                  `json:"email"`
+	Role          models.Role             `json:"role"`
+	Status        models.InvitationStatus `json:"status"`
+	ExpiresAt     time.Time               `json:"expires_at"`
+	SendCount     int                     `json:"send_count"`
+	LastSentAt    *time.Time              `json:"last_sent_at"`
+	LastSendError string                  `json:"last_send_error,omitempty"`
+	EmailSent     bool                    `json:"email_sent"`
+	InvitedBy     uuid.UUID               `json:"invited_by"`
+	AcceptedAt    *time.Time              `json:"accepted_at"`
+	RevokedAt     *time.Time              `json:"revoked_at"`
+	CreatedAt     time.Time               `json:"created_at"`
+}
+
+func toInvitationInfo(inv *models.Invitation, user *models.User) *InvitationInfo {
+	return &InvitationInfo{
+		ID:            inv.ID,
+		UserID:        inv.UserID,
+		Name:          user.Name,
+		Email:         user.Email,
+		Role:          user.Role,
+		Status:        inv.EffectiveStatus(time.Now()),
+		ExpiresAt:     inv.ExpiresAt,
+		SendCount:     inv.SendCount,
+		LastSentAt:    inv.LastSentAt,
+		LastSendError: inv.LastSendError,
+		EmailSent:     inv.LastSentAt != nil && inv.LastSendError == "",
+		InvitedBy:     inv.InvitedBy,
+		AcceptedAt:    inv.AcceptedAt,
+		RevokedAt:     inv.RevokedAt,
+		CreatedAt:     inv.CreatedAt,
+	}
+}
+
+/*
+=====================
+ Issue + Deliver
+=====================
+*/
+
+// Runs inside CreateUser's transaction: the account and its invitation
+// exist together or not at all. Returns the raw set-password token.
+func (s *InvitationService) CreateTx(
+	tx *gorm.DB,
+	user *models.User,
+	invitedBy uuid.UUID,
+) (*models.Invitation, string, error) {
+
+	rawToken, reset, err := s.newResetToken(tx, user.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	inv := &models.Invitation{
+		UserID:       user.ID,
+		InvitedBy:    invitedBy,
+		ResetTokenID: &reset.ID,
+		Status:       models.InvitationPending,
+		ExpiresAt:    reset.ExpiresAt,
+	}
+
+	if err := s.repo.CreateTx(tx, inv); err != nil {
+		return nil, "", err
+	}
+
+	return inv, rawToken, nil
+}
+
+// Best effort: the outcome is stored on the invitation so the admin can
+// see it in the listing and resend. Never fails the caller.
+func (s *InvitationService) Deliver(
+	inv *models.Invitation,
+	user *models.User,
+	rawToken string,
+) {
+	sendErr := ""
+
+	if err := s.sendInviteEmail(user, rawToken); err != nil {
+		log.Printf("⚠️  invitation email to %s failed: %v", user.Email, err)
+		sendErr = err.Error()
+	}
+
+	if err := s.repo.RecordSend(inv.ID, sendErr); err != nil {
+		log.Printf("⚠️  failed to record invitation send %s: %v", inv.ID, err)
+		return
+	}
+
+	now := time.Now()
+	inv.SendCount++
+	inv.LastSentAt = &now
+	inv.LastSendError = sendErr
+}
+
+func (s *InvitationService) newResetToken(
+	tx *gorm.DB,
+	userID uuid.UUID,
+) (string, *models.PasswordResetToken, error) {
+
+	rawToken, err := utils.GenerateRandomToken(48)
+	if err != nil {
+		return "", nil, err
+	}
+
+	reset := &models.PasswordResetToken{
+		UserID:    userID,
+		Token:     utils.HashToken(rawToken),
+		ExpiresAt: time.Now().Add(s.cfg.Invite.TTL),
+	}
+
+	if err := s.repo.CreateResetTokenTx(tx, reset); err != nil {
+		return "", nil, err
+	}
+
+	return rawToken, reset, nil
+}
+
+func (s *InvitationService) sendInviteEmail(user *models.User, rawToken string) error {
+	if s.mailer == nil {
+		return ErrMailerUnavailable
+	}
+
+	resetURL := s.cfg.FrontendURL + "/reset-password?token=" + rawToken
+
+	body := `
+		<h2>Welcome to RBAC App</h2>
+		<p>Your account has been created.</p>
+		<p>
+			<a href="` + resetURL + `">Click here to set your password</a>
+		</p>
+		<p>This link expires in ` + fmt.Sprintf("%d", int(s.cfg.Invite.TTL.Hours())) + ` hours.</p>
+	`
+
+	return s.mailer.Send(user.Email, "Set your password", body)
+}
+
+/*
+=====================
+ Admin Actions
+=====================
+*/
+
+func (s *InvitationService) List(status models.InvitationStatus) ([]InvitationInfo, error) {
+	rows, err := s.repo.List(status)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]InvitationInfo, 0, len(rows))
+	for i := range rows {
+		result = append(result, *toInvitationInfo(&rows[i], &rows[i].User))
+	}
+	return result, nil
+}
+
+// Fresh token + deadline; earlier links stop working. Expired invitations
+// can be resent, accepted / revoked ones cannot.
+func (s *InvitationService) Resend(
+	id uuid.UUID,
+	adminID uuid.UUID,
+	ip string,
+	userAgent string,
+) (*InvitationInfo, error) {
+
+	inv, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, errors.New("invitation not found")
+	}
+
+	switch inv.Status {
+	case models.InvitationAccepted:
+		return nil, errors.New("invitation already accepted")
+	case models.InvitationRevoked:
+		return nil, errors.New("invitation was revoked")
+	}
+
+	if !inv.User.IsActive {
+		return nil, ErrAccountInactive
+	}
+
+	var rawToken string
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewInvitationRepository(tx)
+
+		if err := txRepo.InvalidateResetTokens(inv.UserID); err != nil {
+			return err
+		}
+
+		raw, reset, err := s.newResetToken(tx, inv.UserID)
+		if err != nil {
+			return err
+		}
+
+		if err := txRepo.Reissue(inv.ID, reset.ID, reset.ExpiresAt); err != nil {
+			return err
+		}
+
+		rawToken = raw
+		inv.Status = models.InvitationPending
+		inv.ResetTokenID = &reset.ID
+		inv.ExpiresAt = reset.ExpiresAt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Deliver(inv, &inv.User, rawToken)
+
+	_ = s.auditRepo.Log("invitation", inv.ID, "invitation_resent", adminID, ip, userAgent)
+
+	return toInvitationInfo(inv, &inv.User), nil
+}
+
+// Kills the link and deactivates the never-used account. An account that
+// has signed in is live: it goes through user deactivation instead.
+func (s *InvitationService) Revoke(
+	id uuid.UUID,
+	adminID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	inv, err := s.repo.FindByID(id)
+	if err != nil {
+		return errors.New("invitation not found")
+	}
+
+	if inv.Status != models.InvitationPending {
+		return errors.New("only pending invitations can be revoked")
+	}
+	if inv.User.LastLoginAt != nil {
+		s.MarkAccepted(inv.UserID)
+		return errors.New("account already signed in, deactivate the user instead")
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewInvitationRepository(tx)
+
+		revoked, err := txRepo.Revoke(inv.ID, adminID)
+		if err != nil {
+			return err
+		}
+		if !revoked {
+			return errors.New("only pending invitations can be revoked")
+		}
+
+		if err := txRepo.InvalidateResetTokens(inv.UserID); err != nil {
+			return err
+		}
+
+		return repository.NewUserLifecycleRepository(tx).SetActive(inv.UserID, false, adminID)
+	})
+	if err != nil {
+		return err
+	}
+
+	_ = s.auditRepo.Log("invitation", inv.ID, "invitation_revoked", adminID, ip, userAgent)
+
+	return nil
+}
+
+// Password set through the invite (or any reset), or any first sign-in
+// → invitation accepted
+func (s *InvitationService) MarkAccepted(userID uuid.UUID) {
+	if err := s.repo.AcceptForUser(userID); err != nil {
+		log.Printf("⚠️  failed to mark invitation accepted for %s: %v", userID, err)
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"rbac/models"
+	"rbac/testutil"
+	"rbac/utils"
+)
+
+// Signing in without the invite link (SSO, passkey) still accepts it, and
+// a live account can't be switched off by revoking a stale invitation
+func TestInvitationRevokeSparesAccountsInUse(t *testing.T) {
+	auth, db, _ := newTestAuthService(t)
+	admin := testutil.User(t, db, models.RoleAdmin)
+
+	invite := func(user *models.User) *models.Invitation {
+		inv, _, err := auth.invitations.CreateTx(db, user, admin.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return inv
+	}
+
+	ssoUser := testutil.User(t, db, models.RoleSupport)
+	accepted := invite(ssoUser)
+	if _, err := auth.issueTokens(ssoUser, utils.NewAuthContext(utils.AMRFederated), "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.invitations.Revoke(accepted.ID, admin.ID, "", ""); err == nil {
+		t.Fatal("revoked the invitation of an account that signed in")
+	}
+
+	// Signed in before the fix: still pending, but LastLoginAt gives it away
+	lateUser := testutil.User(t, db, models.RoleAdmin)
+	stale := invite(lateUser)
+	now := time.Now()
+	db.Model(lateUser).Update("last_login_at", &now)
+	if err := auth.invitations.Revoke(stale.ID, admin.ID, "", ""); err == nil {
+		t.Fatal("revoked the invitation of an account that signed in")
+	}
+
+	for _, u := range []*models.User{ssoUser, lateUser} {
+		if !reloadUser(t, auth, u.ID).IsActive {
+			t.Fatalf("%s deactivated by an invitation revoke", u.Email)
+		}
+	}
+
+	unused := testutil.User(t, db, models.RoleSupport)
+	pending := invite(unused)
+	if err := auth.invitations.Revoke(pending.ID, admin.ID, "", ""); err != nil {
+		t.Fatalf("revoke unused invitation: %v", err)
+	}
+	if reloadUser(t, auth, unused.ID).IsActive {
+		t.Fatal("never-used account still active after revoke")
+	}
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+
+	"rbac/dto"
+	"rbac/models"
+	"rbac/utils"
+)
+
+/*
+=====================
+ Login History
+=====================
+ Every interactive sign-in attempt lands in login_events. A success from a
+ device (user agent) or IP range the account never signed in from before
+ is flagged and, unless disabled, mailed to the user.
+*/
+
+func (s *AuthService) recordLoginEvent(e *models.LoginEvent) {
+	e.IPRange = utils.IPRange(e.IPAddress)
+	e.DeviceHash = utils.HashToken(e.UserAgent)
+
+	if err := s.loginEvents.Create(e); err != nil {
+		log.Printf("⚠️  failed to record login event: %v", err)
+	}
+}
+
+// user is nil when the email matched nothing
+func (s *AuthService) recordLoginAttempt(
+	email string,
+	user *models.User,
+	method models.LoginMethod,
+	outcome models.LoginOutcome,
+	err error,
+	ip string,
+	userAgent string,
+) {
+	e := &models.LoginEvent{
+		Email:     email,
+		Method:    method,
+		Outcome:   outcome,
+		Reason:    loginFailureReason(err),
+		IPAddress: ip,
+		UserAgent: userAgent,
+	}
+
+	if user != nil {
+		e.UserID = &user.ID
+		if e.Email == "" {
+			e.Email = user.Email
+		}
+		if method == models.LoginMethodTwoFA || outcome == models.LoginChallenged {
+			e.TwoFAMethod = string(activeTwoFAMethod(user))
+		}
+	}
+
+	s.recordLoginEvent(e)
+}
+
+// Called from issueTokens, i.e. once per completed sign-in
+func (s *AuthService) recordLoginSuccess(
+	user *models.User,
+	auth utils.AuthContext,
+	ip string,
+	userAgent string,
+) {
+	method, twoFA := loginMethodFromAMR(auth.Methods)
+
+	e := &models.LoginEvent{
+		UserID:      &user.ID,
+		Email:       user.Email,
+		Method:      method,
+		TwoFAMethod: twoFA,
+		Outcome:     models.LoginSucceeded,
+		IPAddress:   ip,
+		UserAgent:   userAgent,
+	}
+
+	known, err := s.loginEvents.Familiarity(
+		user.ID,
+		utils.HashToken(userAgent),
+		utils.IPRange(ip),
+	)
+	if err != nil {
+		log.Printf("⚠️  login familiarity check failed for %s: %v", user.ID, err)
+	} else if known.HasHistory {
+		// First ever login has nothing to compare against
+		e.NewDevice = !known.KnownDevice
+		e.NewIPRange = !known.KnownRange
+	}
+
+	s.recordLoginEvent(e)
+
+	if (e.NewDevice || e.NewIPRange) && s.cfg.LoginAlerts.Enabled {
+		s.sendNewLoginAlert(user, e)
+	}
+}
+
+// Mailed in the background: SMTP must not hold up the sign-in
+func (s *AuthService) sendNewLoginAlert(user *models.User, e *models.LoginEvent) {
+	if s.mailer == nil {
+		return
+	}
+
+	body := newLoginAlertBody(e)
+
+	go func() {
+		if err := s.mailer.Send(user.Email, "New sign-in to your account", body); err != nil {
+			log.Printf("⚠️  new login alert to %s failed: %v", user.Email, err)
+		}
+	}()
+}
+
+// The user agent is whatever the client sent, so it is escaped
+func newLoginAlertBody(e *models.LoginEvent) string {
+	what := "a new device"
+	switch {
+	case e.NewDevice && e.NewIPRange:
+		what = "a new device and location"
+	case e.NewIPRange:
+		what = "a new location"
+	}
+
+	return fmt.Sprintf(`
+		<h2>New sign-in to your account</h2>
+		<p>Your account was just signed in to from %s.</p>
+		<p><b>IP address:</b> %s<br/>
+		   <b>Device:</b> %s<br/>
+		   <b>Time:</b> %s</p>
+		<p>If this was you, no action is needed. Otherwise change your
+		   password and sign out all sessions from your profile.</p>
+	`, what, html.EscapeString(e.IPAddress), html.EscapeString(e.UserAgent), e.CreatedAt.Format("2006-01-02 15:04 MST"))
+}
+
+func loginMethodFromAMR(amr []string) (models.LoginMethod, string) {
+	has := map[string]bool{}
+	for _, m := range amr {
+		has[m] = true
+	}
+
+	switch {
+	case has[utils.AMRFederated]:
+		return models.LoginMethodSSO, ""
+	case has[utils.AMRHardwareKey] && !has[utils.AMRPassword]:
+		return models.LoginMethodPasskey, ""
+	case has[utils.AMRHardwareKey]:
+		return models.LoginMethodPassword, string(models.TwoFAMethodWebAuthn)
+	case has[utils.AMROTP]:
+		return models.LoginMethodPassword, "otp"
+	default:
+		return models.LoginMethodPassword, ""
+	}
+}
+
+// Same vocabulary as the API error codes
+func loginFailureReason(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var locked *LockedError
+	if errors.As(err, &locked) {
+		return string(dto.CodeAccountLocked)
+	}
+
+	for _, m := range []struct {
+		err  error
+		code dto.ErrorCode
+	}{
+		{ErrInvalidCredentials, dto.CodeInvalidCredentials},
+		{ErrAccountInactive, dto.CodeAccountInactive},
+		{ErrPasswordResetRequired, dto.CodePasswordResetRequired},
+		{ErrInvalidOTP, dto.CodeInvalidOTP},
+		{ErrInvalidRecoveryCode, dto.CodeInvalidRecoveryCode},
+		{ErrInvalidRefreshToken, dto.CodeInvalidRefreshToken},
+	} {
+		if errors.Is(err, m.err) {
+			return string(m.code)
+		}
+	}
+
+	return string(dto.CodeBadRequest)
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"rbac/models"
+)
+
+func TestNewLoginAlertEscapesUserAgent(t *testing.T) {
+	body := newLoginAlertBody(&models.LoginEvent{
+		IPAddress: "203.0.113.7",
+		UserAgent: `<a href="https://evil.example">Reset your password</a>`,
+		NewDevice: true,
+	})
+
+	if strings.Contains(body, "<a href") {
+		t.Fatal("user agent rendered as HTML in the alert")
+	}
+	if !strings.Contains(body, "&lt;a href=") {
+		t.Fatal("user agent missing from the alert")
+	}
+}
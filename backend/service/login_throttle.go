@@ -0,0 +1,180 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rbac/models"
+)
+
+/*
+=====================
+ Brute-force Protection
+=====================
+*/
+
+func tooManyAttempts(wait time.Duration) error {
+	return &LockedError{RetryAfter: wait}
+}
+
+func normalizeLoginKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Exponential backoff: nothing below the threshold, then base, 2×base, 4×base… capped
+func (s *AuthService) lockoutDelay(failures int, threshold int) time.Duration {
+	if threshold <= 0 || failures < threshold {
+		return 0
+	}
+
+	shift := failures - threshold
+	if shift > 20 {
+		return s.cfg.Lockout.MaxDelay
+	}
+
+	d := s.cfg.Lockout.BaseDelay << uint(shift)
+	if d > s.cfg.Lockout.MaxDelay {
+		d = s.cfg.Lockout.MaxDelay
+	}
+	return d
+}
+
+// Remaining lockout for scope/key (0 = not locked)
+func (s *AuthService) lockedFor(scope string, key string) time.Duration {
+	t, err := s.throttleRepo.Find(scope, key)
+	if err != nil || t.LockedUntil == nil {
+		return 0
+	}
+
+	if wait := time.Until(*t.LockedUntil); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+func (s *AuthService) ensureLoginAllowed(email string, ip string) error {
+	wait := s.lockedFor(models.ThrottleScopeAccount, normalizeLoginKey(email))
+
+	if ipWait := s.lockedFor(models.ThrottleScopeIP, ip); ipWait > wait {
+		wait = ipWait
+	}
+
+	if wait > 0 {
+		return tooManyAttempts(wait)
+	}
+	return nil
+}
+
+// user is nil when the email is unknown (still counted, same as a real account)
+func (s *AuthService) recordLoginFailure(
+	email string,
+	user *models.User,
+	ip string,
+	userAgent string,
+) {
+	threshold := s.cfg.Lockout.AccountThreshold
+	t, err := s.throttleRepo.RecordFailure(
+		models.ThrottleScopeAccount,
+		normalizeLoginKey(email),
+		s.cfg.Lockout.Window,
+		func(n int) time.Duration { return s.lockoutDelay(n, threshold) },
+	)
+	if err == nil && t.Failures >= threshold {
+		s.auditLockout(t, user, "account_locked", ip, userAgent)
+	}
+
+	ipThreshold := s.cfg.Lockout.IPThreshold
+	t, err = s.throttleRepo.RecordFailure(
+		models.ThrottleScopeIP,
+		ip,
+		s.cfg.Lockout.Window,
+		func(n int) time.Duration { return s.lockoutDelay(n, ipThreshold) },
+	)
+	if err == nil && t.Failures >= ipThreshold {
+		s.auditLockout(t, nil, "ip_locked", ip, userAgent)
+	}
+}
+
+func (s *AuthService) clearLoginFailures(email string) {
+	// IP counter is left alone: one valid account must not reset a spraying IP
+	_ = s.throttleRepo.Reset(models.ThrottleScopeAccount, normalizeLoginKey(email))
+}
+
+func (s *AuthService) ensureTwoFAAllowed(userID uuid.UUID) error {
+	if wait := s.lockedFor(models.ThrottleScopeTwoFA, userID.String()); wait > 0 {
+		return tooManyAttempts(wait)
+	}
+	return nil
+}
+
+func (s *AuthService) recordTwoFAFailure(
+	user *models.User,
+	ip string,
+	userAgent string,
+) {
+	// Burn the emailed code after too many wrong guesses
+	_ = s.repo.RegisterOTPFailure(user.ID, s.cfg.Lockout.OTPMaxAttempts)
+
+	threshold := s.cfg.Lockout.AccountThreshold
+	t, err := s.throttleRepo.RecordFailure(
+		models.ThrottleScopeTwoFA,
+		user.ID.String(),
+		s.cfg.Lockout.Window,
+		func(n int) time.Duration { return s.lockoutDelay(n, threshold) },
+	)
+	if err == nil && t.Failures >= threshold {
+		s.auditLockout(t, user, "2fa_locked", ip, userAgent)
+	}
+}
+
+func (s *AuthService) auditLockout(
+	t *models.LoginThrottle,
+	user *models.User,
+	action string,
+	ip string,
+	userAgent string,
+) {
+	if user != nil {
+		_ = s.auditRepo.Log("user", user.ID, action, user.ID, ip, userAgent)
+		return
+	}
+	_ = s.auditRepo.Log("login_throttle", t.ID, action, uuid.Nil, ip, userAgent)
+}
+
+/*
+=====================
+ Admin: Unlock Account
+=====================
+*/
+
+func (s *AuthService) UnlockUser(
+	userID uuid.UUID,
+	adminID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+	user, err := s.repo.FindUserByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if err := s.throttleRepo.Reset(
+		models.ThrottleScopeAccount,
+		normalizeLoginKey(user.Email),
+	); err != nil {
+		return err
+	}
+
+	if err := s.throttleRepo.Reset(
+		models.ThrottleScopeTwoFA,
+		user.ID.String(),
+	); err != nil {
+		return err
+	}
+
+	_ = s.auditRepo.Log("user", user.ID, "account_unlocked", adminID, ip, userAgent)
+
+	return nil
+}
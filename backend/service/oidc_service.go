@@ -0,0 +1,405 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+
+	"rbac/config"
+	"rbac/models"
+	"rbac/repository"
+	"rbac/utils"
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+type OIDCService struct {
+	repo     *repository.OIDCRepository
+	authRepo *repository.AuthRepository
+	staff    *repository.SCIMRepository // role sync + JIT, same path as SCIM
+	auth     *AuthService
+	cfg      config.OIDCConfig
+
+	// Discovery is lazy so a slow / down IdP doesn't block startup
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func NewOIDCService(
+	repo *repository.OIDCRepository,
+	authRepo *repository.AuthRepository,
+	staff *repository.SCIMRepository,
+	auth *AuthService,
+	cfg *config.Config,
+) *OIDCService {
+	return &OIDCService{
+		repo:     repo,
+		authRepo: authRepo,
+		staff:    staff,
+		auth:     auth,
+		cfg:      cfg.OIDC,
+	}
+}
+
+func (s *OIDCService) Enabled() bool {
+	return s.cfg.Enabled()
+}
+
+func (s *OIDCService) discover(ctx context.Context) (*oidc.Provider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.provider != nil {
+		return s.provider, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, s.cfg.IssuerURL)
+	if err != nil {
+		return nil, errors.New("sso provider unavailable")
+	}
+
+	s.provider = provider
+	return provider, nil
+}
+
+func (s *OIDCService) oauthConfig(provider *oidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     s.cfg.ClientID,
+		ClientSecret: s.cfg.ClientSecret,
+		RedirectURL:  s.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       s.cfg.Scopes,
+	}
+}
+
+/*
+=====================
+ Begin (redirect to IdP)
+=====================
+*/
+
+// Returns the IdP authorization URL and the raw state, which the
+// handler also pins in a cookie to bind the flow to this browser.
+func (s *OIDCService) BeginLogin(ctx context.Context) (string, string, error) {
+	if !s.Enabled() {
+		return "", "", errors.New("sso not configured")
+	}
+
+	provider, err := s.discover(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	// Opportunistic cleanup of abandoned logins
+	_ = s.repo.DeleteExpiredStates()
+
+	if err := s.repo.CreateState(&models.OIDCLoginState{
+		State:        utils.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	}); err != nil {
+		return "", "", err
+	}
+
+	url := s.oauthConfig(provider).AuthCodeURL(
+		state,
+		oidc.Nonce(nonce),
+		oauth2.S256ChallengeOption(verifier),
+	)
+
+	return url, state, nil
+}
+
+/*
+=====================
+ Callback (code → tokens)
+=====================
+*/
+
+type oidcIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Groups        []string
+}
+
+func (s *OIDCService) FinishLogin(
+	ctx context.Context,
+	state string,
+	code string,
+	ip string,
+	userAgent string,
+) (*LoginResponse, error) {
+
+	if !s.Enabled() {
+		return nil, errors.New("sso not configured")
+	}
+
+	pending, err := s.repo.TakeState(utils.HashToken(state))
+	if err != nil {
+		return nil, errors.New("sso session expired")
+	}
+
+	provider, err := s.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1️⃣ Exchange code (PKCE)
+	token, err := s.oauthConfig(provider).Exchange(
+		ctx,
+		code,
+		oauth2.VerifierOption(pending.CodeVerifier),
+	)
+	if err != nil {
+		return nil, errors.New("sso code exchange failed")
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("sso response missing id_token")
+	}
+
+	// 2️⃣ Verify ID token (signature, iss, aud, exp) + nonce
+	idToken, err := provider.
+		Verifier(&oidc.Config{ClientID: s.cfg.ClientID}).
+		Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, errors.New("invalid sso id_token")
+	}
+	if idToken.Nonce != pending.Nonce {
+		return nil, errors.New("invalid sso nonce")
+	}
+
+	identity, err := s.parseIdentity(idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3️⃣ Map onto a local staff account
+	user, err := s.resolveUser(identity, ip, userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_ = s.authRepo.UpdateLastLogin(user.ID, &now)
+
+	_ = s.auth.auditRepo.Log("user", user.ID, "sso_login", user.ID, ip, userAgent)
+
+	// IdP enforces its own MFA → no local second factor
+	return s.auth.issueTokens(user, utils.NewAuthContext(utils.AMRFederated), ip, userAgent)
+}
+
+func (s *OIDCService) parseIdentity(idToken *oidc.IDToken) (*oidcIdentity, error) {
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	identity := &oidcIdentity{Subject: idToken.Subject}
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+
+	// Some IdPs send "true" as a string
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = v
+	case string:
+		identity.EmailVerified = v == "true"
+	}
+
+	switch v := claims[s.cfg.GroupsClaim].(type) {
+	case []interface{}:
+		for _, g := range v {
+			if name, ok := g.(string); ok {
+				identity.Groups = append(identity.Groups, name)
+			}
+		}
+	case string:
+		identity.Groups = []string{v}
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, errors.New("sso email not verified")
+	}
+
+	if !s.domainAllowed(identity.Email) {
+		return nil, errors.New("sso email domain not allowed")
+	}
+
+	return identity, nil
+}
+
+func (s *OIDCService) domainAllowed(email string) bool {
+	if len(s.cfg.AllowedDomains) == 0 {
+		return true
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+
+	domain := strings.ToLower(email[at+1:])
+	for _, allowed := range s.cfg.AllowedDomains {
+		if domain == strings.ToLower(allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Admin group wins over support; no staff group = no SSO access
+func (s *OIDCService) mapRole(groups []string) (models.Role, bool) {
+	has := func(wanted []string) bool {
+		for _, g := range groups {
+			for _, w := range wanted {
+				if strings.EqualFold(g, w) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	switch {
+	case has(s.cfg.AdminGroups):
+		return models.RoleAdmin, true
+	case has(s.cfg.SupportGroups):
+		return models.RoleSupport, true
+	}
+	return "", false
+}
+
+func (s *OIDCService) resolveUser(
+	identity *oidcIdentity,
+	ip string,
+	userAgent string,
+) (*models.User, error) {
+
+	role, ok := s.mapRole(identity.Groups)
+	if !ok {
+		return nil, errors.New("not a member of a staff group")
+	}
+
+	// Linked subject first, then verified email
+	user, err := s.authRepo.FindUserByOIDCSubject(identity.Subject)
+	if err != nil {
+		user, err = s.authRepo.FindAnyUserByEmail(identity.Email)
+	}
+
+	if err != nil {
+		if !s.cfg.JITProvisioning {
+			return nil, errors.New("no account for this sso identity")
+		}
+		return s.provisionUser(identity, role, ip, userAgent)
+	}
+
+	if !user.IsActive {
+		return nil, ErrAccountInactive
+	}
+
+	// Customers never sign in through staff SSO
+	if user.Role == models.RoleCustomer {
+		return nil, errors.New("sso is for staff accounts only")
+	}
+
+	// The IdP vouched for this address
+	if user.EmailVerifiedAt == nil && strings.EqualFold(user.Email, identity.Email) {
+		_ = s.authRepo.MarkEmailVerified(user.ID)
+	}
+
+	if user.OIDCSubject == nil {
+		if err := s.authRepo.LinkOIDCSubject(user.ID, identity.Subject); err != nil {
+			return nil, err
+		}
+		user.OIDCSubject = &identity.Subject
+		_ = s.auth.auditRepo.Log("user", user.ID, "sso_linked", user.ID, ip, userAgent)
+	} else if *user.OIDCSubject != identity.Subject {
+		return nil, errors.New("account linked to a different sso identity")
+	}
+
+	// IdP groups are the source of truth for admin vs support; custom
+	// roles are granted in the app and left alone
+	builtIn := user.Role == models.RoleAdmin || user.Role == models.RoleSupport
+	if builtIn && user.Role != role {
+		if err := changeStaffRole(s.staff, s.auth.revocations, user, role); err != nil {
+			return nil, err
+		}
+		_ = s.auth.auditRepo.Log("user", user.ID, "sso_role_synced:"+string(role), user.ID, ip, userAgent)
+	}
+
+	return user, nil
+}
+
+func (s *OIDCService) provisionUser(
+	identity *oidcIdentity,
+	role models.Role,
+	ip string,
+	userAgent string,
+) (*models.User, error) {
+
+	// Unusable local password: SSO-only until an admin resets it
+	random, err := utils.GenerateRandomToken(48)
+	if err != nil {
+		return nil, err
+	}
+	hashed, err := s.auth.passwords.Hash(random)
+	if err != nil {
+		return nil, err
+	}
+
+	name := identity.Name
+	if name == "" {
+		name = identity.Email
+	}
+
+	subject := identity.Subject
+	verifiedAt := time.Now()
+	user := &models.User{
+		Name:        name,
+		Email:       strings.ToLower(identity.Email),
+		Password:    hashed,
+		Role:        role,
+		IsActive:    true,
+		OIDCSubject: &subject,
+
+		EmailVerifiedAt: &verifiedAt,
+	}
+
+	err = s.staff.WithTransaction(func(tx *repository.SCIMRepository) error {
+		if err := tx.CreateUser(user); err != nil {
+			return err
+		}
+		if role == models.RoleSupport {
+			return tx.EnsureSupportProfile(user.ID, true, nil)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.auth.auditRepo.Log("user", user.ID, "sso_provisioned", uuid.Nil, ip, userAgent)
+
+	return user, nil
+}
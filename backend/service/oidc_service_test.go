@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"rbac/models"
+	"rbac/repository"
+	"rbac/testutil"
+	"rbac/utils"
+)
+
+func newTestOIDCService(t *testing.T, jit bool) (*OIDCService, *AuthService, *testutil.OIDCProvider) {
+	t.Helper()
+
+	auth, db, cfg := newTestAuthService(t)
+	idp := testutil.NewOIDCProvider(t, "ticketing")
+
+	cfg.OIDC.IssuerURL = idp.URL
+	cfg.OIDC.ClientID = idp.ClientID
+	cfg.OIDC.ClientSecret = "secret"
+	cfg.OIDC.RedirectURL = "http://localhost:8080/api/v1/auth/oidc/callback"
+	cfg.OIDC.Scopes = []string{"openid", "email", "profile"}
+	cfg.OIDC.GroupsClaim = "groups"
+	cfg.OIDC.AdminGroups = []string{"Admins"}
+	cfg.OIDC.SupportGroups = []string{"Engineers"}
+	cfg.OIDC.JITProvisioning = jit
+
+	svc := NewOIDCService(
+		repository.NewOIDCRepository(db),
+		repository.NewAuthRepository(db),
+		repository.NewSCIMRepository(db),
+		auth,
+		cfg,
+	)
+	return svc, auth, idp
+}
+
+func ssoClaims(email string, groups ...string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":            "sub-" + email,
+		"email":          email,
+		"email_verified": true,
+		"name":           "SSO User",
+		"groups":         groups,
+	}
+}
+
+// Full round trip: app → IdP → callback
+func ssoLogin(t *testing.T, svc *OIDCService, idp *testutil.OIDCProvider, claims jwt.MapClaims) (*LoginResponse, error) {
+	t.Helper()
+
+	authURL, pinned, err := svc.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, code := idp.Authorize(t, authURL, claims)
+	if state != pinned {
+		t.Fatal("state sent to the IdP differs from the pinned one")
+	}
+	return svc.FinishLogin(context.Background(), state, code, "", "")
+}
+
+func reloadUser(t *testing.T, auth *AuthService, id uuid.UUID) *models.User {
+	t.Helper()
+
+	var user models.User
+	if err := auth.db.First(&user, "id = ?", id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &user
+}
+
+func TestSSOProvisionsStaffJustInTime(t *testing.T) {
+	svc, auth, idp := newTestOIDCService(t, true)
+
+	resp, err := ssoLogin(t, svc, idp, ssoClaims("new.engineer@example.com", "Engineers"))
+	if err != nil {
+		t.Fatalf("sso login: %v", err)
+	}
+	if resp.User.Role != models.RoleSupport {
+		t.Fatalf("provisioned role = %s, want support", resp.User.Role)
+	}
+
+	claims, err := utils.ValidateToken(resp.AccessToken, auth.keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(claims.AMR, utils.AMRFederated) {
+		t.Fatalf("sso token methods = %v", claims.AMR)
+	}
+
+	var profiles int64
+	auth.db.Model(&models.SupportEngineer{}).Where("user_id = ?", resp.User.ID).Count(&profiles)
+	if profiles != 1 {
+		t.Fatal("provisioned support user has no engineer profile")
+	}
+
+	// Next login finds the linked subject instead of provisioning again
+	again, err := ssoLogin(t, svc, idp, ssoClaims("new.engineer@example.com", "Engineers"))
+	if err != nil || again.User.ID != resp.User.ID {
+		t.Fatalf("second sso login: %v", err)
+	}
+}
+
+func TestSSOWithoutJITNeedsAnAccount(t *testing.T) {
+	svc, _, idp := newTestOIDCService(t, false)
+
+	if _, err := ssoLogin(t, svc, idp, ssoClaims("stranger@example.com", "Engineers")); err == nil {
+		t.Fatal("unknown identity signed in with provisioning off")
+	}
+}
+
+func TestSSORejectsNonStaffGroups(t *testing.T) {
+	svc, _, idp := newTestOIDCService(t, true)
+
+	if _, err := ssoLogin(t, svc, idp, ssoClaims("sales@example.com", "Sales")); err == nil {
+		t.Fatal("identity outside the staff groups signed in")
+	}
+}
+
+func TestSSOStateIsSingleUse(t *testing.T) {
+	svc, _, idp := newTestOIDCService(t, true)
+
+	authURL, _, err := svc.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, code := idp.Authorize(t, authURL, ssoClaims("eng@example.com", "Engineers"))
+
+	if _, err := svc.FinishLogin(context.Background(), "forged-state", code, "", ""); err == nil {
+		t.Fatal("unknown state accepted")
+	}
+	if _, err := svc.FinishLogin(context.Background(), state, code, "", ""); err != nil {
+		t.Fatalf("sso login: %v", err)
+	}
+	if _, err := svc.FinishLogin(context.Background(), state, code, "", ""); err == nil {
+		t.Fatal("state replayed")
+	}
+}
+
+func TestSSOCodeNeedsThePKCEVerifier(t *testing.T) {
+	svc, auth, idp := newTestOIDCService(t, true)
+
+	authURL, _, err := svc.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, code := idp.Authorize(t, authURL, ssoClaims("eng@example.com", "Engineers"))
+
+	// An intercepted code is useless without the verifier kept server-side
+	auth.db.Model(&models.OIDCLoginState{}).
+		Where("state = ?", utils.HashToken(state)).
+		Update("code_verifier", "not-the-verifier-the-challenge-was-made-from-0123456789")
+
+	if _, err := svc.FinishLogin(context.Background(), state, code, "", ""); err == nil {
+		t.Fatal("code redeemed with the wrong PKCE verifier")
+	}
+}
+
+func TestSSORejectsNonceMismatch(t *testing.T) {
+	svc, _, idp := newTestOIDCService(t, true)
+
+	claims := ssoClaims("eng@example.com", "Engineers")
+	claims["nonce"] = "replayed-id-token-nonce"
+
+	if _, err := ssoLogin(t, svc, idp, claims); err == nil {
+		t.Fatal("id_token with a foreign nonce accepted")
+	}
+}
+
+func TestSSOSyncsBuiltInRolesFromGroups(t *testing.T) {
+	svc, auth, idp := newTestOIDCService(t, false)
+	testutil.User(t, auth.db, models.RoleAdmin) // someone else stays admin
+	user := testutil.User(t, auth.db, models.RoleAdmin)
+
+	if _, err := ssoLogin(t, svc, idp, ssoClaims(user.Email, "Engineers")); err != nil {
+		t.Fatalf("sso login: %v", err)
+	}
+
+	if got := reloadUser(t, auth, user.ID); got.Role != models.RoleSupport {
+		t.Fatalf("role after sync = %s, want support", got.Role)
+	}
+
+	var profiles, cutoffs int64
+	auth.db.Model(&models.SupportEngineer{}).Where("user_id = ?", user.ID).Count(&profiles)
+	auth.db.Model(&models.UserTokenCutoff{}).Where("user_id = ?", user.ID).Count(&cutoffs)
+	if profiles != 1 || cutoffs != 1 {
+		t.Fatalf("demotion: %d engineer profiles, %d token cutoffs", profiles, cutoffs)
+	}
+}
+
+func TestSSOKeepsTheLastAdmin(t *testing.T) {
+	svc, auth, idp := newTestOIDCService(t, false)
+	admin := testutil.User(t, auth.db, models.RoleAdmin)
+
+	if _, err := ssoLogin(t, svc, idp, ssoClaims(admin.Email, "Engineers")); err == nil {
+		t.Fatal("sso demoted the last active admin")
+	}
+	if got := reloadUser(t, auth, admin.ID); got.Role != models.RoleAdmin {
+		t.Fatalf("last admin role = %s", got.Role)
+	}
+}
+
+func TestSSOLeavesCustomRolesAlone(t *testing.T) {
+	svc, auth, idp := newTestOIDCService(t, false)
+	lead := testutil.User(t, auth.db, models.Role("field_lead"))
+
+	resp, err := ssoLogin(t, svc, idp, ssoClaims(lead.Email, "Engineers"))
+	if err != nil {
+		t.Fatalf("sso login: %v", err)
+	}
+	if resp.User.Role != "field_lead" || reloadUser(t, auth, lead.ID).Role != "field_lead" {
+		t.Fatal("sso replaced a custom role")
+	}
+}
+
+func TestSSORefusesCustomersWithoutTouchingThem(t *testing.T) {
+	svc, auth, idp := newTestOIDCService(t, false)
+	customer := testutil.User(t, auth.db, models.RoleCustomer)
+
+	if _, err := ssoLogin(t, svc, idp, ssoClaims(customer.Email, "Engineers")); err == nil {
+		t.Fatal("customer signed in through staff sso")
+	}
+	if got := reloadUser(t, auth, customer.ID); got.EmailVerifiedAt != nil || got.OIDCSubject != nil {
+		t.Fatal("refused sso login still changed the customer account")
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"log"
+
+	"github.com/google/uuid"
+
+	"rbac/utils"
+)
+
+/*
+=====================
+ Password Policy
+=====================
+ Every password change goes through setPassword: strength rules and
+ blocklist (utils.PasswordPolicy), then the last N passwords, then the
+ configured hash. Old hashes move to password_histories.
+*/
+
+func (s *AuthService) setPassword(
+	userID uuid.UUID,
+	currentHash string,
+	newPassword string,
+	mustReset bool,
+) error {
+
+	if err := s.passwords.Validate(newPassword); err != nil {
+		return weakPassword(err)
+	}
+
+	reused, err := s.passwordReused(userID, currentHash, newPassword)
+	if err != nil {
+		return err
+	}
+	if reused {
+		return ErrPasswordReused
+	}
+
+	hashed, err := s.passwords.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateUserPassword(userID, hashed, mustReset); err != nil {
+		return err
+	}
+
+	if keep := s.cfg.Password.HistorySize; keep > 0 && currentHash != "" {
+		if err := s.repo.AddPasswordHistory(userID, currentHash, keep); err != nil {
+			log.Printf("⚠️  failed to record password history for %s: %v", userID, err)
+		}
+	}
+
+	return nil
+}
+
+// Current password plus the last HistorySize ones
+func (s *AuthService) passwordReused(
+	userID uuid.UUID,
+	currentHash string,
+	password string,
+) (bool, error) {
+
+	if s.cfg.Password.HistorySize <= 0 {
+		return false, nil
+	}
+
+	hashes, err := s.repo.RecentPasswordHashes(userID, s.cfg.Password.HistorySize)
+	if err != nil {
+		return false, err
+	}
+	if currentHash != "" {
+		hashes = append(hashes, currentHash)
+	}
+
+	for _, h := range hashes {
+		if utils.CheckPassword(password, h) == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Called right after a successful password check: bcrypt (or weaker
+// Argon2 parameters) is replaced transparently with the configured hash.
+func (s *AuthService) upgradePasswordHash(userID uuid.UUID, currentHash, password string) {
+	if !s.passwords.NeedsRehash(currentHash) {
+		return
+	}
+
+	hashed, err := s.passwords.Hash(password)
+	if err != nil {
+		log.Printf("⚠️  password rehash failed for %s: %v", userID, err)
+		return
+	}
+
+	if err := s.repo.UpdatePasswordHash(userID, hashed); err != nil {
+		log.Printf("⚠️  password rehash failed for %s: %v", userID, err)
+	}
+}
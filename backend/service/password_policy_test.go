@@ -0,0 +1,111 @@
+package service
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"rbac/models"
+	"rbac/testutil"
+	"rbac/utils"
+)
+
+// Legacy bcrypt hashes turn into the configured Argon2id hash on the
+// first successful login, and the password keeps working
+func TestLoginUpgradesBcryptToArgon2id(t *testing.T) {
+	auth, db, cfg := newTestAuthService(t)
+
+	cfg.Password.Algorithm = utils.HashArgon2id
+	cfg.Password.Argon2Time, cfg.Password.Argon2Memory, cfg.Password.Argon2Threads = 1, 64, 1
+	passwords, err := utils.NewPasswordPolicy(cfg.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth.passwords = passwords
+
+	user := testutil.User(t, db, models.RoleSupport)
+	legacy, err := bcrypt.GenerateFromPassword([]byte("Legacy-pass-1!"), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Model(user).Update("password", string(legacy))
+
+	login := func(password string) error {
+		t.Helper()
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("POST", "/api/v1/auth/login", nil)
+		_, err := auth.Login(c, user.Email, password, false, "", "")
+		return err
+	}
+
+	if login("wrong-pass") == nil {
+		t.Fatal("wrong password accepted")
+	}
+	if stored := reloadUser(t, auth, user.ID); stored.Password != string(legacy) {
+		t.Fatal("failed login rewrote the hash")
+	}
+
+	if err := login("Legacy-pass-1!"); err != nil {
+		t.Fatal(err)
+	}
+	upgraded := reloadUser(t, auth, user.ID).Password
+	if !strings.HasPrefix(upgraded, "$argon2id$") {
+		t.Fatalf("hash after login = %.12s…, want argon2id", upgraded)
+	}
+
+	if err := utils.CheckPassword("Legacy-pass-1!", upgraded); err != nil {
+		t.Fatalf("password no longer matches the upgraded hash: %v", err)
+	}
+	if auth.passwords.NeedsRehash(upgraded) {
+		t.Fatal("upgraded hash still flagged for rehash")
+	}
+}
+
+// The current password and the last HistorySize ones can't come back;
+// older ones can
+func TestChangePasswordRefusesRecentPasswords(t *testing.T) {
+	auth, db, cfg := newTestAuthService(t)
+	cfg.Password.HistorySize = 2
+
+	user := testutil.User(t, db, models.RoleSupport)
+	initial, err := auth.passwords.Hash("Initial-pass-0!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Model(user).Update("password", initial)
+
+	change := func(from, to string) error {
+		t.Helper()
+		return auth.ChangePassword(user.ID, from, to, user.Role, "", "")
+	}
+
+	steps := []struct {
+		from, to string
+		reused   bool
+	}{
+		{"Initial-pass-0!", "Initial-pass-0!", true}, // current
+		{"Initial-pass-0!", "Second-pass-1!", false},
+		{"Second-pass-1!", "Initial-pass-0!", true}, // in history
+		{"Second-pass-1!", "Third-pass-2!", false},
+		{"Third-pass-2!", "Fourth-pass-3!", false},
+		{"Fourth-pass-3!", "Second-pass-1!", true},   // still one of the last two
+		{"Fourth-pass-3!", "Initial-pass-0!", false}, // aged out
+	}
+
+	for i, step := range steps {
+		err := change(step.from, step.to)
+		if step.reused != errors.Is(err, ErrPasswordReused) || (!step.reused && err != nil) {
+			t.Fatalf("step %d (%s → %s): %v", i, step.from, step.to, err)
+		}
+	}
+
+	var kept int64
+	db.Model(&models.PasswordHistory{}).Where("user_id = ?", user.ID).Count(&kept)
+	if kept != int64(cfg.Password.HistorySize) {
+		t.Fatalf("history rows = %d, want %d", kept, cfg.Password.HistorySize)
+	}
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/config"
+	"rbac/models"
+	"rbac/repository"
+)
+
+/*
+=====================
+ Resource Policies
+=====================
+ Permissions say what a role may do in general; policies say whether
+ this actor may do it to this ticket / contract / product. A rule
+ passes when the actor holds ANY of its relations, or the override
+ permission (staff who oversee every record).
+*/
+
+type PolicyAction string
+
+const (
+	ActionTicketView     PolicyAction = "ticket.view"
+	ActionTicketWork     PolicyAction = "ticket.work" // start / close
+	ActionTicketFeedback PolicyAction = "ticket.feedback"
+	ActionTicketReassign PolicyAction = "ticket.reassign"
+
+	ActionAMCView             PolicyAction = "amc.view"
+	ActionCustomerProductView PolicyAction = "customer_product.view"
+)
+
+type relation string
+
+const (
+	relAssignee  relation = "assignee"  // current engineer on the ticket
+	relOwner     relation = "owner"     // raised the ticket / member of the owning organization
+	relColleague relation = "colleague" // same organization, with company-wide ticket visibility
+	relTeamLead  relation = "team_lead" // leads the ticket's team or the assignee's team
+)
+
+type policyRule struct {
+	relations []relation
+	override  models.Permission // "" = no override
+}
+
+var policyRules = map[PolicyAction]policyRule{
+	ActionTicketView:     {relations: []relation{relAssignee, relOwner, relColleague, relTeamLead}, override: models.PermTicketRead},
+	ActionTicketWork:     {relations: []relation{relAssignee}},
+	ActionTicketFeedback: {relations: []relation{relOwner}},
+	ActionTicketReassign: {relations: []relation{relTeamLead}, override: models.PermTicketAssign},
+
+	ActionAMCView:             {relations: []relation{relOwner}, override: models.PermAMCRead},
+	ActionCustomerProductView: {relations: []relation{relOwner}, override: models.PermProductRead},
+}
+
+type PolicyService struct {
+	repo  *repository.PolicyRepository
+	roles *RoleService
+	cfg   *config.Config
+}
+
+func NewPolicyService(
+	repo *repository.PolicyRepository,
+	roles *RoleService,
+	cfg *config.Config,
+) *PolicyService {
+	return &PolicyService{
+		repo:  repo,
+		roles: roles,
+		cfg:   cfg,
+	}
+}
+
+// Status for a denied check: 404 unless configured to 403
+func (s *PolicyService) DenyStatus() int {
+	if s.cfg.Policy.DenyStatus == http.StatusForbidden {
+		return http.StatusForbidden
+	}
+	return http.StatusNotFound
+}
+
+// nil = allowed; ErrForbidden or ErrResourceNotFound otherwise
+func (s *PolicyService) Authorize(
+	actorID uuid.UUID,
+	role models.Role,
+	action PolicyAction,
+	resourceID uuid.UUID,
+) error {
+
+	rule, ok := policyRules[action]
+	if !ok {
+		return ErrForbidden
+	}
+
+	parties, err := s.parties(action, resourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrResourceNotFound
+		}
+		return err
+	}
+
+	if rule.override != "" {
+		allowed, err := s.roles.HasPermission(role, rule.override)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			return nil
+		}
+	}
+
+	membership, err := s.repo.Membership(actorID)
+	if err != nil {
+		return err
+	}
+
+	for _, rel := range rule.relations {
+		if parties.heldBy(rel, actorID, membership) {
+			return nil
+		}
+	}
+
+	return ErrForbidden
+}
+
+// Who a resource belongs to. Tickets have a raising user (and maybe an
+// assignee); contracts and products belong to the organization only.
+type resourceParties struct {
+	owner        uuid.UUID
+	organization uuid.UUID
+	assignee     uuid.UUID
+	teamLeads    []uuid.UUID
+}
+
+func (s *PolicyService) parties(action PolicyAction, resourceID uuid.UUID) (*resourceParties, error) {
+	p := &resourceParties{}
+	var err error
+
+	switch action {
+	case ActionTicketView, ActionTicketWork, ActionTicketFeedback, ActionTicketReassign:
+		if p.owner, err = s.repo.TicketOwner(resourceID); err != nil {
+			return nil, err
+		}
+		if p.assignee, err = s.repo.TicketAssignee(resourceID); err != nil {
+			return nil, err
+		}
+		ownerMembership, err := s.repo.Membership(p.owner)
+		if err != nil {
+			return nil, err
+		}
+		if ownerMembership != nil {
+			p.organization = ownerMembership.CustomerID
+		}
+		if p.teamLeads, err = s.repo.TicketTeamLeads(resourceID, p.assignee); err != nil {
+			return nil, err
+		}
+
+	case ActionAMCView:
+		p.organization, err = s.repo.AMCOrganization(resourceID)
+	case ActionCustomerProductView:
+		p.organization, err = s.repo.CustomerProductOrganization(resourceID)
+	default:
+		err = ErrForbidden
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// membership is the actor's (nil for staff and unattached users)
+func (p *resourceParties) heldBy(
+	rel relation,
+	actorID uuid.UUID,
+	membership *models.CustomerMember,
+) bool {
+
+	inOrganization := membership != nil &&
+		p.organization != uuid.Nil &&
+		membership.CustomerID == p.organization
+
+	switch rel {
+	case relAssignee:
+		return p.assignee != uuid.Nil && p.assignee == actorID
+	case relOwner:
+		if p.owner != uuid.Nil {
+			return p.owner == actorID
+		}
+		return inOrganization
+	case relColleague:
+		return inOrganization && membership.SeesAllTickets()
+	case relTeamLead:
+		for _, lead := range p.teamLeads {
+			if lead == actorID {
+				return true
+			}
+		}
+	}
+	return false
+}
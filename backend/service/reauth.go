@@ -0,0 +1,121 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rbac/models"
+	"rbac/utils"
+)
+
+/*
+=====================
+ Step-up Re-authentication
+=====================
+ Sensitive routes (middleware.RequireRecentAuth) want a fresh auth_time.
+ Proving the password and/or a TOTP / email code again mints a new access
+ token with auth_time = now; the refresh session is left as it was.
+*/
+
+type ReauthResponse struct {
+	AccessToken string    `json:"access_token"`
+	AuthTime    time.Time `json:"auth_time"`
+	AMR         []string  `json:"amr"`
+}
+
+// session is the caller's refresh-token family; the new token stays in it
+func (s *AuthService) Reauthenticate(
+	userID uuid.UUID,
+	session uuid.UUID,
+	password string,
+	code string,
+	ip string,
+	userAgent string,
+) (*ReauthResponse, error) {
+
+	if password == "" && code == "" {
+		return nil, errors.New("password or code required")
+	}
+
+	user, err := s.repo.FindUserByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	var methods []string
+
+	// 🔑 Password (shares the login lockout counters)
+	if password != "" {
+		if err := s.ensureLoginAllowed(user.Email, ip); err != nil {
+			return nil, err
+		}
+
+		if err := utils.CheckPassword(password, user.Password); err != nil {
+			s.recordLoginFailure(user.Email, user, ip, userAgent)
+			return nil, ErrInvalidCredentials
+		}
+
+		s.clearLoginFailures(user.Email)
+		methods = append(methods, utils.AMRPassword)
+	}
+
+	// 🔐 TOTP / emailed OTP (shares the 2FA lockout counters)
+	if code != "" {
+		if !user.TwoFAEnabled {
+			return nil, ErrTwoFANotEnabled
+		}
+
+		if err := s.ensureTwoFAAllowed(user.ID); err != nil {
+			return nil, err
+		}
+
+		if !s.verifySecondFactor(user, code) {
+			s.recordTwoFAFailure(user, ip, userAgent)
+			return nil, ErrInvalidOTP
+		}
+
+		_ = s.throttleRepo.Reset(models.ThrottleScopeTwoFA, user.ID.String())
+		methods = append(methods, utils.AMROTP)
+	}
+
+	if len(methods) > 1 {
+		methods = append(methods, utils.AMRMultiFactor)
+	}
+
+	auth := utils.NewAuthContext(methods...)
+	auth.Session = session
+
+	accessToken, err := utils.GenerateAccessToken(
+		user,
+		s.keys,
+		s.cfg.JWT.AccessExpiry,
+		auth,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.auditRepo.Log("user", user.ID, "reauthenticated", user.ID, ip, userAgent)
+
+	return &ReauthResponse{
+		AccessToken: accessToken,
+		AuthTime:    auth.Time,
+		AMR:         auth.Methods,
+	}, nil
+}
+
+// Email users need a code before they can step up with it
+func (s *AuthService) SendReauthOTP(userID uuid.UUID) error {
+	user, err := s.repo.FindUserByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if !user.TwoFAEnabled {
+		return ErrTwoFANotEnabled
+	}
+
+	return s.sendOTP(user)
+}
@@ -0,0 +1,463 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/models"
+	"rbac/repository"
+)
+
+/*
+=====================
+ Roles & Permissions
+=====================
+ Routes check permissions (middleware.RequirePermission), never role
+ names. admin always holds every permission; customer is bound to the
+ customer portal. Everything else is an editable permission set.
+*/
+
+// Other instances pick up role edits within this window
+const rolePermissionsTTL = 30 * time.Second
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{2,19}$`)
+
+type RoleService struct {
+	repo        *repository.RoleRepository
+	auditRepo   *repository.AuditRepository
+	revocations repository.TokenRevocationStore
+
+	mu       sync.RWMutex
+	perms    map[models.Role]map[models.Permission]struct{}
+	loadedAt time.Time
+}
+
+func NewRoleService(
+	repo *repository.RoleRepository,
+	auditRepo *repository.AuditRepository,
+	revocations repository.TokenRevocationStore,
+) *RoleService {
+	return &RoleService{
+		repo:        repo,
+		auditRepo:   auditRepo,
+		revocations: revocations,
+	}
+}
+
+/*
+=====================
+ Response DTOs
+=====================
+*/
+
+type RoleInfo struct {
+	Name        models.Role         `json:"name"`
+	DisplayName string              `json:"display_name"`
+	Description string              `json:"description"`
+	Permissions []models.Permission `json:"permissions"`
+	IsSystem    bool                `json:"is_system"`
+	ReadOnly    bool                `json:"read_only"`
+	UserCount   int64               `json:"user_count"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+type RoleInput struct {
+	Name        models.Role
+	DisplayName string
+	Description string
+	Permissions []models.Permission
+}
+
+/*
+=====================
+ Built-in Roles
+=====================
+*/
+
+func systemRoles() []models.RoleDefinition {
+	return []models.RoleDefinition{
+		{
+			Name:        models.RoleAdmin,
+			DisplayName: "Administrator",
+			Description: "Full access",
+			Permissions: models.AllPermissions(),
+			IsSystem:    true,
+		},
+		{
+			Name:        models.RoleSupport,
+			DisplayName: "Support Engineer",
+			Description: "Works tickets assigned to them",
+			Permissions: []models.Permission{models.PermTicketWork},
+			IsSystem:    true,
+		},
+		{
+			Name:        models.RoleCustomer,
+			DisplayName: "Customer",
+			Description: "Customer portal",
+			Permissions: []models.Permission{},
+			IsSystem:    true,
+		},
+	}
+}
+
+// admin and customer are fixed; support's permission set is editable
+func roleReadOnly(name models.Role) bool {
+	return name == models.RoleAdmin || name == models.RoleCustomer
+}
+
+// Called at startup, after migrations
+func (s *RoleService) EnsureSystemRoles() error {
+	return s.repo.EnsureSystemRoles(systemRoles())
+}
+
+/*
+=====================
+ Permission Checks
+=====================
+*/
+
+func (s *RoleService) HasPermission(role models.Role, perm models.Permission) (bool, error) {
+	if role == models.RoleAdmin {
+		return true, nil
+	}
+
+	perms, err := s.permissions()
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := perms[role][perm]
+	return ok, nil
+}
+
+func (s *RoleService) PermissionsFor(role models.Role) ([]models.Permission, error) {
+	if role == models.RoleAdmin {
+		return models.AllPermissions(), nil
+	}
+
+	perms, err := s.permissions()
+	if err != nil {
+		return nil, err
+	}
+
+	// Catalog order, not map order
+	result := []models.Permission{}
+	for _, p := range models.AllPermissions() {
+		if _, ok := perms[role][p]; ok {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (s *RoleService) permissions() (map[models.Role]map[models.Permission]struct{}, error) {
+	s.mu.RLock()
+	perms, fresh := s.perms, time.Since(s.loadedAt) < rolePermissionsTTL
+	s.mu.RUnlock()
+
+	if perms != nil && fresh {
+		return perms, nil
+	}
+
+	roles, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	perms = make(map[models.Role]map[models.Permission]struct{}, len(roles))
+	for _, role := range roles {
+		set := make(map[models.Permission]struct{}, len(role.Permissions))
+		for _, p := range role.Permissions {
+			set[p] = struct{}{}
+		}
+		perms[role.Name] = set
+	}
+
+	s.mu.Lock()
+	s.perms, s.loadedAt = perms, time.Now()
+	s.mu.Unlock()
+
+	return perms, nil
+}
+
+func (s *RoleService) invalidate() {
+	s.mu.Lock()
+	s.perms = nil
+	s.mu.Unlock()
+}
+
+/*
+=====================
+ Admin: Manage Roles
+=====================
+*/
+
+func (s *RoleService) Catalog() []models.PermissionInfo {
+	return models.PermissionCatalog
+}
+
+func (s *RoleService) List() ([]*RoleInfo, error) {
+	roles, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*RoleInfo, 0, len(roles))
+	for i := range roles {
+		info, err := s.toRoleInfo(&roles[i])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+func (s *RoleService) Create(
+	in RoleInput,
+	createdBy uuid.UUID,
+	ip string,
+	userAgent string,
+) (*RoleInfo, error) {
+
+	name := models.Role(strings.ToLower(strings.TrimSpace(string(in.Name))))
+	if !roleNamePattern.MatchString(string(name)) {
+		return nil, fmt.Errorf("%w: name must be 3-20 characters of a-z, 0-9, _ or -", ErrInvalidRole)
+	}
+
+	perms, err := validatePermissions(in.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.FindByName(name); err == nil {
+		return nil, ErrRoleExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	role := &models.RoleDefinition{
+		Name:        name,
+		DisplayName: strings.TrimSpace(in.DisplayName),
+		Description: strings.TrimSpace(in.Description),
+		Permissions: perms,
+		CreatedBy:   &createdBy,
+	}
+	if role.DisplayName == "" {
+		role.DisplayName = string(name)
+	}
+
+	if err := s.repo.Create(role); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	_ = s.auditRepo.Log("role", role.ID, "role_created", createdBy, ip, userAgent)
+
+	return s.toRoleInfo(role)
+}
+
+// Name is immutable: users.role points at it
+func (s *RoleService) Update(
+	name models.Role,
+	in RoleInput,
+	updatedBy uuid.UUID,
+	ip string,
+	userAgent string,
+) (*RoleInfo, error) {
+
+	role, err := s.findRole(name)
+	if err != nil {
+		return nil, err
+	}
+	if roleReadOnly(role.Name) {
+		return nil, ErrRoleReadOnly
+	}
+
+	perms, err := validatePermissions(in.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	if d := strings.TrimSpace(in.DisplayName); d != "" {
+		role.DisplayName = d
+	}
+	role.Description = strings.TrimSpace(in.Description)
+	role.Permissions = perms
+
+	if err := s.repo.Update(role); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	_ = s.auditRepo.Log("role", role.ID, "role_updated", updatedBy, ip, userAgent)
+
+	return s.toRoleInfo(role)
+}
+
+func (s *RoleService) Delete(
+	name models.Role,
+	deletedBy uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	role, err := s.findRole(name)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return ErrRoleReadOnly
+	}
+
+	count, err := s.repo.CountUsers(role.Name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRoleInUse
+	}
+
+	if err := s.repo.Delete(role.ID); err != nil {
+		return err
+	}
+	s.invalidate()
+
+	_ = s.auditRepo.Log("role", role.ID, "role_deleted", deletedBy, ip, userAgent)
+
+	return nil
+}
+
+/*
+=====================
+ Admin: Assign Role
+=====================
+ Staff only: a customer account owns a Customer profile and tickets,
+ so it is never moved to or from the customer role. Only an admin (by
+ stored role, not token) assigns roles.
+*/
+
+func (s *RoleService) AssignRole(
+	userID uuid.UUID,
+	name models.Role,
+	assignedBy uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	assigner, err := s.repo.FindUser(assignedBy)
+	if err != nil || !assigner.IsActive || assigner.Role != models.RoleAdmin {
+		return ErrForbidden
+	}
+
+	role, err := s.findRole(name)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.repo.FindUser(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	if user.Role == role.Name {
+		return nil
+	}
+	if user.Role == models.RoleCustomer || role.Name == models.RoleCustomer {
+		return fmt.Errorf("%w: customer accounts cannot change role", ErrInvalidRole)
+	}
+
+	if user.Role == models.RoleAdmin && user.IsActive {
+		admins, err := s.repo.CountActiveAdmins()
+		if err != nil {
+			return err
+		}
+		if admins <= 1 {
+			return ErrLastAdmin
+		}
+	}
+
+	if err := s.repo.SetUserRole(user.ID, role.Name); err != nil {
+		return err
+	}
+
+	// Access tokens carry the role; refresh issues one with the new role
+	_ = s.revocations.RevokeUserTokens(user.ID, time.Now())
+
+	_ = s.auditRepo.Log("user", user.ID, "role_assigned:"+string(role.Name), assignedBy, ip, userAgent)
+
+	return nil
+}
+
+/*
+=====================
+ Helpers
+=====================
+*/
+
+func (s *RoleService) findRole(name models.Role) (*models.RoleDefinition, error) {
+	role, err := s.repo.FindByName(name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	return role, nil
+}
+
+// Known, grantable and de-duplicated (catalog order)
+func validatePermissions(in []models.Permission) ([]models.Permission, error) {
+	wanted := make(map[models.Permission]struct{}, len(in))
+	for _, p := range in {
+		info, ok := p.Info()
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown permission %s", ErrInvalidRole, p)
+		}
+		if info.AdminOnly {
+			return nil, fmt.Errorf("%w: %s is reserved for admins", ErrInvalidRole, p)
+		}
+		wanted[p] = struct{}{}
+	}
+
+	result := []models.Permission{}
+	for _, p := range models.AllPermissions() {
+		if _, ok := wanted[p]; ok {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (s *RoleService) toRoleInfo(role *models.RoleDefinition) (*RoleInfo, error) {
+	count, err := s.repo.CountUsers(role.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	perms := role.Permissions
+	if role.Name == models.RoleAdmin {
+		perms = models.AllPermissions()
+	}
+
+	return &RoleInfo{
+		Name:        role.Name,
+		DisplayName: role.DisplayName,
+		Description: role.Description,
+		Permissions: perms,
+		IsSystem:    role.IsSystem,
+		ReadOnly:    roleReadOnly(role.Name),
+		UserCount:   count,
+		UpdatedAt:   role.UpdatedAt,
+	}, nil
+}
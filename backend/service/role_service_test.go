@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/models"
+	"rbac/repository"
+	"rbac/testutil"
+)
+
+func newTestRoleService(t *testing.T) (*RoleService, *gorm.DB) {
+	t.Helper()
+
+	db := testutil.NewDB(t)
+	auditRepo := repository.NewAuditRepository(db)
+	roles := NewRoleService(
+		repository.NewRoleRepository(db),
+		auditRepo,
+		repository.NewPostgresTokenRevocationStore(db),
+	)
+	if err := roles.EnsureSystemRoles(); err != nil {
+		t.Fatal(err)
+	}
+	return roles, db
+}
+
+// The assigner's stored role counts, not whatever their token says
+func TestAssignRoleRequiresStoredAdmin(t *testing.T) {
+	roles, db := newTestRoleService(t)
+
+	admin := testutil.User(t, db, models.RoleAdmin)
+	lead := testutil.User(t, db, models.RoleSupport)
+	engineer := testutil.User(t, db, models.RoleSupport)
+
+	err := roles.AssignRole(lead.ID, models.RoleAdmin, lead.ID, "", "")
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatalf("support assigning admin to self: err = %v, want ErrForbidden", err)
+	}
+
+	err = roles.AssignRole(engineer.ID, models.RoleAdmin, lead.ID, "", "")
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatalf("support assigning admin: err = %v, want ErrForbidden", err)
+	}
+
+	if err := roles.AssignRole(engineer.ID, models.RoleAdmin, admin.ID, "", ""); err != nil {
+		t.Fatalf("admin assigning admin: %v", err)
+	}
+}
+
+// Creating an account grants its role: a custom role holding user.create
+// may only create roles it already covers
+func TestCreateUserRoleWithinCreatorPermissions(t *testing.T) {
+	auth, db, _ := newTestAuthService(t)
+	if err := repository.NewRoleRepository(db).EnsureSystemRoles(systemRoles()); err != nil {
+		t.Fatal(err)
+	}
+
+	customRole := func(name models.Role, perms ...models.Permission) {
+		if err := db.Create(&models.RoleDefinition{Name: name, DisplayName: string(name), Permissions: perms}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	customRole("dispatcher", models.PermUserCreate, models.PermTicketAssign)
+	customRole("trainee", models.PermTicketAssign)
+	customRole("supervisor", models.PermTicketAssign, models.PermUserUnlock)
+
+	admin := testutil.User(t, db, models.RoleAdmin)
+	dispatcher := testutil.User(t, db, "dispatcher")
+
+	create := func(role models.Role, by uuid.UUID) error {
+		_, _, err := auth.CreateUser("New", uuid.NewString()[:8]+"@example.com", role, by, "", "", "", "", "", nil)
+		return err
+	}
+
+	for _, role := range []models.Role{models.RoleAdmin, models.RoleSupport, "supervisor"} {
+		if err := create(role, dispatcher.ID); !errors.Is(err, ErrForbidden) {
+			t.Errorf("dispatcher creating %s: err = %v, want ErrForbidden", role, err)
+		}
+	}
+	for _, role := range []models.Role{"trainee", "dispatcher", models.RoleCustomer} {
+		if err := create(role, dispatcher.ID); err != nil {
+			t.Errorf("dispatcher creating %s: %v", role, err)
+		}
+	}
+	for _, role := range []models.Role{models.RoleAdmin, models.RoleSupport, "supervisor"} {
+		if err := create(role, admin.ID); err != nil {
+			t.Errorf("admin creating %s: %v", role, err)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+/*
+=====================
+ SCIM Filters (RFC 7644 §3.4.2.2)
+=====================
+ Subset IdPs actually send: `attr op value` terms joined with `and`.
+ `or`, `not` and parentheses are rejected as invalidFilter. Value-path
+ selectors (emails[type eq "work"].value) are accepted and ignored.
+*/
+
+type scimFilterTerm struct {
+	attr  string // lowercased, selectors stripped
+	op    string // lowercased
+	value interface{}
+}
+
+func parseSCIMFilter(filter string) ([]scimFilterTerm, error) {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return nil, nil
+	}
+
+	var terms []scimFilterTerm
+	for _, raw := range splitSCIMFilter(filter) {
+		term, err := parseSCIMTerm(raw)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+// Splits on top-level " and " (outside quotes and [...])
+func splitSCIMFilter(filter string) []string {
+	var (
+		parts   []string
+		start   int
+		depth   int
+		inQuote bool
+	)
+
+	lower := strings.ToLower(filter)
+	for i := 0; i < len(filter); i++ {
+		switch c := filter[i]; {
+		case c == '"' && (i == 0 || filter[i-1] != '\\'):
+			inQuote = !inQuote
+		case inQuote:
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case depth == 0 && strings.HasPrefix(lower[i:], " and "):
+			parts = append(parts, filter[start:i])
+			start = i + len(" and ")
+			i = start - 1
+		}
+	}
+
+	return append(parts, filter[start:])
+}
+
+func parseSCIMTerm(raw string) (scimFilterTerm, error) {
+	raw = stripSCIMSelectors(strings.TrimSpace(raw))
+
+	lower := strings.ToLower(raw)
+	if strings.HasPrefix(raw, "(") || strings.HasPrefix(lower, "not(") || strings.HasPrefix(lower, "not ") {
+		return scimFilterTerm{}, scimInvalidFilter("grouping and not are not supported")
+	}
+
+	fields := strings.SplitN(raw, " ", 3)
+	if len(fields) < 2 {
+		return scimFilterTerm{}, scimInvalidFilter("expected 'attribute operator value'")
+	}
+
+	term := scimFilterTerm{
+		attr: strings.ToLower(fields[0]),
+		op:   strings.ToLower(fields[1]),
+	}
+
+	switch term.op {
+	case "pr":
+		if len(fields) == 3 && strings.TrimSpace(fields[2]) != "" {
+			return term, scimInvalidFilter("pr takes no value")
+		}
+		return term, nil
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+	default:
+		return term, scimInvalidFilter("unsupported operator " + fields[1])
+	}
+
+	if len(fields) < 3 {
+		return term, scimInvalidFilter("missing value")
+	}
+
+	// `a eq "x" or b eq "y"` ends up here too and fails as a value
+	valueText := strings.TrimSpace(fields[2])
+	if err := json.Unmarshal([]byte(valueText), &term.value); err != nil {
+		return term, scimInvalidFilter("invalid value " + valueText)
+	}
+
+	return term, nil
+}
+
+// emails[type eq "work"].value → emails.value
+func stripSCIMSelectors(s string) string {
+	var b strings.Builder
+	depth := 0
+	inQuote := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' && (i == 0 || s[i-1] != '\\'):
+			inQuote = !inQuote
+		case !inQuote && c == '[':
+			depth++
+			continue
+		case !inQuote && c == ']':
+			depth--
+			continue
+		}
+		if depth == 0 {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+/* =====================
+   Users → SQL
+===================== */
+
+var scimUserColumns = map[string]string{
+	"username":          "email",
+	"emails":            "email",
+	"emails.value":      "email",
+	"displayname":       "name",
+	"name.formatted":    "name",
+	"externalid":        "scim_external_id",
+	"id":                "id",
+	"active":            "is_active",
+	"meta.created":      "created_at",
+	"meta.lastmodified": "updated_at",
+}
+
+func scimUserFilterClauses(filter string) ([]clause.Expression, error) {
+	terms, err := parseSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	exprs := make([]clause.Expression, 0, len(terms))
+	for _, t := range terms {
+		column, ok := scimUserColumns[t.attr]
+		if !ok {
+			return nil, scimInvalidFilter("unsupported attribute " + t.attr)
+		}
+
+		expr, err := scimColumnExpr(column, t)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	return exprs, nil
+}
+
+func scimColumnExpr(column string, t scimFilterTerm) (clause.Expression, error) {
+	if t.op == "pr" {
+		return clause.Expr{SQL: column + " IS NOT NULL"}, nil
+	}
+
+	switch column {
+	case "is_active":
+		b, ok := t.value.(bool)
+		if !ok || (t.op != "eq" && t.op != "ne") {
+			return nil, scimInvalidFilter("active supports eq / ne with true or false")
+		}
+		if t.op == "ne" {
+			b = !b
+		}
+		return clause.Expr{SQL: "is_active = ?", Vars: []interface{}{b}}, nil
+
+	case "id":
+		s, _ := t.value.(string)
+		id, err := uuid.Parse(s)
+		if err != nil || (t.op != "eq" && t.op != "ne") {
+			return clause.Expr{SQL: "1 = 0"}, nil
+		}
+		if t.op == "ne" {
+			return clause.Expr{SQL: "id <> ?", Vars: []interface{}{id}}, nil
+		}
+		return clause.Expr{SQL: "id = ?", Vars: []interface{}{id}}, nil
+
+	case "created_at", "updated_at":
+		s, _ := t.value.(string)
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, scimInvalidFilter("dates must be RFC 3339")
+		}
+		ops := map[string]string{"eq": "=", "ne": "<>", "gt": ">", "ge": ">=", "lt": "<", "le": "<="}
+		sqlOp, ok := ops[t.op]
+		if !ok {
+			return nil, scimInvalidFilter("unsupported operator for dates")
+		}
+		return clause.Expr{SQL: column + " " + sqlOp + " ?", Vars: []interface{}{ts}}, nil
+	}
+
+	// Strings: caseExact=false for every attribute we expose
+	s, ok := t.value.(string)
+	if !ok {
+		return nil, scimInvalidFilter(t.attr + " expects a string")
+	}
+
+	col := "LOWER(" + column + ")"
+	s = strings.ToLower(s)
+	like := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+
+	switch t.op {
+	case "eq":
+		return clause.Expr{SQL: col + " = ?", Vars: []interface{}{s}}, nil
+	case "ne":
+		return clause.Expr{SQL: col + " <> ?", Vars: []interface{}{s}}, nil
+	case "co":
+		return clause.Expr{SQL: col + " LIKE ?", Vars: []interface{}{"%" + like + "%"}}, nil
+	case "sw":
+		return clause.Expr{SQL: col + " LIKE ?", Vars: []interface{}{like + "%"}}, nil
+	case "ew":
+		return clause.Expr{SQL: col + " LIKE ?", Vars: []interface{}{"%" + like}}, nil
+	}
+
+	return nil, scimInvalidFilter("unsupported operator " + t.op + " for " + t.attr)
+}
+
+/* =====================
+   Groups (in memory)
+===================== */
+
+func scimGroupMatches(g *SCIMGroup, terms []scimFilterTerm) (bool, error) {
+	for _, t := range terms {
+		var actual string
+		switch t.attr {
+		case "displayname":
+			actual = g.DisplayName
+		case "id":
+			actual = g.ID
+		case "externalid":
+			actual = ""
+		default:
+			return false, scimInvalidFilter("unsupported attribute " + t.attr)
+		}
+
+		if t.op == "pr" {
+			if actual == "" {
+				return false, nil
+			}
+			continue
+		}
+
+		want, ok := t.value.(string)
+		if !ok {
+			return false, scimInvalidFilter(t.attr + " expects a string")
+		}
+		a, w := strings.ToLower(actual), strings.ToLower(want)
+
+		var match bool
+		switch t.op {
+		case "eq":
+			match = a == w
+		case "ne":
+			match = a != w
+		case "co":
+			match = strings.Contains(a, w)
+		case "sw":
+			match = strings.HasPrefix(a, w)
+		case "ew":
+			match = strings.HasSuffix(a, w)
+		default:
+			return false, scimInvalidFilter("unsupported operator " + t.op)
+		}
+		if !match {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func scimInvalidFilter(detail string) error {
+	return &SCIMError{
+		Status:   http.StatusBadRequest,
+		SCIMType: "invalidFilter",
+		Detail:   fmt.Sprintf("invalid filter: %s", detail),
+	}
+}
@@ -0,0 +1,1119 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/config"
+	"rbac/models"
+	"rbac/repository"
+	"rbac/utils"
+)
+
+/*
+=====================
+ SCIM 2.0 Provisioning
+=====================
+ The corporate IdP creates, updates and deprovisions staff accounts.
+ Groups are fixed and map onto roles:
+   cfg.SCIM.AdminGroup   (id "admin")   → RoleAdmin
+   cfg.SCIM.SupportGroup (id "support") → RoleSupport, and custom roles
+ Custom-role staff are listed in the support group; their role itself is
+ granted in the app and only changes when they join the admin group.
+ New users start as support; joining the admin group promotes, leaving it
+ demotes back to support. Deprovisioning (active=false or DELETE) is a
+ regular deactivation through UserLifecycleService, never a delete.
+*/
+
+const (
+	SCIMUserSchema  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SCIMGroupSchema = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SCIMListSchema  = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SCIMErrorSchema = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	SCIMMaxResults   = 200
+	scimDefaultCount = 100
+)
+
+type SCIMService struct {
+	repo        *repository.SCIMRepository
+	lifecycle   *UserLifecycleService
+	passwords   *utils.PasswordPolicy
+	auditRepo   *repository.AuditRepository
+	revocations repository.TokenRevocationStore
+	cfg         *config.Config
+}
+
+func NewSCIMService(
+	repo *repository.SCIMRepository,
+	lifecycle *UserLifecycleService,
+	passwords *utils.PasswordPolicy,
+	auditRepo *repository.AuditRepository,
+	revocations repository.TokenRevocationStore,
+	cfg *config.Config,
+) *SCIMService {
+	return &SCIMService{
+		repo:        repo,
+		lifecycle:   lifecycle,
+		passwords:   passwords,
+		auditRepo:   auditRepo,
+		revocations: revocations,
+		cfg:         cfg,
+	}
+}
+
+/*
+=====================
+ Errors
+=====================
+*/
+
+// Rendered as a SCIM Error message (RFC 7644 §3.12)
+type SCIMError struct {
+	Status   int
+	SCIMType string
+	Detail   string
+}
+
+func (e *SCIMError) Error() string {
+	return e.Detail
+}
+
+func scimNotFound(resource string) error {
+	return &SCIMError{Status: http.StatusNotFound, Detail: resource + " not found"}
+}
+
+func scimBadRequest(scimType string, detail string) error {
+	return &SCIMError{Status: http.StatusBadRequest, SCIMType: scimType, Detail: detail}
+}
+
+func scimConflict(detail string) error {
+	return &SCIMError{Status: http.StatusConflict, SCIMType: "uniqueness", Detail: detail}
+}
+
+/*
+=====================
+ Resources
+=====================
+*/
+
+type SCIMMeta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+type SCIMName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type SCIMMultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type SCIMUser struct {
+	Schemas     []string         `json:"schemas"`
+	ID          string           `json:"id,omitempty"`
+	ExternalID  string           `json:"externalId,omitempty"`
+	UserName    string           `json:"userName"`
+	Name        *SCIMName        `json:"name,omitempty"`
+	DisplayName string           `json:"displayName,omitempty"`
+	Title       string           `json:"title,omitempty"`
+	Active      *bool            `json:"active,omitempty"`
+	Emails      []SCIMMultiValue `json:"emails,omitempty"`
+	Groups      []SCIMMultiValue `json:"groups,omitempty"`
+	Meta        *SCIMMeta        `json:"meta,omitempty"`
+}
+
+type SCIMGroup struct {
+	Schemas     []string         `json:"schemas"`
+	ID          string           `json:"id"`
+	DisplayName string           `json:"displayName"`
+	Members     []SCIMMultiValue `json:"members,omitempty"`
+	Meta        *SCIMMeta        `json:"meta,omitempty"`
+}
+
+type SCIMListResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int64       `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+type SCIMPatchRequest struct {
+	Schemas    []string      `json:"schemas"`
+	Operations []SCIMPatchOp `json:"Operations" binding:"required"`
+}
+
+type SCIMPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+func (s *SCIMService) location(resource string, id string) string {
+	return strings.TrimRight(s.cfg.SCIM.BaseURL, "/") + "/" + resource + "/" + id
+}
+
+func (s *SCIMService) toSCIMUser(u *models.User, title string) *SCIMUser {
+	active := u.IsActive
+	id := u.ID.String()
+
+	out := &SCIMUser{
+		Schemas:     []string{SCIMUserSchema},
+		ID:          id,
+		UserName:    u.Email,
+		Name:        &SCIMName{Formatted: u.Name},
+		DisplayName: u.Name,
+		Title:       title,
+		Active:      &active,
+		Emails:      []SCIMMultiValue{{Value: u.Email, Type: "work", Primary: true}},
+		Meta: &SCIMMeta{
+			ResourceType: "User",
+			Created:      u.CreatedAt,
+			LastModified: u.UpdatedAt,
+			Location:     s.location("Users", id),
+		},
+	}
+
+	if u.SCIMExternalID != nil {
+		out.ExternalID = *u.SCIMExternalID
+	}
+
+	g := s.groupForRole(u.Role)
+	out.Groups = []SCIMMultiValue{{
+		Value:   g.ID,
+		Display: g.DisplayName,
+		Ref:     s.location("Groups", g.ID),
+	}}
+
+	return out
+}
+
+/*
+=====================
+ Users
+=====================
+*/
+
+func scimPage(startIndex int, count int) (int, int) {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count <= 0 {
+		count = scimDefaultCount
+	}
+	if count > SCIMMaxResults {
+		count = SCIMMaxResults
+	}
+	return startIndex, count
+}
+
+func (s *SCIMService) ListUsers(filter string, startIndex int, count int) (*SCIMListResponse, error) {
+	where, err := scimUserFilterClauses(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	startIndex, count = scimPage(startIndex, count)
+
+	users, total, err := s.repo.ListUsers(where, startIndex-1, count)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	titles, err := s.repo.Designations(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*SCIMUser, 0, len(users))
+	for i := range users {
+		resources = append(resources, s.toSCIMUser(&users[i], titles[users[i].ID]))
+	}
+
+	return &SCIMListResponse{
+		Schemas:      []string{SCIMListSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}, nil
+}
+
+func (s *SCIMService) findUser(id string) (*models.User, error) {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, scimNotFound("user")
+	}
+
+	user, err := s.repo.FindUser(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, scimNotFound("user")
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *SCIMService) GetUser(id string) (*SCIMUser, error) {
+	user, err := s.findUser(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.render(user)
+}
+
+func (s *SCIMService) render(user *models.User) (*SCIMUser, error) {
+	titles, err := s.repo.Designations([]uuid.UUID{user.ID})
+	if err != nil {
+		return nil, err
+	}
+	return s.toSCIMUser(user, titles[user.ID]), nil
+}
+
+func (s *SCIMService) CreateUser(
+	in *SCIMUser,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) (*SCIMUser, error) {
+
+	state := scimStateFromResource(in)
+	if err := state.validate(); err != nil {
+		return nil, err
+	}
+
+	taken, err := s.repo.EmailTaken(state.Email, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, scimConflict("userName already exists")
+	}
+
+	// Unusable local password: SSO (or a reset link) is the way in
+	random, err := utils.GenerateRandomToken(48)
+	if err != nil {
+		return nil, err
+	}
+	hashed, err := s.passwords.Hash(random)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Name:              state.displayName(),
+		Email:             state.Email,
+		Password:          hashed,
+		Role:              models.RoleSupport,
+		IsActive:          state.Active,
+		MustResetPassword: true,
+		CreatedBy:         &actorID,
+		EmailVerifiedAt:   &now, // the IdP owns the address
+		SCIMExternalID:    state.externalIDPtr(),
+	}
+
+	err = s.repo.WithTransaction(func(tx *repository.SCIMRepository) error {
+		if err := tx.CreateUser(user); err != nil {
+			return err
+		}
+		return tx.EnsureSupportProfile(user.ID, user.IsActive, &state.Title)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.auditRepo.Log("user", user.ID, "scim_user_created", actorID, ip, userAgent)
+
+	return s.toSCIMUser(user, state.Title), nil
+}
+
+// PUT: every mutable attribute comes from the request
+func (s *SCIMService) ReplaceUser(
+	id string,
+	in *SCIMUser,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) (*SCIMUser, error) {
+
+	user, err := s.findUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.applyState(user, scimStateFromResource(in), actorID, ip, userAgent)
+}
+
+func (s *SCIMService) PatchUser(
+	id string,
+	req *SCIMPatchRequest,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) (*SCIMUser, error) {
+
+	user, err := s.findUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	titles, err := s.repo.Designations([]uuid.UUID{user.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	state := scimStateFromUser(user, titles[user.ID])
+	for _, op := range req.Operations {
+		if err := state.apply(op); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.applyState(user, state, actorID, ip, userAgent)
+}
+
+// DELETE = deprovision: deactivate, keep the row and its history
+func (s *SCIMService) DeleteUser(
+	id string,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	user, err := s.findUser(id)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	return s.setActive(user, false, actorID, ip, userAgent)
+}
+
+func (s *SCIMService) applyState(
+	user *models.User,
+	state *scimUserState,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) (*SCIMUser, error) {
+
+	if err := state.validate(); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+
+	if !strings.EqualFold(state.Email, user.Email) {
+		taken, err := s.repo.EmailTaken(state.Email, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, scimConflict("userName already exists")
+		}
+		fields["email"] = state.Email
+		fields["email_verified_at"] = time.Now()
+	}
+
+	if name := state.displayName(); name != user.Name {
+		fields["name"] = name
+	}
+
+	current := ""
+	if user.SCIMExternalID != nil {
+		current = *user.SCIMExternalID
+	}
+	if state.ExternalID != current {
+		fields["scim_external_id"] = state.externalIDPtr()
+	}
+
+	err := s.repo.WithTransaction(func(tx *repository.SCIMRepository) error {
+		if len(fields) > 0 {
+			if err := tx.UpdateUser(user.ID, fields); err != nil {
+				return err
+			}
+		}
+		if user.Role == models.RoleSupport {
+			return tx.EnsureSupportProfile(user.ID, user.IsActive, &state.Title)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) > 0 {
+		_ = s.auditRepo.Log("user", user.ID, "scim_user_updated", actorID, ip, userAgent)
+	}
+
+	if state.Active != user.IsActive {
+		if err := s.setActive(user, state.Active, actorID, ip, userAgent); err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := s.repo.FindUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.render(updated)
+}
+
+func (s *SCIMService) setActive(
+	user *models.User,
+	active bool,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	var err error
+	if active {
+		_, err = s.lifecycle.Reactivate(user.ID, actorID, ip, userAgent)
+	} else {
+		// Open tickets of a support engineer go back to the admin queue
+		_, err = s.lifecycle.Deactivate(user.ID, actorID, nil, ip, userAgent)
+	}
+
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return scimBadRequest("mutability", err.Error())
+	}
+	return err
+}
+
+/*
+=====================
+ User state (PUT / PATCH)
+=====================
+*/
+
+type scimUserState struct {
+	Email      string
+	Name       string
+	GivenName  string
+	FamilyName string
+	ExternalID string
+	Title      string
+	Active     bool
+
+	nameParts bool // given / family changed without a formatted name
+}
+
+func scimStateFromResource(in *SCIMUser) *scimUserState {
+	st := &scimUserState{
+		Email:      strings.ToLower(strings.TrimSpace(in.UserName)),
+		Name:       strings.TrimSpace(in.DisplayName),
+		ExternalID: in.ExternalID,
+		Title:      in.Title,
+		Active:     in.Active == nil || *in.Active,
+	}
+
+	if in.Name != nil {
+		if st.Name == "" {
+			st.Name = strings.TrimSpace(in.Name.Formatted)
+		}
+		st.GivenName = in.Name.GivenName
+		st.FamilyName = in.Name.FamilyName
+		st.nameParts = st.Name == ""
+	}
+
+	return st
+}
+
+func scimStateFromUser(u *models.User, title string) *scimUserState {
+	st := &scimUserState{
+		Email:  u.Email,
+		Name:   u.Name,
+		Title:  title,
+		Active: u.IsActive,
+	}
+	if u.SCIMExternalID != nil {
+		st.ExternalID = *u.SCIMExternalID
+	}
+	return st
+}
+
+func (st *scimUserState) displayName() string {
+	if st.nameParts {
+		if name := strings.TrimSpace(st.GivenName + " " + st.FamilyName); name != "" {
+			return name
+		}
+	}
+	if st.Name != "" {
+		return st.Name
+	}
+	return st.Email
+}
+
+func (st *scimUserState) externalIDPtr() *string {
+	if st.ExternalID == "" {
+		return nil
+	}
+	id := st.ExternalID
+	return &id
+}
+
+func (st *scimUserState) validate() error {
+	if st.Email == "" || !strings.Contains(st.Email, "@") {
+		return scimBadRequest("invalidValue", "userName must be an email address")
+	}
+	return nil
+}
+
+func (st *scimUserState) apply(op SCIMPatchOp) error {
+	kind := strings.ToLower(op.Op)
+	if kind != "add" && kind != "replace" && kind != "remove" {
+		return scimBadRequest("invalidSyntax", "unsupported patch op "+op.Op)
+	}
+
+	// No path: value is an object of attribute → value
+	if op.Path == "" {
+		if kind == "remove" {
+			return scimBadRequest("noTarget", "remove requires a path")
+		}
+
+		var attrs map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &attrs); err != nil {
+			return scimBadRequest("invalidValue", "value must be an object when path is omitted")
+		}
+		for attr, value := range attrs {
+			if err := st.set(attr, value, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return st.set(op.Path, op.Value, kind == "remove")
+}
+
+func (st *scimUserState) set(path string, raw json.RawMessage, remove bool) error {
+	str := func() (string, error) {
+		if remove {
+			return "", nil
+		}
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return "", scimBadRequest("invalidValue", path+" must be a string")
+		}
+		return strings.TrimSpace(v), nil
+	}
+
+	var err error
+
+	switch strings.ToLower(stripSCIMSelectors(path)) {
+	case "active":
+		if remove {
+			return scimBadRequest("mutability", "active cannot be removed")
+		}
+		st.Active, err = parseSCIMBool(raw)
+
+	case "username":
+		if remove {
+			return scimBadRequest("mutability", "userName cannot be removed")
+		}
+		var v string
+		v, err = str()
+		st.Email = strings.ToLower(v)
+
+	case "displayname", "name.formatted":
+		st.Name, err = str()
+		st.nameParts = false
+
+	case "name.givenname":
+		st.GivenName, err = str()
+		st.nameParts = true
+
+	case "name.familyname":
+		st.FamilyName, err = str()
+		st.nameParts = true
+
+	case "name":
+		var n SCIMName
+		if !remove {
+			if err := json.Unmarshal(raw, &n); err != nil {
+				return scimBadRequest("invalidValue", "name must be an object")
+			}
+		}
+		st.Name, st.GivenName, st.FamilyName = n.Formatted, n.GivenName, n.FamilyName
+		st.nameParts = n.Formatted == ""
+
+	case "externalid":
+		st.ExternalID, err = str()
+
+	case "title":
+		st.Title, err = str()
+
+	default:
+		// userName is the address; emails, phone numbers and extension
+		// attributes the IdP may push are not stored
+	}
+
+	return err
+}
+
+// Some IdPs send "True" / "False" as strings
+func parseSCIMBool(raw json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(raw, &b); err == nil {
+		return b, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		switch strings.ToLower(s) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+
+	return false, scimBadRequest("invalidValue", "active must be a boolean")
+}
+
+/*
+=====================
+ Groups
+=====================
+*/
+
+// Anyone who isn't admin is in the support group
+func (s *SCIMService) groupForRole(role models.Role) *SCIMGroup {
+	if role == models.RoleAdmin {
+		return &SCIMGroup{ID: string(models.RoleAdmin), DisplayName: s.cfg.SCIM.AdminGroup}
+	}
+	return &SCIMGroup{ID: string(models.RoleSupport), DisplayName: s.cfg.SCIM.SupportGroup}
+}
+
+func (s *SCIMService) buildGroup(role models.Role, withMembers bool) (*SCIMGroup, error) {
+	g := s.groupForRole(role)
+	g.Schemas = []string{SCIMGroupSchema}
+	g.Meta = &SCIMMeta{
+		ResourceType: "Group",
+		Location:     s.location("Groups", g.ID),
+	}
+
+	if !withMembers {
+		return g, nil
+	}
+
+	var users []models.User
+	var err error
+	if role == models.RoleAdmin {
+		users, err = s.repo.ListByRole(role)
+	} else {
+		users, err = s.repo.ListNonAdmins()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	g.Members = make([]SCIMMultiValue, 0, len(users))
+	for _, u := range users {
+		g.Members = append(g.Members, SCIMMultiValue{
+			Value:   u.ID.String(),
+			Display: u.Name,
+			Ref:     s.location("Users", u.ID.String()),
+		})
+		if u.UpdatedAt.After(g.Meta.LastModified) {
+			g.Meta.LastModified = u.UpdatedAt
+		}
+		if g.Meta.Created.IsZero() || u.CreatedAt.Before(g.Meta.Created) {
+			g.Meta.Created = u.CreatedAt
+		}
+	}
+	return g, nil
+}
+
+func scimGroupRole(id string) (models.Role, error) {
+	switch models.Role(id) {
+	case models.RoleAdmin, models.RoleSupport:
+		return models.Role(id), nil
+	}
+	return "", scimNotFound("group")
+}
+
+func (s *SCIMService) ListGroups(
+	filter string,
+	startIndex int,
+	count int,
+	withMembers bool,
+) (*SCIMListResponse, error) {
+
+	terms, err := parseSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	startIndex, count = scimPage(startIndex, count)
+
+	var matched []*SCIMGroup
+	for _, role := range []models.Role{models.RoleAdmin, models.RoleSupport} {
+		g, err := s.buildGroup(role, withMembers)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := scimGroupMatches(g, terms)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, g)
+		}
+	}
+
+	page := []*SCIMGroup{}
+	if from := startIndex - 1; from < len(matched) {
+		to := from + count
+		if to > len(matched) {
+			to = len(matched)
+		}
+		page = matched[from:to]
+	}
+
+	return &SCIMListResponse{
+		Schemas:      []string{SCIMListSchema},
+		TotalResults: int64(len(matched)),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(page),
+		Resources:    page,
+	}, nil
+}
+
+func (s *SCIMService) GetGroup(id string, withMembers bool) (*SCIMGroup, error) {
+	role, err := scimGroupRole(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildGroup(role, withMembers)
+}
+
+// Groups are fixed: creating one that exists lets the IdP link to it
+func (s *SCIMService) CreateGroup(in *SCIMGroup) error {
+	for _, role := range []models.Role{models.RoleAdmin, models.RoleSupport} {
+		if strings.EqualFold(s.groupForRole(role).DisplayName, in.DisplayName) {
+			return scimConflict("group already exists")
+		}
+	}
+	return scimBadRequest("mutability", fmt.Sprintf(
+		"groups are fixed: %q and %q",
+		s.cfg.SCIM.AdminGroup,
+		s.cfg.SCIM.SupportGroup,
+	))
+}
+
+// PUT: members becomes the complete membership
+func (s *SCIMService) ReplaceGroup(
+	id string,
+	in *SCIMGroup,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) (*SCIMGroup, error) {
+
+	role, err := scimGroupRole(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.DisplayName != "" && !strings.EqualFold(in.DisplayName, s.groupForRole(role).DisplayName) {
+		return nil, scimBadRequest("mutability", "group displayName is read-only")
+	}
+
+	ids := make([]string, 0, len(in.Members))
+	for _, m := range in.Members {
+		ids = append(ids, m.Value)
+	}
+
+	if err := s.replaceMembers(role, ids, actorID, ip, userAgent); err != nil {
+		return nil, err
+	}
+
+	return s.buildGroup(role, true)
+}
+
+var scimMemberPath = regexp.MustCompile(`(?i)^members\[value eq "([^"]+)"\]$`)
+
+func (s *SCIMService) PatchGroup(
+	id string,
+	req *SCIMPatchRequest,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	role, err := scimGroupRole(id)
+	if err != nil {
+		return err
+	}
+
+	for _, op := range req.Operations {
+		kind := strings.ToLower(op.Op)
+		path := strings.TrimSpace(op.Path)
+
+		// Single member by filter: members[value eq "…"]
+		if m := scimMemberPath.FindStringSubmatch(path); m != nil {
+			if kind != "remove" {
+				return scimBadRequest("invalidPath", "member filters are only valid with remove")
+			}
+			if err := s.removeMembers(role, []string{m[1]}, actorID, ip, userAgent); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var members []SCIMMultiValue
+
+		switch strings.ToLower(path) {
+		case "members":
+			if len(op.Value) > 0 {
+				if err := json.Unmarshal(op.Value, &members); err != nil {
+					return scimBadRequest("invalidValue", "members must be an array")
+				}
+			}
+
+		case "":
+			var body struct {
+				DisplayName string           `json:"displayName"`
+				Members     []SCIMMultiValue `json:"members"`
+			}
+			if err := json.Unmarshal(op.Value, &body); err != nil {
+				return scimBadRequest("invalidValue", "value must be an object when path is omitted")
+			}
+			if body.DisplayName != "" && !strings.EqualFold(body.DisplayName, s.groupForRole(role).DisplayName) {
+				return scimBadRequest("mutability", "group displayName is read-only")
+			}
+			members = body.Members
+
+		case "displayname":
+			var name string
+			_ = json.Unmarshal(op.Value, &name)
+			if !strings.EqualFold(name, s.groupForRole(role).DisplayName) {
+				return scimBadRequest("mutability", "group displayName is read-only")
+			}
+			continue
+
+		default:
+			return scimBadRequest("invalidPath", "unsupported path "+op.Path)
+		}
+
+		ids := make([]string, 0, len(members))
+		for _, m := range members {
+			ids = append(ids, m.Value)
+		}
+
+		switch kind {
+		case "add":
+			err = s.addMembers(role, ids, actorID, ip, userAgent)
+		case "remove":
+			if len(op.Value) == 0 {
+				err = s.replaceMembers(role, nil, actorID, ip, userAgent)
+			} else {
+				err = s.removeMembers(role, ids, actorID, ip, userAgent)
+			}
+		case "replace":
+			err = s.replaceMembers(role, ids, actorID, ip, userAgent)
+		default:
+			err = scimBadRequest("invalidSyntax", "unsupported patch op "+op.Op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *SCIMService) DeleteGroup(id string) error {
+	if _, err := scimGroupRole(id); err != nil {
+		return err
+	}
+	return scimBadRequest("mutability", "groups are fixed and cannot be deleted")
+}
+
+/*
+=====================
+ Membership → Role
+=====================
+ Admin group: add = promote, remove = demote to support.
+ Support group is every other staff member; adding is a no-op (admins
+ stay admins) and leaving it changes nothing — deprovision instead.
+*/
+
+// Members the IdP knows but we don't (customers, unprovisioned) are skipped
+func (s *SCIMService) membersByID(ids []string) []*models.User {
+	users := make([]*models.User, 0, len(ids))
+	for _, id := range ids {
+		if u, err := s.findUser(id); err == nil {
+			users = append(users, u)
+		}
+	}
+	return users
+}
+
+func (s *SCIMService) addMembers(
+	role models.Role,
+	ids []string,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	if role != models.RoleAdmin {
+		return nil
+	}
+	for _, u := range s.membersByID(ids) {
+		if err := s.setRole(u, models.RoleAdmin, actorID, ip, userAgent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SCIMService) removeMembers(
+	role models.Role,
+	ids []string,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	if role != models.RoleAdmin {
+		return nil
+	}
+	for _, u := range s.membersByID(ids) {
+		if err := s.setRole(u, models.RoleSupport, actorID, ip, userAgent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SCIMService) replaceMembers(
+	role models.Role,
+	ids []string,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	if role != models.RoleAdmin {
+		return nil
+	}
+
+	// Promote first so the "last admin" guard sees the new admins
+	if err := s.addMembers(role, ids, actorID, ip, userAgent); err != nil {
+		return err
+	}
+
+	keep := map[string]bool{}
+	for _, id := range ids {
+		keep[id] = true
+	}
+
+	admins, err := s.repo.ListByRole(models.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	for i := range admins {
+		if keep[admins[i].ID.String()] {
+			continue
+		}
+		if err := s.setRole(&admins[i], models.RoleSupport, actorID, ip, userAgent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SCIMService) setRole(
+	user *models.User,
+	role models.Role,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	if user.Role == role {
+		return nil
+	}
+
+	err := changeStaffRole(s.repo, s.revocations, user, role)
+	if errors.Is(err, ErrLastAdmin) {
+		return scimBadRequest("mutability", "cannot demote the last active admin")
+	}
+	if err != nil {
+		return err
+	}
+
+	_ = s.auditRepo.Log("user", user.ID, "scim_role_changed:"+string(role), actorID, ip, userAgent)
+	return nil
+}
+
+// Role change driven by the IdP (SCIM or SSO groups): keeps an active
+// admin, gives new support engineers their profile and revokes tokens
+// carrying the old role. Callers audit.
+func changeStaffRole(
+	repo *repository.SCIMRepository,
+	revocations repository.TokenRevocationStore,
+	user *models.User,
+	role models.Role,
+) error {
+
+	if user.Role == models.RoleAdmin && user.IsActive {
+		admins, err := repo.CountActiveAdmins()
+		if err != nil {
+			return err
+		}
+		if admins <= 1 {
+			return ErrLastAdmin
+		}
+	}
+
+	err := repo.WithTransaction(func(tx *repository.SCIMRepository) error {
+		if err := tx.SetRole(user.ID, role); err != nil {
+			return err
+		}
+		if role == models.RoleSupport {
+			return tx.EnsureSupportProfile(user.ID, user.IsActive, nil)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Role is baked into access tokens: make the user pick up the new one
+	_ = revocations.RevokeUserTokens(user.ID, time.Now())
+
+	user.Role = role
+	return nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"rbac/models"
+	"rbac/repository"
+	"rbac/testutil"
+)
+
+func newTestSCIMService(t *testing.T) (*SCIMService, *AuthService) {
+	t.Helper()
+
+	auth, db, cfg := newTestAuthService(t)
+	revocations := repository.NewPostgresTokenRevocationStore(db)
+	auditRepo := repository.NewAuditRepository(db)
+
+	svc := NewSCIMService(
+		repository.NewSCIMRepository(db),
+		NewUserLifecycleService(repository.NewUserLifecycleRepository(db), auditRepo, revocations),
+		auth.passwords,
+		auditRepo,
+		revocations,
+		cfg,
+	)
+	return svc, auth
+}
+
+// Staff on a custom role are managed by the IdP like everyone else
+func TestSCIMSeesCustomRoleStaff(t *testing.T) {
+	svc, auth := newTestSCIMService(t)
+	lead := testutil.User(t, auth.db, models.Role("field_lead"))
+	customer := testutil.User(t, auth.db, models.RoleCustomer)
+
+	if _, err := svc.GetUser(customer.ID.String()); err == nil {
+		t.Fatal("customer exposed over SCIM")
+	}
+
+	user, err := svc.GetUser(lead.ID.String())
+	if err != nil {
+		t.Fatalf("custom-role staff not visible: %v", err)
+	}
+	if len(user.Groups) != 1 || user.Groups[0].Value != string(models.RoleSupport) {
+		t.Fatalf("custom-role staff groups = %+v", user.Groups)
+	}
+
+	group, err := svc.GetGroup(string(models.RoleSupport), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(group.Members) != 1 || group.Members[0].Value != lead.ID.String() {
+		t.Fatalf("support group members = %+v", group.Members)
+	}
+
+	if err := svc.DeleteUser(lead.ID.String(), uuid.Nil, "", ""); err != nil {
+		t.Fatalf("deprovision: %v", err)
+	}
+	if got := reloadUser(t, auth, lead.ID); got.IsActive || got.Role != "field_lead" {
+		t.Fatalf("after deprovision: active=%v role=%s", got.IsActive, got.Role)
+	}
+}
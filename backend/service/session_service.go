@@ -0,0 +1,282 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rbac/models"
+	"rbac/repository"
+	"rbac/utils"
+)
+
+type SessionService struct {
+	authRepo   *repository.AuthRepository
+	deviceRepo *repository.RememberedDeviceRepo
+	loginRepo  *repository.LoginEventRepository
+	auditRepo  *repository.AuditRepository
+
+	revocations repository.TokenRevocationStore
+}
+
+func NewSessionService(
+	authRepo *repository.AuthRepository,
+	deviceRepo *repository.RememberedDeviceRepo,
+	loginRepo *repository.LoginEventRepository,
+	auditRepo *repository.AuditRepository,
+	revocations repository.TokenRevocationStore,
+) *SessionService {
+	return &SessionService{
+		authRepo:    authRepo,
+		deviceRepo:  deviceRepo,
+		loginRepo:   loginRepo,
+		auditRepo:   auditRepo,
+		revocations: revocations,
+	}
+}
+
+/*
+=====================
+ Response DTOs
+=====================
+*/
+
+type SessionInfo struct {
+	ID         uuid.UUID  `json:"id"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	Current    bool       `json:"current"`
+}
+
+type LoginEventInfo struct {
+	ID          uuid.UUID           `json:"id"`
+	Method      models.LoginMethod  `json:"method"`
+	TwoFAMethod string              `json:"two_fa_method,omitempty"`
+	Outcome     models.LoginOutcome `json:"outcome"`
+	Reason      string              `json:"reason,omitempty"`
+	IPAddress   string              `json:"ip_address"`
+	UserAgent   string              `json:"user_agent"`
+	NewDevice   bool                `json:"new_device"`
+	NewIPRange  bool                `json:"new_ip_range"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+type LoginHistoryPage struct {
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
+	Total    int64            `json:"total"`
+	Events   []LoginEventInfo `json:"events"`
+}
+
+type DeviceInfo struct {
+	ID         uuid.UUID  `json:"id"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+}
+
+/*
+=====================
+ Refresh-token Sessions
+=====================
+*/
+
+// currentRefresh (optional) marks the caller's own session
+func (s *SessionService) ListSessions(
+	userID uuid.UUID,
+	currentRefresh string,
+) ([]SessionInfo, error) {
+
+	rows, err := s.authRepo.ListActiveSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	currentHash := ""
+	if currentRefresh != "" {
+		currentHash = utils.HashToken(currentRefresh)
+	}
+
+	result := make([]SessionInfo, 0, len(rows))
+	for _, rt := range rows {
+		result = append(result, SessionInfo{
+			ID:         rt.ID,
+			UserAgent:  rt.UserAgent,
+			IPAddress:  rt.IPAddress,
+			CreatedAt:  rt.CreatedAt,
+			LastUsedAt: rt.LastUsedAt,
+			ExpiresAt:  rt.ExpiresAt,
+			Current:    currentHash != "" && rt.Token == currentHash,
+		})
+	}
+
+	return result, nil
+}
+
+func (s *SessionService) RevokeSession(
+	userID uuid.UUID,
+	sessionID uuid.UUID,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	rt, err := s.authRepo.RevokeSessionByID(userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if rt == nil {
+		return errors.New("session not found")
+	}
+
+	// Access tokens already minted for the session carry its family as
+	// `sid`; none outlives the refresh token they came with
+	if err := s.revocations.RevokeToken(rt.FamilyID.String(), userID, rt.ExpiresAt); err != nil {
+		return err
+	}
+
+	_ = s.auditRepo.Log("session", sessionID, "session_revoked", actorID, ip, userAgent)
+
+	return nil
+}
+
+func (s *SessionService) RevokeAllSessions(
+	userID uuid.UUID,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	if err := s.authRepo.RevokeAllUserTokens(userID); err != nil {
+		return err
+	}
+
+	// Access tokens already handed out die too
+	if err := s.revocations.RevokeUserTokens(userID, time.Now()); err != nil {
+		return err
+	}
+
+	_ = s.auditRepo.Log("user", userID, "all_sessions_revoked", actorID, ip, userAgent)
+
+	return nil
+}
+
+/*
+=====================
+ Remembered Devices
+=====================
+*/
+
+func (s *SessionService) ListDevices(userID uuid.UUID) ([]DeviceInfo, error) {
+	rows, err := s.deviceRepo.ListValidByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]DeviceInfo, 0, len(rows))
+	for _, d := range rows {
+		result = append(result, DeviceInfo{
+			ID:         d.ID,
+			UserAgent:  d.UserAgent,
+			IPAddress:  d.IPAddress,
+			CreatedAt:  d.CreatedAt,
+			LastUsedAt: d.LastUsedAt,
+			ExpiresAt:  d.ExpiresAt,
+		})
+	}
+
+	return result, nil
+}
+
+func (s *SessionService) RevokeDevice(
+	userID uuid.UUID,
+	deviceID uuid.UUID,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	deleted, err := s.deviceRepo.DeleteByID(userID, deviceID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("device not found")
+	}
+
+	_ = s.auditRepo.Log("remembered_device", deviceID, "device_revoked", actorID, ip, userAgent)
+
+	return nil
+}
+
+func (s *SessionService) RevokeAllDevices(
+	userID uuid.UUID,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	if err := s.authRepo.DeleteUserDevices(userID); err != nil {
+		return err
+	}
+
+	_ = s.auditRepo.Log("user", userID, "all_devices_revoked", actorID, ip, userAgent)
+
+	return nil
+}
+
+/*
+=====================
+ Login History
+=====================
+*/
+
+func (s *SessionService) ListLogins(userID uuid.UUID, page int) (*LoginHistoryPage, error) {
+	const pageSize = 20
+
+	if page < 1 {
+		page = 1
+	}
+
+	rows, total, err := s.loginRepo.ListByUser(userID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]LoginEventInfo, 0, len(rows))
+	for _, e := range rows {
+		events = append(events, LoginEventInfo{
+			ID:          e.ID,
+			Method:      e.Method,
+			TwoFAMethod: e.TwoFAMethod,
+			Outcome:     e.Outcome,
+			Reason:      e.Reason,
+			IPAddress:   e.IPAddress,
+			UserAgent:   e.UserAgent,
+			NewDevice:   e.NewDevice,
+			NewIPRange:  e.NewIPRange,
+			CreatedAt:   e.CreatedAt,
+		})
+	}
+
+	return &LoginHistoryPage{
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+		Events:   events,
+	}, nil
+}
+
+// Admin targets must exist
+func (s *SessionService) EnsureUser(userID uuid.UUID) error {
+	if _, err := s.authRepo.FindUserByID(userID); err != nil {
+		return ErrUserNotFound
+	}
+	return nil
+}
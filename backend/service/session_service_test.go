@@ -0,0 +1,77 @@
+package service
+
+import (
+	"testing"
+
+	"rbac/models"
+	"rbac/repository"
+	"rbac/testutil"
+	"rbac/utils"
+)
+
+// Revoking a session takes the access tokens minted for it along, not
+// just the refresh token; the user's other sessions keep working
+func TestRevokeSessionDeniesItsAccessTokens(t *testing.T) {
+	auth, db, _ := newTestAuthService(t)
+	user := testutil.User(t, db, models.RoleSupport)
+
+	first, err := auth.issueTokens(user, utils.NewAuthContext(utils.AMRPassword), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := auth.issueTokens(user, utils.NewAuthContext(utils.AMRPassword), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshed, err := auth.RefreshAccessToken(first.RefreshToken, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sid := func(access string) string {
+		t.Helper()
+		claims, err := utils.ValidateToken(access, auth.keys)
+		if err != nil || claims.SessionID == "" {
+			t.Fatalf("access token without a session: %v", err)
+		}
+		return claims.SessionID
+	}
+	if sid(first.AccessToken) != sid(refreshed.AccessToken) || sid(first.AccessToken) == sid(other.AccessToken) {
+		t.Fatal("refresh moved the token to another session, or two logins share one")
+	}
+
+	sessions := NewSessionService(
+		repository.NewAuthRepository(db),
+		repository.NewRememberedDeviceRepo(db),
+		repository.NewLoginEventRepository(db),
+		repository.NewAuditRepository(db),
+		auth.revocations,
+	)
+	list, err := sessions.ListSessions(user.ID, refreshed.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range list {
+		if s.Current {
+			if err := sessions.RevokeSession(user.ID, s.ID, user.ID, "", ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	revoked := func(access string) bool {
+		t.Helper()
+		claims, _ := utils.ValidateToken(access, auth.keys)
+		denied, err := auth.revocations.IsRevoked(claims.SessionID, user.ID, claims.IssuedAt.Time)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return denied
+	}
+	if !revoked(first.AccessToken) || !revoked(refreshed.AccessToken) {
+		t.Fatal("access tokens of the revoked session still valid")
+	}
+	if revoked(other.AccessToken) {
+		t.Fatal("revoking one session denied another")
+	}
+}
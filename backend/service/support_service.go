@@ -2,6 +2,8 @@
 package service
 
 import (
+	"sort"
+
 	"github.com/google/uuid"
 	"rbac/models"
 	"rbac/repository"
@@ -9,14 +11,85 @@ import (
 
 type SupportService struct {
 	ticketRepo *repository.TicketRepository
+	teamRepo   *repository.SupportTeamRepository
 }
 
-func NewSupportService(t *repository.TicketRepository) *SupportService {
-	return &SupportService{ticketRepo: t}
+func NewSupportService(
+	t *repository.TicketRepository,
+	teams *repository.SupportTeamRepository,
+) *SupportService {
+	return &SupportService{ticketRepo: t, teamRepo: teams}
 }
 
+// Own assignments plus, for team leads, every ticket of the teams they
+// lead. teamID narrows it to one led team.
 func (s *SupportService) GetAssignedTickets(
 	engineerID uuid.UUID,
+	teamID *uuid.UUID,
 ) ([]models.Ticket, error) {
-	return s.ticketRepo.FindByEngineer(engineerID)
+
+	led, err := s.teamRepo.LedTeamIDs(engineerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if teamID != nil {
+		for _, id := range led {
+			if id == *teamID {
+				return s.ticketRepo.FindByTeams([]uuid.UUID{id})
+			}
+		}
+		return nil, ErrForbidden
+	}
+
+	own, err := s.ticketRepo.FindByEngineer(engineerID)
+	if err != nil || len(led) == 0 {
+		return own, err
+	}
+
+	tickets, err := s.ticketRepo.FindByTeams(led)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uuid.UUID]bool, len(tickets))
+	for _, t := range tickets {
+		seen[t.ID] = true
+	}
+	for _, t := range own {
+		if !seen[t.ID] {
+			tickets = append(tickets, t)
+		}
+	}
+
+	sort.SliceStable(tickets, func(i, j int) bool {
+		return tickets[i].CreatedAt.After(tickets[j].CreatedAt)
+	})
+	return tickets, nil
+}
+
+// The caller's teams, for the queue filter
+func (s *SupportService) MyTeams(engineerID uuid.UUID) ([]*MyTeamInfo, error) {
+	memberships, err := s.teamRepo.MembershipsOf(engineerID)
+	if err != nil {
+		return nil, err
+	}
+
+	lead := map[uuid.UUID]bool{}
+	ids := make([]uuid.UUID, 0, len(memberships))
+	for _, m := range memberships {
+		lead[m.TeamID] = m.IsLead
+		ids = append(ids, m.TeamID)
+	}
+
+	teams, err := s.teamRepo.TeamsByID(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*MyTeamInfo, 0, len(teams))
+	for _, t := range teams {
+		result = append(result, &MyTeamInfo{ID: t.ID, Name: t.Name, IsLead: lead[t.ID]})
+	}
+	return result, nil
 }
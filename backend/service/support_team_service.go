@@ -0,0 +1,419 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/models"
+	"rbac/repository"
+)
+
+/*
+=====================
+ Support Teams
+=====================
+ Engineers are grouped into teams (region, product line, ...). Admins
+ queue tickets to a team; team leads see their team's tickets and hand
+ them between members.
+*/
+
+type SupportTeamService struct {
+	repo       *repository.SupportTeamRepository
+	ticketRepo *repository.TicketRepository
+	roles      *RoleService
+	auditRepo  *repository.AuditRepository
+}
+
+func NewSupportTeamService(
+	repo *repository.SupportTeamRepository,
+	ticketRepo *repository.TicketRepository,
+	roles *RoleService,
+	auditRepo *repository.AuditRepository,
+) *SupportTeamService {
+	return &SupportTeamService{
+		repo:       repo,
+		ticketRepo: ticketRepo,
+		roles:      roles,
+		auditRepo:  auditRepo,
+	}
+}
+
+/*
+=====================
+ Response DTOs
+=====================
+*/
+
+type TeamInfo struct {
+	ID          uuid.UUID         `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Members     []*TeamMemberInfo `json:"members"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+type TeamMemberInfo struct {
+	UserID      uuid.UUID `json:"user_id"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	Designation string    `json:"designation"`
+	IsActive    bool      `json:"is_active"`
+	IsLead      bool      `json:"is_lead"`
+}
+
+// The caller's own teams (support view)
+type MyTeamInfo struct {
+	ID     uuid.UUID `json:"id"`
+	Name   string    `json:"name"`
+	IsLead bool      `json:"is_lead"`
+}
+
+func toTeamInfo(t *models.SupportTeam) *TeamInfo {
+	info := &TeamInfo{
+		ID:          t.ID,
+		Name:        t.Name,
+		Description: t.Description,
+		Members:     make([]*TeamMemberInfo, 0, len(t.Members)),
+		CreatedAt:   t.CreatedAt,
+	}
+	for _, m := range t.Members {
+		info.Members = append(info.Members, &TeamMemberInfo{
+			UserID:      m.Engineer.UserID,
+			Name:        m.Engineer.User.Name,
+			Email:       m.Engineer.User.Email,
+			Designation: m.Engineer.Designation,
+			IsActive:    m.Engineer.User.IsActive,
+			IsLead:      m.IsLead,
+		})
+	}
+	return info
+}
+
+/*
+=====================
+ Teams (admin)
+=====================
+*/
+
+func (s *SupportTeamService) List() ([]*TeamInfo, error) {
+	teams, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*TeamInfo, 0, len(teams))
+	for i := range teams {
+		result = append(result, toTeamInfo(&teams[i]))
+	}
+	return result, nil
+}
+
+func (s *SupportTeamService) Get(teamID uuid.UUID) (*TeamInfo, error) {
+	team, err := s.team(teamID)
+	if err != nil {
+		return nil, err
+	}
+	return toTeamInfo(team), nil
+}
+
+func (s *SupportTeamService) Create(
+	name string,
+	description string,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) (*TeamInfo, error) {
+
+	name = strings.TrimSpace(name)
+	if err := s.checkName(name, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	team := &models.SupportTeam{
+		Name:        name,
+		Description: description,
+		CreatedBy:   actorID,
+	}
+	if err := s.repo.Create(team); err != nil {
+		return nil, err
+	}
+
+	_ = s.auditRepo.Log("support_team", team.ID, "team_created", actorID, ip, userAgent)
+
+	return toTeamInfo(team), nil
+}
+
+func (s *SupportTeamService) Update(
+	teamID uuid.UUID,
+	name string,
+	description string,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) (*TeamInfo, error) {
+
+	if _, err := s.team(teamID); err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if err := s.checkName(name, teamID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(teamID, map[string]interface{}{
+		"name":        name,
+		"description": description,
+	}); err != nil {
+		return nil, err
+	}
+
+	_ = s.auditRepo.Log("support_team", teamID, "team_updated", actorID, ip, userAgent)
+
+	return s.Get(teamID)
+}
+
+// Tickets queued to the team become unscoped
+func (s *SupportTeamService) Delete(
+	teamID uuid.UUID,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	if _, err := s.team(teamID); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(teamID); err != nil {
+		return err
+	}
+
+	_ = s.auditRepo.Log("support_team", teamID, "team_deleted", actorID, ip, userAgent)
+
+	return nil
+}
+
+/*
+=====================
+ Members (admin)
+=====================
+*/
+
+// Adds the engineer or changes their lead flag
+func (s *SupportTeamService) SetMember(
+	teamID uuid.UUID,
+	userID uuid.UUID,
+	isLead bool,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) (*TeamInfo, error) {
+
+	if _, err := s.team(teamID); err != nil {
+		return nil, err
+	}
+
+	if err := s.requireEngineer(userID); err != nil {
+		return nil, err
+	}
+
+	engineer, err := s.repo.EnsureEngineer(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpsertMember(&models.SupportTeamMember{
+		TeamID:            teamID,
+		SupportEngineerID: engineer.ID,
+		IsLead:            isLead,
+		AddedBy:           actorID,
+	}); err != nil {
+		return nil, err
+	}
+
+	action := "team_member_set"
+	if isLead {
+		action = "team_lead_set"
+	}
+	_ = s.auditRepo.Log("support_team", teamID, action+":"+userID.String(), actorID, ip, userAgent)
+
+	return s.Get(teamID)
+}
+
+func (s *SupportTeamService) RemoveMember(
+	teamID uuid.UUID,
+	userID uuid.UUID,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	removed, err := s.repo.RemoveMember(teamID, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrNotTeamMember
+	}
+
+	_ = s.auditRepo.Log("support_team", teamID, "team_member_removed:"+userID.String(), actorID, ip, userAgent)
+
+	return nil
+}
+
+/*
+=====================
+ Ticket Queues
+=====================
+*/
+
+// Admin: nil teamID takes the ticket off any team queue
+func (s *SupportTeamService) QueueTicket(
+	ticketID uuid.UUID,
+	teamID *uuid.UUID,
+	actorID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	if _, err := s.ticketRepo.GetByID(ticketID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrResourceNotFound
+		}
+		return err
+	}
+
+	if teamID != nil {
+		if _, err := s.team(*teamID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.ticketRepo.UpdateFields(ticketID, map[string]interface{}{
+		"team_id": teamID,
+	}); err != nil {
+		return err
+	}
+
+	_ = s.auditRepo.Log("ticket", ticketID, "ticket_queued", actorID, ip, userAgent)
+
+	return nil
+}
+
+// Team lead (or anyone with ticket.assign): the route policy has already
+// checked the ticket; leads may only hand it to members of teams they lead
+func (s *SupportTeamService) Reassign(
+	ticketID uuid.UUID,
+	engineerID uuid.UUID,
+	actorID uuid.UUID,
+	actorRole models.Role,
+	ip string,
+	userAgent string,
+) error {
+
+	ticket, err := s.ticketRepo.GetByID(ticketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrResourceNotFound
+		}
+		return err
+	}
+	if ticket.Status == models.StatusClosed {
+		return errors.New("closed tickets cannot be reassigned")
+	}
+
+	if err := s.requireEngineer(engineerID); err != nil {
+		return err
+	}
+
+	canAssign, err := s.roles.HasPermission(actorRole, models.PermTicketAssign)
+	if err != nil {
+		return err
+	}
+	if !canAssign {
+		led, err := s.repo.LedTeamIDs(actorID)
+		if err != nil {
+			return err
+		}
+		inTeam, err := s.repo.InAnyTeam(engineerID, led)
+		if err != nil {
+			return err
+		}
+		if !inTeam {
+			return ErrNotTeamMember
+		}
+	}
+
+	if err := s.ticketRepo.Reassign(ticket, &models.TicketAssignment{
+		TicketID:   ticketID,
+		EngineerID: engineerID,
+		AssignedBy: actorID,
+		AssignedAt: time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	_ = s.auditRepo.Log("ticket", ticketID, "ticket_reassigned:"+engineerID.String(), actorID, ip, userAgent)
+
+	return nil
+}
+
+/*
+=====================
+ Helpers
+=====================
+*/
+
+func (s *SupportTeamService) team(teamID uuid.UUID) (*models.SupportTeam, error) {
+	team, err := s.repo.FindByID(teamID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrResourceNotFound
+		}
+		return nil, err
+	}
+	return team, nil
+}
+
+func (s *SupportTeamService) checkName(name string, exceptID uuid.UUID) error {
+	if name == "" {
+		return errors.New("team name is required")
+	}
+	taken, err := s.repo.NameTaken(name, exceptID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrTeamExists
+	}
+	return nil
+}
+
+// Active staff whose role can work tickets
+func (s *SupportTeamService) requireEngineer(userID uuid.UUID) error {
+	user, err := s.repo.FindUser(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if !user.IsActive {
+		return fmt.Errorf("%w: account is deactivated", ErrNotAnEngineer)
+	}
+
+	allowed, err := s.roles.HasPermission(user.Role, models.PermTicketWork)
+	if err != nil {
+		return err
+	}
+	if !allowed || user.Role == models.RoleAdmin {
+		return ErrNotAnEngineer
+	}
+	return nil
+}
@@ -113,6 +113,7 @@ func (s *TicketService) AssignTicket(
 	ticket.SupportMode = supportMode
 	ticket.ServiceCallType = serviceType
 	ticket.Status = models.StatusAssigned // Update Status
+	ticket.NeedsReassignment = false
 
 	// 3. Create Assignment Record
 	assignment := &models.TicketAssignment{
@@ -171,3 +172,13 @@ func (s *TicketService) CloseTicket(
 func (s *TicketService) GetAll() ([]models.Ticket, error) {
 	return s.repo.GetAll()
 }
+
+// Queued to the team or assigned to one of its members
+func (s *TicketService) GetByTeam(teamID uuid.UUID) ([]models.Ticket, error) {
+	return s.repo.FindByTeams([]uuid.UUID{teamID})
+}
+
+// Access is decided by the route policy (assignee, owner, company)
+func (s *TicketService) GetByID(ticketID uuid.UUID) (*models.Ticket, error) {
+	return s.repo.GetByID(ticketID)
+}
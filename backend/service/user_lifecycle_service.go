@@ -0,0 +1,264 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rbac/models"
+	"rbac/repository"
+)
+
+type UserLifecycleService struct {
+	repo        *repository.UserLifecycleRepository
+	auditRepo   *repository.AuditRepository
+	revocations repository.TokenRevocationStore
+}
+
+func NewUserLifecycleService(
+	repo *repository.UserLifecycleRepository,
+	auditRepo *repository.AuditRepository,
+	revocations repository.TokenRevocationStore,
+) *UserLifecycleService {
+	return &UserLifecycleService{
+		repo:        repo,
+		auditRepo:   auditRepo,
+		revocations: revocations,
+	}
+}
+
+/*
+=====================
+ Response DTOs
+=====================
+*/
+
+type LifecycleResult struct {
+	UserID            uuid.UUID `json:"user_id"`
+	IsActive          bool      `json:"is_active"`
+	TicketsReassigned int       `json:"tickets_reassigned"`
+	TicketsFlagged    int       `json:"tickets_flagged"`
+}
+
+/*
+=====================
+ Deactivate / Reactivate
+=====================
+*/
+
+// reassignTo (optional) takes over a support engineer's open tickets;
+// without it they go back to the admin queue flagged for reassignment.
+func (s *UserLifecycleService) Deactivate(
+	userID uuid.UUID,
+	adminID uuid.UUID,
+	reassignTo *uuid.UUID,
+	ip string,
+	userAgent string,
+) (*LifecycleResult, error) {
+
+	user, err := s.checkTarget(userID, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, errors.New("user already deactivated")
+	}
+
+	result := &LifecycleResult{UserID: userID}
+
+	err = s.repo.WithTransaction(func(tx *repository.UserLifecycleRepository) error {
+		return s.deactivateTx(tx, user, adminID, reassignTo, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.afterDeactivate(user, adminID, "user_deactivated", result, ip, userAgent)
+
+	return result, nil
+}
+
+func (s *UserLifecycleService) Reactivate(
+	userID uuid.UUID,
+	adminID uuid.UUID,
+	ip string,
+	userAgent string,
+) (*LifecycleResult, error) {
+
+	user, err := s.repo.FindUser(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.OffboardedAt != nil {
+		return nil, errors.New("offboarded users cannot be reactivated")
+	}
+	if user.IsActive {
+		return nil, errors.New("user is already active")
+	}
+
+	if err := s.repo.SetActive(userID, true, adminID); err != nil {
+		return nil, err
+	}
+
+	_ = s.auditRepo.Log("user", userID, "user_reactivated", adminID, ip, userAgent)
+
+	return &LifecycleResult{UserID: userID, IsActive: true}, nil
+}
+
+/*
+=====================
+ Offboard (permanent)
+=====================
+*/
+
+// Deactivates (if needed) and wipes credentials + PII. The row stays so
+// tickets, audit logs and assignments keep pointing at something.
+func (s *UserLifecycleService) Offboard(
+	userID uuid.UUID,
+	adminID uuid.UUID,
+	reassignTo *uuid.UUID,
+	ip string,
+	userAgent string,
+) (*LifecycleResult, error) {
+
+	user, err := s.checkTarget(userID, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if user.OffboardedAt != nil {
+		return nil, errors.New("user already offboarded")
+	}
+
+	result := &LifecycleResult{UserID: userID}
+
+	err = s.repo.WithTransaction(func(tx *repository.UserLifecycleRepository) error {
+		if user.IsActive {
+			if err := s.deactivateTx(tx, user, adminID, reassignTo, result); err != nil {
+				return err
+			}
+		}
+		return tx.Scrub(userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.afterDeactivate(user, adminID, "user_offboarded", result, ip, userAgent)
+
+	return result, nil
+}
+
+/*
+=====================
+ Helpers
+=====================
+*/
+
+func (s *UserLifecycleService) checkTarget(
+	userID uuid.UUID,
+	adminID uuid.UUID,
+) (*models.User, error) {
+
+	if userID == adminID {
+		return nil, errors.New("cannot deactivate your own account")
+	}
+
+	user, err := s.repo.FindUser(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	// Never lock everyone out of the admin console
+	if user.Role == models.RoleAdmin && user.IsActive {
+		admins, err := s.repo.CountActiveAdmins()
+		if err != nil {
+			return nil, err
+		}
+		if admins <= 1 {
+			return nil, errors.New("cannot deactivate the last active admin")
+		}
+	}
+
+	return user, nil
+}
+
+func (s *UserLifecycleService) deactivateTx(
+	tx *repository.UserLifecycleRepository,
+	user *models.User,
+	adminID uuid.UUID,
+	reassignTo *uuid.UUID,
+	result *LifecycleResult,
+) error {
+
+	if err := tx.SetActive(user.ID, false, adminID); err != nil {
+		return err
+	}
+
+	if err := tx.RevokeSessions(user.ID); err != nil {
+		return err
+	}
+
+	// Tickets follow the engineer profile, not the role name: custom
+	// roles can carry one too
+	engineer, err := tx.IsEngineer(user.ID, false)
+	if err != nil {
+		return err
+	}
+	if !engineer {
+		return nil
+	}
+
+	if reassignTo != nil {
+		successor, err := tx.FindUser(*reassignTo)
+		if err != nil || successor.ID == user.ID || !successor.IsActive {
+			return errors.New("reassign_to must be another active support engineer")
+		}
+		if ok, err := tx.IsEngineer(successor.ID, true); err != nil || !ok {
+			return errors.New("reassign_to must be another active support engineer")
+		}
+	}
+
+	tickets, err := tx.FindOpenTicketsAssignedTo(user.ID)
+	if err != nil {
+		return err
+	}
+
+	for i := range tickets {
+		if reassignTo != nil {
+			if err := tx.ReassignTicket(&tickets[i], *reassignTo, adminID); err != nil {
+				return err
+			}
+			result.TicketsReassigned++
+			continue
+		}
+
+		if err := tx.FlagTicket(&tickets[i], adminID); err != nil {
+			return err
+		}
+		result.TicketsFlagged++
+	}
+
+	return nil
+}
+
+// Access tokens already out there die at the cutoff; audit last
+func (s *UserLifecycleService) afterDeactivate(
+	user *models.User,
+	adminID uuid.UUID,
+	action string,
+	result *LifecycleResult,
+	ip string,
+	userAgent string,
+) {
+	_ = s.revocations.RevokeUserTokens(user.ID, time.Now())
+
+	_ = s.auditRepo.Log("user", user.ID, action, adminID, ip, userAgent)
+
+	if result.TicketsReassigned > 0 {
+		_ = s.auditRepo.Log("user", user.ID, "tickets_reassigned", adminID, ip, userAgent)
+	}
+	if result.TicketsFlagged > 0 {
+		_ = s.auditRepo.Log("user", user.ID, "tickets_flagged_for_reassignment", adminID, ip, userAgent)
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/models"
+	"rbac/repository"
+	"rbac/testutil"
+)
+
+func engineer(t *testing.T, db *gorm.DB, role models.Role) *models.User {
+	t.Helper()
+
+	user := testutil.User(t, db, role)
+	if err := db.Create(&models.SupportEngineer{UserID: user.ID, IsActive: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func assignedTicket(t *testing.T, db *gorm.DB, engineerID, adminID uuid.UUID) *models.Ticket {
+	t.Helper()
+
+	ticket := &models.Ticket{Title: "Printer jam", Status: models.StatusOpen, CreatedBy: adminID}
+	if err := db.Create(ticket).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.TicketAssignment{
+		TicketID:   ticket.ID,
+		EngineerID: engineerID,
+		AssignedBy: adminID,
+		AssignedAt: time.Now(),
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return ticket
+}
+
+// Tickets follow the engineer profile, so custom-role engineers hand
+// their work over too
+func TestOffboardingReassignsCustomRoleEngineers(t *testing.T) {
+	db := testutil.NewDB(t)
+	lifecycle := NewUserLifecycleService(
+		repository.NewUserLifecycleRepository(db),
+		repository.NewAuditRepository(db),
+		repository.NewPostgresTokenRevocationStore(db),
+	)
+
+	admin := testutil.User(t, db, models.RoleAdmin)
+	leaving := engineer(t, db, models.Role("field_lead"))
+	successor := engineer(t, db, models.Role("field_tech"))
+	assignedTicket(t, db, leaving.ID, admin.ID)
+
+	// Without an engineer profile there is nobody to hand work to
+	plain := testutil.User(t, db, models.Role("dispatcher"))
+	if _, err := lifecycle.Deactivate(leaving.ID, admin.ID, &plain.ID, "", ""); err == nil {
+		t.Fatal("tickets handed to a user with no engineer profile")
+	}
+
+	result, err := lifecycle.Deactivate(leaving.ID, admin.ID, &successor.ID, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TicketsReassigned != 1 {
+		t.Fatalf("tickets reassigned = %d, want 1", result.TicketsReassigned)
+	}
+}
+
+// A customer organization outlives its founding contact and only goes
+// dark with its last active member
+func TestCustomerFollowsItsLastActiveMember(t *testing.T) {
+	db := testutil.NewDB(t)
+	lifecycle := NewUserLifecycleService(
+		repository.NewUserLifecycleRepository(db),
+		repository.NewAuditRepository(db),
+		repository.NewPostgresTokenRevocationStore(db),
+	)
+
+	admin := testutil.User(t, db, models.RoleAdmin)
+	founder := testutil.User(t, db, models.RoleCustomer)
+	colleague := testutil.User(t, db, models.RoleCustomer)
+
+	customer := &models.Customer{UserID: founder.ID, Company: "Acme", IsActive: true}
+	if err := db.Create(customer).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []*models.User{founder, colleague} {
+		if err := db.Create(&models.CustomerMember{CustomerID: customer.ID, UserID: u.ID}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	customerActive := func() bool {
+		t.Helper()
+		var c models.Customer
+		if err := db.First(&c, "id = ?", customer.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		return c.IsActive
+	}
+
+	if _, err := lifecycle.Deactivate(founder.ID, admin.ID, nil, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if !customerActive() {
+		t.Fatal("organization deactivated with its founding contact")
+	}
+
+	if _, err := lifecycle.Deactivate(colleague.ID, admin.ID, nil, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if customerActive() {
+		t.Fatal("organization still active without any active member")
+	}
+
+	if _, err := lifecycle.Reactivate(colleague.ID, admin.ID, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if !customerActive() {
+		t.Fatal("organization not reactivated with its member")
+	}
+}
+
+// Offboarding leaves no TOTP secret behind, confirmed or mid-rotation
+func TestOffboardingWipesTOTPSecrets(t *testing.T) {
+	db := testutil.NewDB(t)
+	lifecycle := NewUserLifecycleService(
+		repository.NewUserLifecycleRepository(db),
+		repository.NewAuditRepository(db),
+		repository.NewPostgresTokenRevocationStore(db),
+	)
+
+	admin := testutil.User(t, db, models.RoleAdmin)
+	leaving := testutil.User(t, db, models.RoleCustomer)
+	db.Model(leaving).Updates(map[string]interface{}{
+		"totp_secret":         "enc-current",
+		"totp_pending_secret": "enc-pending",
+		"totp_last_step":      42,
+	})
+
+	if _, err := lifecycle.Offboard(leaving.ID, admin.ID, nil, "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var got models.User
+	if err := db.First(&got, "id = ?", leaving.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got.TOTPSecret != "" || got.TOTPPendingSecret != "" || got.TOTPLastStep != 0 {
+		t.Fatalf("offboarded user kept TOTP state: %q %q %d", got.TOTPSecret, got.TOTPPendingSecret, got.TOTPLastStep)
+	}
+}
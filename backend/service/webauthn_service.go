@@ -0,0 +1,479 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+
+	"rbac/config"
+	"rbac/models"
+	"rbac/repository"
+	"rbac/utils"
+)
+
+const (
+	ceremonyRegister = "register"
+	ceremonyLogin    = "login"
+	ceremonyTwoFA    = "2fa"
+
+	webauthnSessionTTL = 5 * time.Minute
+)
+
+type WebAuthnService struct {
+	wa       *webauthn.WebAuthn
+	repo     *repository.WebAuthnRepository
+	authRepo *repository.AuthRepository
+	auth     *AuthService
+}
+
+func NewWebAuthnService(
+	repo *repository.WebAuthnRepository,
+	authRepo *repository.AuthRepository,
+	auth *AuthService,
+	cfg *config.Config,
+) (*WebAuthnService, error) {
+
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.WebAuthn.RPID,
+		RPDisplayName: cfg.WebAuthn.RPDisplayName,
+		RPOrigins:     cfg.WebAuthn.RPOrigins,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebAuthnService{
+		wa:       wa,
+		repo:     repo,
+		authRepo: authRepo,
+		auth:     auth,
+	}, nil
+}
+
+/*
+=====================
+ Response DTOs
+=====================
+*/
+
+type WebAuthnCredentialInfo struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+/*
+=====================
+ webauthn.User adapter
+=====================
+*/
+
+type webAuthnUser struct {
+	user  *models.User
+	creds []webauthn.Credential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	id := u.user.ID
+	return id[:]
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	if u.user.Name != "" {
+		return u.user.Name
+	}
+	return u.user.Email
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.creds
+}
+
+func (s *WebAuthnService) loadUser(userID uuid.UUID) (*webAuthnUser, error) {
+	user, err := s.authRepo.FindUserByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	rows, err := s.repo.FindByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	creds := make([]webauthn.Credential, 0, len(rows))
+	for _, row := range rows {
+		var cred webauthn.Credential
+		if err := json.Unmarshal([]byte(row.Data), &cred); err != nil {
+			continue
+		}
+		creds = append(creds, cred)
+	}
+
+	return &webAuthnUser{user: user, creds: creds}, nil
+}
+
+/*
+=====================
+ Session helpers
+=====================
+*/
+
+func (s *WebAuthnService) saveSession(
+	userID *uuid.UUID,
+	ceremony string,
+	data *webauthn.SessionData,
+) (uuid.UUID, error) {
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	// Opportunistic cleanup of abandoned ceremonies
+	_ = s.repo.DeleteExpiredSessions()
+
+	session := &models.WebAuthnSession{
+		UserID:    userID,
+		Ceremony:  ceremony,
+		Data:      string(raw),
+		ExpiresAt: time.Now().Add(webauthnSessionTTL),
+	}
+
+	if err := s.repo.CreateSession(session); err != nil {
+		return uuid.Nil, err
+	}
+
+	return session.ID, nil
+}
+
+func (s *WebAuthnService) takeSession(
+	sessionID uuid.UUID,
+	ceremony string,
+) (*models.WebAuthnSession, *webauthn.SessionData, error) {
+
+	session, err := s.repo.TakeSession(sessionID, ceremony)
+	if err != nil {
+		return nil, nil, errors.New("passkey session expired")
+	}
+
+	var data webauthn.SessionData
+	if err := json.Unmarshal([]byte(session.Data), &data); err != nil {
+		return nil, nil, err
+	}
+
+	return session, &data, nil
+}
+
+// Persists the updated sign counter / flags after an assertion
+func (s *WebAuthnService) touchCredential(cred *webauthn.Credential) error {
+	if cred.Authenticator.CloneWarning {
+		return errors.New("passkey may be cloned, please contact an admin")
+	}
+
+	row, err := s.repo.FindByCredentialID(cred.ID)
+	if err != nil {
+		return errors.New("unknown passkey")
+	}
+
+	raw, err := json.Marshal(cred)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpdateCredentialData(row.ID, string(raw))
+}
+
+/*
+=====================
+ Registration
+=====================
+*/
+
+func (s *WebAuthnService) BeginRegistration(
+	userID uuid.UUID,
+) (*protocol.CredentialCreation, uuid.UUID, error) {
+
+	u, err := s.loadUser(userID)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	exclude := make([]protocol.CredentialDescriptor, 0, len(u.creds))
+	for _, cred := range u.creds {
+		exclude = append(exclude, cred.Descriptor())
+	}
+
+	options, data, err := s.wa.BeginRegistration(
+		u,
+		webauthn.WithExclusions(exclude),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+	)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	sessionID, err := s.saveSession(&u.user.ID, ceremonyRegister, data)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	return options, sessionID, nil
+}
+
+func (s *WebAuthnService) FinishRegistration(
+	userID uuid.UUID,
+	sessionID uuid.UUID,
+	name string,
+	r *http.Request,
+	ip string,
+	userAgent string,
+) (*WebAuthnCredentialInfo, error) {
+
+	session, data, err := s.takeSession(sessionID, ceremonyRegister)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.UserID == nil || *session.UserID != userID {
+		return nil, errors.New("passkey session mismatch")
+	}
+
+	u, err := s.loadUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := s.wa.FinishRegistration(u, *data, r)
+	if err != nil {
+		return nil, errors.New("passkey registration failed")
+	}
+
+	raw, err := json.Marshal(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = "Passkey"
+	}
+
+	row := &models.WebAuthnCredential{
+		UserID:       userID,
+		CredentialID: cred.ID,
+		Name:         name,
+		Data:         string(raw),
+	}
+
+	if err := s.repo.CreateCredential(row); err != nil {
+		return nil, err
+	}
+
+	_ = s.auth.auditRepo.Log("user", userID, "passkey_registered", userID, ip, userAgent)
+
+	return &WebAuthnCredentialInfo{
+		ID:        row.ID,
+		Name:      row.Name,
+		CreatedAt: row.CreatedAt,
+	}, nil
+}
+
+func (s *WebAuthnService) ListCredentials(userID uuid.UUID) ([]WebAuthnCredentialInfo, error) {
+	rows, err := s.repo.FindByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]WebAuthnCredentialInfo, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, WebAuthnCredentialInfo{
+			ID:         row.ID,
+			Name:       row.Name,
+			CreatedAt:  row.CreatedAt,
+			LastUsedAt: row.LastUsedAt,
+		})
+	}
+	return result, nil
+}
+
+func (s *WebAuthnService) DeleteCredential(
+	userID uuid.UUID,
+	credentialID uuid.UUID,
+	ip string,
+	userAgent string,
+) error {
+
+	deleted, err := s.repo.DeleteCredential(userID, credentialID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("passkey not found")
+	}
+
+	// Last passkey gone → fall back to email OTP
+	remaining, _ := s.repo.CountByUser(userID)
+	if remaining == 0 {
+		user, err := s.authRepo.FindUserByID(userID)
+		if err == nil && user.TwoFAMethod == models.TwoFAMethodWebAuthn {
+			_ = s.authRepo.SetTwoFAMethod(userID, models.TwoFAMethodEmail)
+		}
+	}
+
+	_ = s.auth.auditRepo.Log("user", userID, "passkey_removed", userID, ip, userAgent)
+
+	return nil
+}
+
+/*
+=====================
+ Passwordless Login
+=====================
+*/
+
+func (s *WebAuthnService) BeginLogin() (*protocol.CredentialAssertion, uuid.UUID, error) {
+	options, data, err := s.wa.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	sessionID, err := s.saveSession(nil, ceremonyLogin, data)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	return options, sessionID, nil
+}
+
+func (s *WebAuthnService) FinishLogin(
+	sessionID uuid.UUID,
+	r *http.Request,
+	ip string,
+	userAgent string,
+) (*LoginResponse, error) {
+
+	_, data, err := s.takeSession(sessionID, ceremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	var found *webAuthnUser
+
+	// userHandle = users.id bytes (see WebAuthnID)
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, errors.New("invalid user handle")
+		}
+
+		u, err := s.loadUser(userID)
+		if err != nil {
+			return nil, err
+		}
+
+		found = u
+		return u, nil
+	}
+
+	cred, err := s.wa.FinishDiscoverableLogin(handler, *data, r)
+	if err != nil || found == nil {
+		return nil, errors.New("passkey login failed")
+	}
+
+	if err := s.touchCredential(cred); err != nil {
+		return nil, err
+	}
+
+	// 🔒 Same gate as password login
+	if found.user.MustResetPassword {
+		return nil, ErrPasswordResetRequired
+	}
+
+	now := time.Now()
+	_ = s.authRepo.UpdateLastLogin(found.user.ID, &now)
+
+	_ = s.auth.auditRepo.Log("user", found.user.ID, "passkey_login", found.user.ID, ip, userAgent)
+
+	// User-verified passkey = possession + inherence → no extra 2FA step
+	auth := utils.NewAuthContext(utils.AMRHardwareKey, utils.AMRMultiFactor)
+	return s.auth.issueTokens(found.user, auth, ip, userAgent)
+}
+
+/*
+=====================
+ Passkey as Second Factor
+=====================
+*/
+
+func (s *WebAuthnService) BeginTwoFA(
+	userID uuid.UUID,
+) (*protocol.CredentialAssertion, uuid.UUID, error) {
+
+	u, err := s.loadUser(userID)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	if len(u.creds) == 0 {
+		return nil, uuid.Nil, errors.New("no passkey registered")
+	}
+
+	options, data, err := s.wa.BeginLogin(u)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	sessionID, err := s.saveSession(&u.user.ID, ceremonyTwoFA, data)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	return options, sessionID, nil
+}
+
+func (s *WebAuthnService) FinishTwoFA(
+	userID uuid.UUID,
+	sessionID uuid.UUID,
+	remember bool,
+	r *http.Request,
+	ip string,
+	userAgent string,
+) (*LoginResponse, *string, error) {
+
+	session, data, err := s.takeSession(sessionID, ceremonyTwoFA)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if session.UserID == nil || *session.UserID != userID {
+		return nil, nil, errors.New("passkey session mismatch")
+	}
+
+	u, err := s.loadUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cred, err := s.wa.FinishLogin(u, *data, r)
+	if err != nil {
+		return nil, nil, errors.New("passkey verification failed")
+	}
+
+	if err := s.touchCredential(cred); err != nil {
+		return nil, nil, err
+	}
+
+	auth := utils.NewAuthContext(utils.AMRPassword, utils.AMRHardwareKey, utils.AMRMultiFactor)
+	return s.auth.completeTwoFALogin(u.user, remember, auth, ip, userAgent)
+}
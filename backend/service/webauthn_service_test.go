@@ -0,0 +1,115 @@
+package service
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"rbac/models"
+	"rbac/repository"
+	"rbac/testutil"
+	"rbac/utils"
+)
+
+func newTestWebAuthnService(t *testing.T) (*WebAuthnService, *AuthService, *testutil.Authenticator) {
+	t.Helper()
+
+	auth, db, cfg := newTestAuthService(t)
+	svc, err := NewWebAuthnService(
+		repository.NewWebAuthnRepository(db),
+		repository.NewAuthRepository(db),
+		auth,
+		cfg,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return svc, auth, testutil.NewAuthenticator(t, cfg.WebAuthn.RPOrigins[0], cfg.WebAuthn.RPID)
+}
+
+func passkeyRequest(body []byte) *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+}
+
+func registerPasskey(t *testing.T, svc *WebAuthnService, device *testutil.Authenticator, userID uuid.UUID) {
+	t.Helper()
+
+	options, sessionID, err := svc.BeginRegistration(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.FinishRegistration(userID, sessionID, "", passkeyRequest(device.Register(t, options)), "", ""); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+}
+
+func accessMethods(t *testing.T, auth *AuthService, token string) []string {
+	t.Helper()
+
+	claims, err := utils.ValidateToken(token, auth.keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return claims.AMR
+}
+
+func TestPasskeyPasswordlessLogin(t *testing.T) {
+	svc, auth, device := newTestWebAuthnService(t)
+	user := testutil.User(t, auth.db, models.RoleSupport)
+	registerPasskey(t, svc, device, user.ID)
+
+	options, sessionID, err := svc.BeginLogin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := svc.FinishLogin(sessionID, passkeyRequest(device.Assert(t, options)), "", "")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if resp.User.ID != user.ID {
+		t.Fatalf("logged in as %s, want %s", resp.User.ID, user.ID)
+	}
+	if !slices.Contains(accessMethods(t, auth, resp.AccessToken), utils.AMRHardwareKey) {
+		t.Fatal("passkey login token does not record the hardware key")
+	}
+
+	// Each challenge is single-use
+	if _, err := svc.FinishLogin(sessionID, passkeyRequest(device.Assert(t, options)), "", ""); err == nil {
+		t.Fatal("replayed login ceremony succeeded")
+	}
+}
+
+func TestPasskeySecondFactor(t *testing.T) {
+	svc, auth, device := newTestWebAuthnService(t)
+	user := testutil.User(t, auth.db, models.RoleSupport)
+	registerPasskey(t, svc, device, user.ID)
+
+	options, sessionID, err := svc.BeginTwoFA(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A ceremony started for one user cannot finish another's login
+	other := testutil.User(t, auth.db, models.RoleSupport)
+	if _, _, err := svc.FinishTwoFA(other.ID, sessionID, false, passkeyRequest(device.Assert(t, options)), "", ""); err == nil {
+		t.Fatal("second factor finished for the wrong user")
+	}
+
+	options, sessionID, err = svc.BeginTwoFA(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, _, err := svc.FinishTwoFA(user.ID, sessionID, false, passkeyRequest(device.Assert(t, options)), "", "")
+	if err != nil {
+		t.Fatalf("second factor: %v", err)
+	}
+	methods := accessMethods(t, auth, resp.AccessToken)
+	if !slices.Contains(methods, utils.AMRPassword) || !slices.Contains(methods, utils.AMRMultiFactor) {
+		t.Fatalf("second factor token methods = %v", methods)
+	}
+}
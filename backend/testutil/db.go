@@ -0,0 +1,86 @@
+// Package testutil holds helpers shared by the package tests.
+package testutil
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/go-sqlite"
+	gormsqlite "github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+
+	"rbac/database"
+)
+
+/*
+=====================
+ Test Database
+=====================
+ In-memory SQLite standing in for Postgres: the schema comes from
+ database.Migrate, and the Postgres functions the models and
+ repositories use (gen_random_uuid, NOW) are registered as SQL
+ functions. Each test gets its own database.
+*/
+
+var registerOnce sync.Once
+
+func NewDB(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	registerOnce.Do(func() {
+		sqlite.MustRegisterScalarFunction("gen_random_uuid", 0,
+			func(*sqlite.FunctionContext, []driver.Value) (driver.Value, error) {
+				return uuid.NewString(), nil
+			})
+		// Same text format the driver writes time.Time parameters in
+		sqlite.MustRegisterScalarFunction("now", 0,
+			func(*sqlite.FunctionContext, []driver.Value) (driver.Value, error) {
+				return time.Now().Format("2006-01-02 15:04:05.999999999-07:00"), nil
+			})
+	})
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", uuid.NewString())
+	db, err := gorm.Open(dialector{gormsqlite.Open(dsn).(*gormsqlite.Dialector)}, &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1) // one connection = one in-memory database
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	database.Migrate(db)
+	return db
+}
+
+// SQLite only accepts expressions as column defaults in parentheses
+type dialector struct {
+	*gormsqlite.Dialector
+}
+
+func (d dialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return migrator{d.Dialector.Migrator(db).(gormsqlite.Migrator)}
+}
+
+type migrator struct {
+	gormsqlite.Migrator
+}
+
+func (m migrator) FullDataTypeOf(field *schema.Field) clause.Expr {
+	expr := m.Migrator.FullDataTypeOf(field)
+	expr.SQL = strings.Replace(expr.SQL, "DEFAULT gen_random_uuid()", "DEFAULT (gen_random_uuid())", 1)
+	return expr
+}
@@ -0,0 +1,42 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"rbac/config"
+	"rbac/models"
+)
+
+// Defaults from LoadConfig with everything outbound (mail, SSO) off
+func Config() *config.Config {
+	cfg := config.LoadConfig()
+	cfg.Server.Env = "test"
+	cfg.TwoFA.EncryptionKey = "test-two-fa-key"
+	cfg.Mail = config.MailConfig{}
+	cfg.OIDC = config.OIDCConfig{}
+	cfg.LoginAlerts.Enabled = false
+	cfg.Password.BlocklistFile = ""
+	cfg.Password.Algorithm = "bcrypt"
+	cfg.Password.BcryptCost = 4 // hashing speed, not strength
+	return cfg
+}
+
+// Active user with a unique email; no password set
+func User(t testing.TB, db *gorm.DB, role models.Role) *models.User {
+	t.Helper()
+
+	user := &models.User{
+		Name:     string(role) + " user",
+		Email:    uuid.NewString()[:8] + "@example.com",
+		Password: "-",
+		Role:     role,
+		IsActive: true,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create %s user: %v", role, err)
+	}
+	return user
+}
@@ -0,0 +1,169 @@
+package testutil
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+/*
+=====================
+ Mock OIDC Provider
+=====================
+ Local issuer with discovery, JWKS and a token endpoint that enforces
+ PKCE (S256). The browser leg is Authorize: it reads the authorization
+ URL the app built and hands back the state and a code, as the IdP's
+ redirect would.
+*/
+
+type OIDCProvider struct {
+	*httptest.Server
+	ClientID string
+
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]oidcGrant
+}
+
+type oidcGrant struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+const oidcKeyID = "test-key"
+
+func NewOIDCProvider(t testing.TB, clientID string) *OIDCProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &OIDCProvider{ClientID: clientID, key: key, codes: map[string]oidcGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/keys", p.keys)
+	mux.HandleFunc("/token", p.token)
+
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// Signs the user in at the IdP. claims become the ID token (iss, aud,
+// exp, iat and the request's nonce are filled in unless set).
+func (p *OIDCProvider) Authorize(t testing.TB, authURL string, claims jwt.MapClaims) (state, code string) {
+	t.Helper()
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+
+	if q.Get("client_id") != p.ClientID || q.Get("response_type") != "code" {
+		t.Fatalf("authorization request: %s", authURL)
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("authorization request without PKCE: %s", authURL)
+	}
+
+	id := jwt.MapClaims{
+		"iss":   p.URL,
+		"aud":   p.ClientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"nonce": q.Get("nonce"),
+	}
+	for k, v := range claims {
+		id[k] = v
+	}
+
+	code = uuid.NewString()
+	p.mu.Lock()
+	p.codes[code] = oidcGrant{challenge: q.Get("code_challenge"), claims: id}
+	p.mu.Unlock()
+
+	return q.Get("state"), code
+}
+
+func (p *OIDCProvider) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.URL,
+		"authorization_endpoint":                p.URL + "/authorize",
+		"token_endpoint":                        p.URL + "/token",
+		"jwks_uri":                              p.URL + "/keys",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (p *OIDCProvider) keys(w http.ResponseWriter, _ *http.Request) {
+	pub := p.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": oidcKeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+// Codes are single-use and only redeem with the matching verifier
+func (p *OIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	p.mu.Lock()
+	grant, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, grant.claims)
+	token.Header["kid"] = oidcKeyID
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": uuid.NewString(),
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,156 @@
+package testutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+// Software passkey: one P-256 credential, "none" attestation, and user
+// verification on every ceremony. Answers the options the server hands
+// out with the JSON body a browser would post back.
+type Authenticator struct {
+	Origin string
+	RPID   string
+
+	key        *ecdsa.PrivateKey
+	credID     []byte
+	userHandle []byte
+	signCount  uint32
+}
+
+func NewAuthenticator(t testing.TB, origin, rpID string) *Authenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credID := make([]byte, 16)
+	_, _ = rand.Read(credID)
+
+	return &Authenticator{Origin: origin, RPID: rpID, key: key, credID: credID}
+}
+
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+// navigator.credentials.create()
+func (a *Authenticator) Register(t testing.TB, options *protocol.CredentialCreation) []byte {
+	t.Helper()
+
+	switch id := options.Response.User.ID.(type) {
+	case protocol.URLEncodedBase64:
+		a.userHandle = id
+	case []byte:
+		a.userHandle = id
+	}
+
+	cose, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: a.key.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authData := a.authData(flagUserPresent | flagUserVerified | flagAttestedData)
+	authData = append(authData, make([]byte, 16)...) // AAGUID
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credID)))
+	authData = append(authData, a.credID...)
+	authData = append(authData, cose...)
+
+	attestation, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a.credential(t, map[string]string{
+		"clientDataJSON":    b64(a.clientData(t, "webauthn.create", options.Response.Challenge)),
+		"attestationObject": b64(attestation),
+	})
+}
+
+// navigator.credentials.get()
+func (a *Authenticator) Assert(t testing.TB, options *protocol.CredentialAssertion) []byte {
+	t.Helper()
+
+	a.signCount++
+	authData := a.authData(flagUserPresent | flagUserVerified)
+	clientData := a.clientData(t, "webauthn.get", options.Response.Challenge)
+
+	digest := sha256.Sum256(clientData)
+	signed := sha256.Sum256(append(append([]byte{}, authData...), digest[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, signed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a.credential(t, map[string]string{
+		"clientDataJSON":    b64(clientData),
+		"authenticatorData": b64(authData),
+		"signature":         b64(sig),
+		"userHandle":        b64(a.userHandle),
+	})
+}
+
+func (a *Authenticator) authData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	out := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(out, a.signCount)
+}
+
+func (a *Authenticator) clientData(t testing.TB, ceremony string, challenge protocol.URLEncodedBase64) []byte {
+	t.Helper()
+
+	raw, err := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": challenge.String(),
+		"origin":    a.Origin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func (a *Authenticator) credential(t testing.TB, response map[string]string) []byte {
+	t.Helper()
+
+	raw, err := json.Marshal(map[string]any{
+		"id":       b64(a.credID),
+		"rawId":    b64(a.credID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -23,39 +23,156 @@ type Claims struct {
 	UserID string      `json:"user_id"` // UUID as string
 	Email  string      `json:"email"`
 	Role   models.Role `json:"role"`
+
+	// Set only on impersonation tokens: the admin really acting (RFC 8693 `act`)
+	Actor *ActorClaim `json:"act,omitempty"`
+
+	// Set only on break-glass tokens: Role is the elevated one
+	Elevation *ElevationClaim `json:"elev,omitempty"`
+
+	// When / how the user last proved who they are (OIDC-style).
+	// Refresh carries them forward; only a login or /auth/reauth moves them.
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	AMR      []string         `json:"amr,omitempty"`
+
+	// Refresh-token family the token was minted for; revoking the session
+	// denies it in the revocation store. Absent on impersonation tokens.
+	SessionID string `json:"sid,omitempty"`
+
 	jwt.RegisteredClaims
 }
 
+type ActorClaim struct {
+	Subject string `json:"sub"` // admin user ID
+	Email   string `json:"email"`
+}
+
+type ElevationClaim struct {
+	GrantID  string      `json:"grant"` // models.ElevationGrant ID
+	BaseRole models.Role `json:"base_role"`
+}
+
+// Authentication method references (RFC 8176)
+const (
+	AMRPassword    = "pwd"
+	AMROTP         = "otp"
+	AMRHardwareKey = "hwk" // passkey / security key
+	AMRFederated   = "fed" // corporate IdP (OIDC)
+	AMRMultiFactor = "mfa"
+)
+
+// Every token this API issues names itself and the SPA; ValidateToken
+// refuses anything else signed with the same keys
+const (
+	tokenIssuer   = "ticketing-api"
+	tokenAudience = "ticketing-web"
+)
+
+// How a session was authenticated; zero value = never (impersonation)
+type AuthContext struct {
+	Time    time.Time
+	Methods []string
+	Session uuid.UUID // refresh-token family, uuid.Nil = none
+}
+
+func NewAuthContext(methods ...string) AuthContext {
+	return AuthContext{Time: time.Now(), Methods: methods}
+}
+
 /*
 =====================
  Access Token
 =====================
 */
 
+// Signed with the key ring's active key (RS256 / EdDSA, `kid` header)
 func GenerateAccessToken(
 	user *models.User,
-	secret string,
+	keys *KeyRing,
 	expiry time.Duration,
+	auth AuthContext,
 ) (string, error) {
 
+	return keys.Sign(newAccessClaims(user, expiry, auth))
+}
+
+// Short-lived token for `user`, carrying the admin in `act`.
+// No refresh token is ever issued for it.
+func GenerateImpersonationToken(
+	user *models.User,
+	actor *models.User,
+	keys *KeyRing,
+	expiry time.Duration,
+) (string, *Claims, error) {
+
+	// No auth_time: impersonation can never pass a step-up check
+	claims := newAccessClaims(user, expiry, AuthContext{})
+	claims.Actor = &ActorClaim{
+		Subject: actor.ID.String(),
+		Email:   actor.Email,
+	}
+
+	token, err := keys.Sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, &claims, nil
+}
+
+// Access token for `user` holding the elevated role while grantID is
+// active. AuthMiddleware re-checks the grant on every request.
+func GenerateElevatedToken(
+	user *models.User,
+	role models.Role,
+	grantID uuid.UUID,
+	keys *KeyRing,
+	expiry time.Duration,
+	auth AuthContext,
+) (string, *Claims, error) {
+
+	claims := newAccessClaims(user, expiry, auth)
+	claims.Role = role
+	claims.Elevation = &ElevationClaim{
+		GrantID:  grantID.String(),
+		BaseRole: user.Role,
+	}
+
+	token, err := keys.Sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, &claims, nil
+}
+
+func newAccessClaims(user *models.User, expiry time.Duration, auth AuthContext) Claims {
 	now := time.Now()
 
-	claims := Claims{
-		UserID: user.ID.String(), // ✅ UUID → string
-		Email:  user.Email,
-		Role:   user.Role,
+	var authTime *jwt.NumericDate
+	if !auth.Time.IsZero() {
+		authTime = jwt.NewNumericDate(auth.Time)
+	}
+
+	var sessionID string
+	if auth.Session != uuid.Nil {
+		sessionID = auth.Session.String()
+	}
+
+	return Claims{
+		UserID:    user.ID.String(), // ✅ UUID → string
+		Email:     user.Email,
+		Role:      user.Role,
+		AuthTime:  authTime,
+		AMR:       auth.Methods,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    "ticketing-api",
+			Issuer:    tokenIssuer,
 			Subject:   user.ID.String(), // ✅ UUID in `sub`
-			Audience:  []string{"ticketing-web"},
+			Audience:  []string{tokenAudience},
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
 			ID:        uuid.NewString(), // jti
 		},
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
 }
 
 /*
@@ -64,23 +181,10 @@ func GenerateAccessToken(
 =====================
 */
 
-func GenerateRefreshToken(
-	secret string,
-	expiry time.Duration,
-) (string, error) {
-
-	now := time.Now()
-
-	claims := jwt.RegisteredClaims{
-		Issuer:    "ticketing-api",
-		Audience:  []string{"ticketing-web"},
-		IssuedAt:  jwt.NewNumericDate(now),
-		ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
-		ID:        uuid.NewString(), // jti (used for rotation detection)
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+// Opaque random value: the server only ever looks it up by hash, and
+// expiry / rotation live on the refresh_tokens row
+func GenerateRefreshToken() (string, error) {
+	return GenerateRandomToken(48)
 }
 
 /*
@@ -89,16 +193,15 @@ func GenerateRefreshToken(
 =====================
 */
 
-func ValidateToken(tokenStr, secret string) (*Claims, error) {
+// Verification key is picked by the token's `kid`
+func ValidateToken(tokenStr string, keys *KeyRing) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(
 		tokenStr,
 		&Claims{},
-		func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			return []byte(secret), nil
-		},
+		keys.Keyfunc,
+		jwt.WithValidMethods(keys.Methods()),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithAudience(tokenAudience),
 	)
 
 	if err != nil {
@@ -114,6 +217,11 @@ func ValidateToken(tokenStr, secret string) (*Claims, error) {
 	if _, err := uuid.Parse(claims.UserID); err != nil {
 		return nil, errors.New("invalid user_id in token")
 	}
+	if claims.Actor != nil {
+		if _, err := uuid.Parse(claims.Actor.Subject); err != nil {
+			return nil, errors.New("invalid actor in token")
+		}
+	}
 
 	return claims, nil
 }
@@ -7,6 +7,11 @@ import (
 	"github.com/google/uuid"
 )
 
+// Between the password step and the second factor. Signed with the key
+// ring like access tokens, but for its own audience, so neither kind is
+// accepted in place of the other.
+const twoFAAudience = "ticketing-2fa"
+
 type TwoFATokenClaims struct {
 	UserID   uuid.UUID `json:"user_id"`
 	Remember bool      `json:"remember"`
@@ -16,32 +21,32 @@ type TwoFATokenClaims struct {
 func Generate2FAToken(
 	userID uuid.UUID,
 	remember bool,
-	secret string,
+	keys *KeyRing,
 ) (string, error) {
 
 	claims := TwoFATokenClaims{
 		UserID:   userID,
 		Remember: remember,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   userID.String(),
+			Audience:  []string{twoFAAudience},
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	return jwt.NewWithClaims(
-		jwt.SigningMethodHS256,
-		claims,
-	).SignedString([]byte(secret))
+	return keys.Sign(claims)
 }
 
-func Parse2FAToken(raw, secret string) (*TwoFATokenClaims, error) {
+func Parse2FAToken(raw string, keys *KeyRing) (*TwoFATokenClaims, error) {
 	token, err := jwt.ParseWithClaims(
 		raw,
 		&TwoFATokenClaims{},
-		func(t *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
-		},
-		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}),
+		keys.Keyfunc,
+		jwt.WithValidMethods(keys.Methods()),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithAudience(twoFAAudience),
 	)
 
 	if err != nil {
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"rbac/models"
+)
+
+// Same keys, different iss / aud: not one of our access tokens
+func TestValidateTokenChecksIssuerAndAudience(t *testing.T) {
+	keys, err := LoadKeyRing("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{ID: uuid.New(), Email: "a@example.com", Role: models.RoleSupport}
+
+	good := newAccessClaims(user, time.Minute, AuthContext{})
+	token, err := keys.Sign(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateToken(token, keys); err != nil {
+		t.Fatalf("access token rejected: %v", err)
+	}
+
+	for name, mutate := range map[string]func(*Claims){
+		"issuer":      func(c *Claims) { c.Issuer = "someone-else" },
+		"audience":    func(c *Claims) { c.Audience = jwt.ClaimStrings{"another-app"} },
+		"no audience": func(c *Claims) { c.Audience = nil },
+	} {
+		claims := newAccessClaims(user, time.Minute, AuthContext{})
+		mutate(&claims)
+
+		token, err := keys.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ValidateToken(token, keys); err == nil {
+			t.Errorf("%s: token accepted", name)
+		}
+	}
+}
+
+// The 2FA step token is key-ring signed for its own audience: it can't be
+// forged with a shared secret or swapped with an access token
+func TestTwoFATokenIsItsOwnKind(t *testing.T) {
+	keys, err := LoadKeyRing("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{ID: uuid.New(), Email: "a@example.com", Role: models.RoleSupport}
+
+	twoFA, err := Generate2FAToken(user.ID, true, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := Parse2FAToken(twoFA, keys)
+	if err != nil || claims.UserID != user.ID || !claims.Remember {
+		t.Fatalf("2fa token round trip: %+v, %v", claims, err)
+	}
+	if _, err := ValidateToken(twoFA, keys); err == nil {
+		t.Fatal("2fa token accepted as an access token")
+	}
+
+	access, err := GenerateAccessToken(user, keys, time.Minute, AuthContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse2FAToken(access, keys); err == nil {
+		t.Fatal("access token accepted as a 2fa token")
+	}
+
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, TwoFATokenClaims{
+		UserID: user.ID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Audience:  []string{twoFAAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}).SignedString([]byte("access-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse2FAToken(forged, keys); err == nil {
+		t.Fatal("HS256 2fa token accepted")
+	}
+}
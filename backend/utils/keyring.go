@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+/*
+=====================
+ Signing Keys
+=====================
+*/
+
+// One entry per `kid`. Retired keys keep only the public half so tokens
+// they signed stay valid until expiry (rotation without mass logout).
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod // RS256 or EdDSA
+	Private crypto.Signer     // nil = verify-only
+	Public  crypto.PublicKey
+}
+
+type KeyRing struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// LoadKeyRing reads every <kid>.pem in dir. Files may hold a PKCS#8 /
+// PKCS#1 private key (RSA or Ed25519) or a PKIX public key (retired key).
+// activeKID picks the signing key; default is the last private key by name.
+// An empty dir yields an in-memory Ed25519 key (development only).
+func LoadKeyRing(dir string, activeKID string) (*KeyRing, error) {
+	ring := &KeyRing{keys: map[string]*SigningKey{}}
+
+	if dir == "" {
+		log.Println("⚠️ JWT_KEY_DIR not set, using an ephemeral signing key")
+		return ring, ring.addEphemeral()
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		key, err := parseSigningKey(kid, raw)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", file, err)
+		}
+
+		ring.keys[kid] = key
+		if key.Private != nil && activeKID == "" {
+			ring.active = key
+		}
+	}
+
+	if activeKID != "" {
+		key, ok := ring.keys[activeKID]
+		if !ok || key.Private == nil {
+			return nil, fmt.Errorf("active jwt key %q not found", activeKID)
+		}
+		ring.active = key
+	}
+
+	if ring.active == nil {
+		return nil, errors.New("no private jwt signing key in " + dir)
+	}
+
+	return ring, nil
+}
+
+func (r *KeyRing) addEphemeral() error {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	kid, err := GenerateRandomToken(8)
+	if err != nil {
+		return err
+	}
+
+	key := &SigningKey{
+		ID:      "dev-" + kid,
+		Method:  jwt.SigningMethodEdDSA,
+		Private: priv,
+		Public:  pub,
+	}
+	r.keys[key.ID] = key
+	r.active = key
+	return nil
+}
+
+func parseSigningKey(kid string, raw []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("no PEM block")
+	}
+
+	var parsed interface{}
+	var err error
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, errors.New("unsupported PEM type " + block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodRS256, Private: k, Public: &k.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodEdDSA, Private: k, Public: k.Public()}, nil
+	case *rsa.PublicKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodRS256, Public: k}, nil
+	case ed25519.PublicKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodEdDSA, Public: k}, nil
+	}
+
+	return nil, errors.New("unsupported key type (want RSA or Ed25519)")
+}
+
+/*
+=====================
+ Sign / Verify
+=====================
+*/
+
+func (r *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(r.active.Method, claims)
+	token.Header["kid"] = r.active.ID
+	return token.SignedString(r.active.Private)
+}
+
+// Keyfunc for jwt.Parse*: key is chosen by `kid`, algorithm must match it
+func (r *KeyRing) Keyfunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	key, ok := r.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+
+	return key.Public, nil
+}
+
+func (r *KeyRing) Methods() []string {
+	return []string{
+		jwt.SigningMethodRS256.Alg(),
+		jwt.SigningMethodEdDSA.Alg(),
+	}
+}
+
+/*
+=====================
+ JWKS
+=====================
+*/
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Public halves of every key (active + retired)
+func (r *KeyRing) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+
+	ids := make([]string, 0, len(r.keys))
+	for id := range r.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		key := r.keys[id]
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
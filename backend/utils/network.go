@@ -0,0 +1,18 @@
+package utils
+
+import "net"
+
+// Coarse network an address belongs to (/24 for IPv4, /48 for IPv6), so a
+// DHCP renewal on the same ISP line doesn't look like a new location.
+func IPRange(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
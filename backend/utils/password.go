@@ -1,29 +1,87 @@
 package utils
 
 import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"os"
+	"strings"
 	"unicode"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
+
+	"rbac/config"
+)
+
+const (
+	HashArgon2id = "argon2id"
+	HashBcrypt   = "bcrypt"
+
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
 )
 
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 12)
-	return string(bytes), err
+/*
+=====================
+ Password Policy
+=====================
+ One place for strength rules and hashing, driven by config.Password
+*/
+
+type PasswordPolicy struct {
+	cfg       config.PasswordConfig
+	blocklist map[string]struct{}
 }
 
-func CheckPassword(password, hash string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+func NewPasswordPolicy(cfg config.PasswordConfig) (*PasswordPolicy, error) {
+	if cfg.Algorithm != HashArgon2id && cfg.Algorithm != HashBcrypt {
+		return nil, fmt.Errorf("unknown password hash algorithm %q", cfg.Algorithm)
+	}
+
+	p := &PasswordPolicy{
+		cfg:       cfg,
+		blocklist: map[string]struct{}{},
+	}
+
+	if cfg.BlocklistFile != "" {
+		if err := p.loadBlocklist(cfg.BlocklistFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
 }
 
+// One password per line, '#' comments, matched case-insensitively
+func (p *PasswordPolicy) loadBlocklist(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("password blocklist: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.blocklist[strings.ToLower(line)] = struct{}{}
+	}
 
+	return scanner.Err()
+}
 
-func ValidatePasswordStrength(password string) error {
-	if len(password) < 8 {
-		return errors.New("password must be at least 8 characters")
+func (p *PasswordPolicy) Validate(password string) error {
+	if len([]rune(password)) < p.cfg.MinLength {
+		return fmt.Errorf("password must be at least %d characters", p.cfg.MinLength)
 	}
 
-	var hasUpper, hasLower, hasNumber bool
+	var hasUpper, hasLower, hasNumber, hasSymbol bool
 
 	for _, c := range password {
 		switch {
@@ -33,13 +91,160 @@ func ValidatePasswordStrength(password string) error {
 			hasLower = true
 		case unicode.IsDigit(c):
 			hasNumber = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c) || unicode.IsSpace(c):
+			hasSymbol = true
 		}
 	}
 
-	if !hasUpper || !hasLower || !hasNumber {
-		return errors.New("password must contain upper, lower and number")
+	var missing []string
+	if p.cfg.RequireUpper && !hasUpper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if p.cfg.RequireLower && !hasLower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if p.cfg.RequireDigit && !hasNumber {
+		missing = append(missing, "a number")
+	}
+	if p.cfg.RequireSymbol && !hasSymbol {
+		missing = append(missing, "a symbol")
+	}
+	if len(missing) > 0 {
+		return errors.New("password must contain " + strings.Join(missing, ", "))
+	}
+
+	if _, blocked := p.blocklist[strings.ToLower(password)]; blocked {
+		return errors.New("password is too common")
 	}
 
 	return nil
 }
 
+/*
+=====================
+ Hashing
+=====================
+*/
+
+func (p *PasswordPolicy) Hash(password string) (string, error) {
+	if p.cfg.Algorithm == HashBcrypt {
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), p.cfg.BcryptCost)
+		return string(bytes), err
+	}
+
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey(
+		[]byte(password),
+		salt,
+		p.cfg.Argon2Time,
+		p.cfg.Argon2Memory,
+		p.cfg.Argon2Threads,
+		argon2KeyLen,
+	)
+
+	// PHC string format
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		p.cfg.Argon2Memory,
+		p.cfg.Argon2Time,
+		p.cfg.Argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Hash made with another algorithm or weaker parameters than configured
+func (p *PasswordPolicy) NeedsRehash(hash string) bool {
+	if p.cfg.Algorithm == HashBcrypt {
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost < p.cfg.BcryptCost
+	}
+
+	params, _, _, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+
+	return params.memory < p.cfg.Argon2Memory ||
+		params.time < p.cfg.Argon2Time ||
+		params.threads < p.cfg.Argon2Threads
+}
+
+// Accepts Argon2id (PHC) and legacy bcrypt hashes
+func CheckPassword(password, hash string) error {
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	}
+
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return err
+	}
+
+	candidate := argon2.IDKey(
+		[]byte(password),
+		salt,
+		params.time,
+		params.memory,
+		params.threads,
+		uint32(len(key)),
+	)
+
+	if subtle.ConstantTimeCompare(candidate, key) != 1 {
+		return errors.New("password mismatch")
+	}
+	return nil
+}
+
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+func decodeArgon2id(hash string) (*argon2Params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=…,t=…,p=…", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, errors.New("unsupported argon2 version")
+	}
+
+	var params argon2Params
+	if _, err := fmt.Sscanf(
+		parts[3],
+		"m=%d,t=%d,p=%d",
+		&params.memory,
+		&params.time,
+		&params.threads,
+	); err != nil {
+		return nil, nil, nil, errors.New("invalid argon2id parameters")
+	}
+
+	// argon2 panics on zero time / threads
+	if params.memory == 0 || params.time == 0 || params.threads == 0 {
+		return nil, nil, nil, errors.New("invalid argon2id parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(salt) != argon2SaltLen {
+		return nil, nil, nil, errors.New("invalid argon2id salt")
+	}
+
+	// An empty key would match any password
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) != argon2KeyLen {
+		return nil, nil, nil, errors.New("invalid argon2id key")
+	}
+
+	return &params, salt, key, nil
+}
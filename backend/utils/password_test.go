@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"rbac/config"
+)
+
+// Cheap parameters: these tests are about the format, not the strength
+func argon2Policy(t *testing.T, time, memory uint32, threads uint8) *PasswordPolicy {
+	t.Helper()
+
+	p, err := NewPasswordPolicy(config.PasswordConfig{
+		Algorithm:     HashArgon2id,
+		Argon2Time:    time,
+		Argon2Memory:  memory,
+		Argon2Threads: threads,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestArgon2idPHCRoundTrip(t *testing.T) {
+	cases := []struct {
+		time    uint32
+		memory  uint32
+		threads uint8
+	}{
+		{1, 64, 1},
+		{2, 128, 2},
+		{3, 256, 4},
+	}
+
+	for _, tc := range cases {
+		name := fmt.Sprintf("m=%d,t=%d,p=%d", tc.memory, tc.time, tc.threads)
+		t.Run(name, func(t *testing.T) {
+			p := argon2Policy(t, tc.time, tc.memory, tc.threads)
+
+			hash, err := p.Hash("correct horse")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(hash, "$argon2id$v=19$"+name+"$") {
+				t.Fatalf("hash = %s", hash)
+			}
+
+			params, salt, key, err := decodeArgon2id(hash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if params.time != tc.time || params.memory != tc.memory || params.threads != tc.threads {
+				t.Fatalf("decoded params = %+v", params)
+			}
+			if len(salt) != argon2SaltLen || len(key) != argon2KeyLen {
+				t.Fatalf("salt %d / key %d bytes", len(salt), len(key))
+			}
+
+			if err := CheckPassword("correct horse", hash); err != nil {
+				t.Fatalf("own hash rejected: %v", err)
+			}
+			if CheckPassword("wrong horse", hash) == nil {
+				t.Fatal("wrong password accepted")
+			}
+			if p.NeedsRehash(hash) {
+				t.Fatal("hash with the configured parameters flagged for rehash")
+			}
+
+			// Any parameter raised in config → upgrade on next login
+			for _, stronger := range []*PasswordPolicy{
+				argon2Policy(t, tc.time+1, tc.memory, tc.threads),
+				argon2Policy(t, tc.time, tc.memory*2, tc.threads),
+				argon2Policy(t, tc.time, tc.memory, tc.threads+1),
+			} {
+				if !stronger.NeedsRehash(hash) {
+					t.Fatalf("weaker hash not flagged under %+v", stronger.cfg)
+				}
+			}
+		})
+	}
+}
+
+// Malformed hashes are refused cleanly: no panic in argon2, no empty key
+// that any password would match
+func TestArgon2idRejectsMalformedHashes(t *testing.T) {
+	p := argon2Policy(t, 1, 64, 1)
+
+	salt := base64.RawStdEncoding.EncodeToString(make([]byte, argon2SaltLen))
+	key := base64.RawStdEncoding.EncodeToString(make([]byte, argon2KeyLen))
+	short := base64.RawStdEncoding.EncodeToString(make([]byte, 4))
+	phc := func(version, params, salt, key string) string {
+		return "$argon2id$" + version + "$" + params + "$" + salt + "$" + key
+	}
+
+	if _, _, _, err := decodeArgon2id(phc("v=19", "m=64,t=1,p=1", salt, key)); err != nil {
+		t.Fatalf("well-formed hash rejected: %v", err)
+	}
+
+	cases := map[string]string{
+		"other variant":   "$argon2i$v=19$m=64,t=1,p=1$" + salt + "$" + key,
+		"missing key":     "$argon2id$v=19$m=64,t=1,p=1$" + salt,
+		"old version":     phc("v=16", "m=64,t=1,p=1", salt, key),
+		"no version":      phc("19", "m=64,t=1,p=1", salt, key),
+		"zero memory":     phc("v=19", "m=0,t=1,p=1", salt, key),
+		"zero time":       phc("v=19", "m=64,t=0,p=1", salt, key),
+		"zero threads":    phc("v=19", "m=64,t=1,p=0", salt, key),
+		"garbled params":  phc("v=19", "t=1,m=64,p=1", salt, key),
+		"short salt":      phc("v=19", "m=64,t=1,p=1", short, key),
+		"salt not base64": phc("v=19", "m=64,t=1,p=1", "!!!!", key),
+		"short key":       phc("v=19", "m=64,t=1,p=1", salt, short),
+		"empty key":       phc("v=19", "m=64,t=1,p=1", salt, ""),
+	}
+
+	for name, hash := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, _, _, err := decodeArgon2id(hash); err == nil {
+				t.Fatal("decoded")
+			}
+			if CheckPassword("", hash) == nil {
+				t.Fatal("password accepted")
+			}
+			if !p.NeedsRehash(hash) {
+				t.Fatal("not flagged for rehash")
+			}
+		})
+	}
+}
+
+func TestNeedsRehashAcrossAlgorithms(t *testing.T) {
+	argon := argon2Policy(t, 1, 64, 1)
+	bcryptPolicy, err := NewPasswordPolicy(config.PasswordConfig{Algorithm: HashBcrypt, BcryptCost: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("correct horse"), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := argon.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CheckPassword("correct horse", string(legacy)); err != nil {
+		t.Fatalf("legacy bcrypt hash rejected: %v", err)
+	}
+	if !argon.NeedsRehash(string(legacy)) {
+		t.Fatal("bcrypt hash not upgraded under argon2id")
+	}
+	if !bcryptPolicy.NeedsRehash(string(legacy)) {
+		t.Fatal("bcrypt hash below the configured cost not upgraded")
+	}
+	if !bcryptPolicy.NeedsRehash(current) {
+		t.Fatal("argon2id hash not flagged under bcrypt")
+	}
+}
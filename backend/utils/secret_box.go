@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+/*
+=====================
+ Secrets at rest
+=====================
+ AES-256-GCM, key derived from config.
+ Output: base64(nonce || ciphertext)
+*/
+
+func EncryptSecret(plain, key string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func DecryptSecret(encoded, key string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	if len(raw) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted secret")
+	}
+
+	nonce, data := raw[:gcm.NonceSize()], raw[gcm.NonceSize():]
+
+	plain, err := gcm.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain), nil
+}
+
+func newGCM(key string) (cipher.AEAD, error) {
+	if key == "" {
+		return nil, errors.New("encryption key not configured")
+	}
+
+	sum := sha256.Sum256([]byte(key))
+
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
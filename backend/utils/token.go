@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"strings"
 )
 
 
@@ -20,3 +21,55 @@ func GenerateRandomToken(length int) (string, error) {
 	}
 	return hex.EncodeToString(bytes)[:length], nil
 }
+
+// Recovery codes: xxxxx-xxxxx (no ambiguous 0/o/1/l)
+const recoveryAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+
+func GenerateRecoveryCode() (string, error) {
+	bytes := make([]byte, 10)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	out := make([]byte, 0, 11)
+	for i, b := range bytes {
+		if i == 5 {
+			out = append(out, '-')
+		}
+		out = append(out, recoveryAlphabet[int(b)%len(recoveryAlphabet)])
+	}
+
+	return string(out), nil
+}
+
+// Users type codes with/without dash, any case
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// API keys: rbk_<prefix>_<secret>. The prefix is stored in clear for
+// lookup / display, the full key only as a SHA-256 hash.
+const apiKeyTag = "rbk"
+
+func GenerateAPIKey() (raw string, prefix string, err error) {
+	prefix, err = GenerateRandomToken(12)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err := GenerateRandomToken(48)
+	if err != nil {
+		return "", "", err
+	}
+
+	return apiKeyTag + "_" + prefix + "_" + secret, prefix, nil
+}
+
+func ParseAPIKey(raw string) (prefix string, ok bool) {
+	parts := strings.Split(raw, "_")
+	if len(parts) != 3 || parts[0] != apiKeyTag || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
@@ -6,6 +6,7 @@ import RequireGuest from "./auth/RequireGuest";
 import LoginPage from "./pages/Login-page";
 import VerifyOTP from "./pages/VerifyOTP";
 import ResetPassword from "./pages/ResetPassword";
+import VerifyEmail from "./pages/VerifyEmail";
 import ForgotPasswordPage from "./pages/ForgotPasswordPage";
 
 import Dashboard from "./pages/Dashboard";
@@ -59,6 +60,7 @@ function App() {
 
       <Route path="/reset-password" element={<ResetPassword />} />
       <Route path="/forgot-password" element={<ForgotPasswordPage />} />
+      <Route path="/verify-email" element={<VerifyEmail />} />
 
       {/* =====================
           PROTECTED (AUTH)
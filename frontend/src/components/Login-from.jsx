@@ -53,7 +53,7 @@ export function LoginForm({ className, ...props }) {
     } catch (err) {
       if (
         err.response?.status === 403 &&
-        err.response?.data?.error === "password_reset_required"
+        err.response?.data?.code === "password_reset_required"
       ) {
         setError("You must reset your password before logging in.")
       } else {
@@ -36,7 +36,7 @@ const submit = async (e) => {
   } catch (err) {
     if (
       err.response?.status === 403 &&
-      err.response?.data?.error === "password_reset_required"
+      err.response?.data?.code === "password_reset_required"
     ) {
       setErr("You must reset your password before logging in.");
     } else {
@@ -0,0 +1,69 @@
+import { useEffect, useRef, useState } from "react";
+import { Link, useSearchParams } from "react-router-dom";
+import { MailCheck } from "lucide-react";
+import api from "../api/axios";
+
+export default function VerifyEmail() {
+  const [params] = useSearchParams();
+  const token = params.get("token");
+
+  const [status, setStatus] = useState(token ? "loading" : "error");
+  const [message, setMessage] = useState(
+    token ? "" : "Invalid or expired verification link"
+  );
+
+  // Links are single-use: don't fire twice under StrictMode
+  const sent = useRef(false);
+
+  useEffect(() => {
+    if (!token || sent.current) return;
+    sent.current = true;
+
+    api
+      .post("/auth/verify-email", { token })
+      .then((res) => {
+        setStatus("done");
+        setMessage(
+          res.data.purpose === "change"
+            ? `Your account now uses ${res.data.email}.`
+            : `${res.data.email} is verified.`
+        );
+      })
+      .catch((err) => {
+        setStatus("error");
+        setMessage(
+          err.response?.data?.error || "Invalid or expired verification link"
+        );
+      });
+  }, [token]);
+
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-slate-50 px-4">
+      <div className="w-full max-w-md rounded-2xl bg-white shadow-xl p-6 text-center">
+        <div className="mx-auto flex h-12 w-12 items-center justify-center rounded-full bg-blue-600 text-white">
+          <MailCheck size={20} />
+        </div>
+        <h1 className="mt-4 text-xl font-semibold">Email verification</h1>
+
+        {status === "loading" && (
+          <p className="mt-2 text-sm text-slate-500">Verifying…</p>
+        )}
+        {status === "done" && (
+          <p className="mt-2 text-sm text-green-700">{message}</p>
+        )}
+        {status === "error" && (
+          <div className="mt-4 rounded-lg bg-red-50 p-3 text-sm text-red-600">
+            {message}
+          </div>
+        )}
+
+        <Link
+          to="/login"
+          className="mt-6 inline-block text-sm font-medium text-blue-600 hover:underline"
+        >
+          Go to login
+        </Link>
+      </div>
+    </div>
+  );
+}